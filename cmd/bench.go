@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/canvas"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitable"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitrecord"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/render"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scene"
+)
+
+// rayCounter counts the rays traced against the hitable it wraps. Every render worker
+// owns its sampler, so the rays are counted per sampler to keep the workers from
+// contending for a shared counter, and the counts are added up after the render.
+type rayCounter struct {
+	hitable.Hitable
+	counts sync.Map
+}
+
+// rayCount is padded to a cache line so that the counters of different workers do not share one.
+type rayCount struct {
+	n int64
+	_ [56]byte
+}
+
+func (rc *rayCounter) Hit(r ray.Ray, tMin float64, tMax float64, s sampler.Sampler) (*hitrecord.HitRecord, material.Material, bool) {
+	c, ok := rc.counts.Load(s)
+	if !ok {
+		c, _ = rc.counts.LoadOrStore(s, &rayCount{})
+	}
+	c.(*rayCount).n++
+	return rc.Hitable.Hit(r, tMin, tMax, s)
+}
+
+// total returns the number of rays traced by all the workers. It must only be called
+// once the render has completed.
+func (rc *rayCounter) total() int64 {
+	total := int64(0)
+	rc.counts.Range(func(_, c interface{}) bool {
+		total += c.(*rayCount).n
+		return true
+	})
+
+	return total
+}
+
+// runBench renders the selected scene with a fixed seed using different numbers of workers
+// and reports the throughput of the fastest run for each of them.
+func runBench(args []string) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	sf := newSceneFlags(fs, 200, 200)
+	inf := newIntegratorFlags(fs, 16)
+	workers := fs.String("workers", defaultWorkers(), "comma separated list of the numbers of workers to measure")
+	runs := fs.Int("runs", 3, "the number of runs for each number of workers")
+	fs.Parse(args)
+
+	counts := []int{}
+	for _, field := range strings.Split(*workers, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || n < 1 {
+			log.Fatalf("invalid number of workers %q", field)
+		}
+		counts = append(counts, n)
+	}
+	if *runs < 1 {
+		log.Fatalf("the number of runs must be positive, got %v", *runs)
+	}
+
+	doc := sf.document()
+	s := sf.scene(doc)
+	samples := float64(*sf.width * *sf.height * *inf.samples)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	defer w.Flush()
+	fmt.Fprintf(w, "workers\ttime\trays/s\tsamples/s\tspeedup\t\n")
+
+	var baseline time.Duration
+	for _, n := range counts {
+		opts := &render.Options{
+			NumSamples: *inf.samples,
+			NumWorkers: n,
+			Integrator: inf.newIntegrator(),
+			Seed:       *sf.seed,
+			Sampler:    inf.samplerType(),
+		}
+
+		var best time.Duration
+		var rays int64
+		for i := 0; i < *runs; i++ {
+			// Every run gets new samplers so the counter is created again as well.
+			counter := &rayCounter{Hitable: s.World()}
+			counted := scene.New(hitable.NewSlice([]hitable.Hitable{counter}), s.Lights(), s.Camera())
+			film := canvas.NewFilm(*sf.width, *sf.height)
+			start := time.Now()
+			if err := render.RenderContext(context.Background(), counted, film, opts); err != nil {
+				log.Fatalf("render failed; %v", err)
+			}
+			if elapsed := time.Since(start); i == 0 || elapsed < best {
+				best = elapsed
+				rays = counter.total()
+			}
+		}
+		if baseline == 0 {
+			baseline = best
+		}
+
+		seconds := best.Seconds()
+		fmt.Fprintf(w, "%v\t%v\t%.0f\t%.0f\t%.2fx\t\n", n, best.Round(time.Millisecond),
+			float64(rays)/seconds, samples/seconds, baseline.Seconds()/seconds)
+	}
+}
+
+// defaultWorkers returns the powers of two up to the number of CPUs followed by the number of CPUs.
+func defaultWorkers() string {
+	counts := []string{}
+	n := 1
+	for ; n < runtime.NumCPU(); n *= 2 {
+		counts = append(counts, strconv.Itoa(n))
+	}
+	counts = append(counts, strconv.Itoa(runtime.NumCPU()))
+
+	return strings.Join(counts, ",")
+}
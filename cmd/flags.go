@@ -0,0 +1,216 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/integrator"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/render"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scene"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scenefile"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scenes"
+)
+
+// sceneFlags holds the flags that select the scene and the image size.
+type sceneFlags struct {
+	fs     *flag.FlagSet
+	name   *string
+	file   *string
+	width  *int
+	height *int
+	seed   *int64
+	// sceneSeed is the seed used to generate the scene. It defaults to seed.
+	sceneSeed *int64
+}
+
+func newSceneFlags(fs *flag.FlagSet, width int, height int) *sceneFlags {
+	return &sceneFlags{
+		fs:        fs,
+		name:      fs.String("scene", scenes.DefaultScene, "the built-in scene to use: "+strings.Join(scenes.Names(), ", ")),
+		file:      fs.String("scene-file", "", "use the scene described by this file instead of a built-in scene; its render settings are used for the flags that are not set"),
+		width:     fs.Int("x", width, "output image x size"),
+		height:    fs.Int("y", height, "output image y size"),
+		seed:      fs.Int64("seed", 0, "the seed used to generate the scene and the sample values"),
+		sceneSeed: fs.Int64("scene-seed", 0, "the seed used to generate the scene if it differs from -seed, so that renders with different seeds can be merged"),
+	}
+}
+
+// document returns the description of the selected scene. It must be called after the flags
+// are parsed and before the values of the flags that scene files can set are used.
+func (sf *sceneFlags) document() *scenefile.Document {
+	doc, err := sf.load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return doc
+}
+
+// load is like document but returns an error instead of exiting.
+func (sf *sceneFlags) load() (*scenefile.Document, error) {
+	var doc *scenefile.Document
+	if *sf.file != "" {
+		var err error
+		doc, err = scenefile.Load(*sf.file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load scene; %w", err)
+		}
+		applySettings(sf.fs, doc.Render)
+	}
+
+	if !isSet(sf.fs, "scene-seed") {
+		sf.fs.Set("scene-seed", strconv.FormatInt(*sf.seed, 10))
+	}
+	// Scene construction is single threaded and uses the global generator.
+	rand.Seed(*sf.sceneSeed)
+
+	if doc == nil {
+		entry, ok := scenes.Lookup(*sf.name)
+		if !ok {
+			return nil, fmt.Errorf("unknown scene %q, expected one of %v", *sf.name, strings.Join(scenes.Names(), ", "))
+		}
+		doc = entry.Document()
+	}
+
+	return doc, nil
+}
+
+// scene builds the scene described by the document using the aspect ratio of the image.
+func (sf *sceneFlags) scene(doc *scenefile.Document) *scene.Scene {
+	s, err := doc.Scene(float64(*sf.width) / float64(*sf.height))
+	if err != nil {
+		log.Fatalf("failed to build scene; %v", err)
+	}
+
+	return s
+}
+
+// isSet reports whether the flag was set.
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+
+	return set
+}
+
+// applySettings uses the render settings stored with a scene for the flags that were not set.
+// Settings without a matching flag are ignored.
+func applySettings(fs *flag.FlagSet, r *scenefile.Render) {
+	if r == nil {
+		return
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	for _, s := range []struct {
+		flag  string
+		value string
+		ok    bool
+	}{
+		{"x", strconv.Itoa(r.Width), r.Width > 0},
+		{"y", strconv.Itoa(r.Height), r.Height > 0},
+		{"samples", strconv.Itoa(r.Samples), r.Samples > 0},
+		{"max-depth", strconv.Itoa(r.MaxDepth), r.MaxDepth > 0},
+		{"integrator", r.Integrator, r.Integrator != ""},
+		{"sampler", r.Sampler, r.Sampler != ""},
+		{"seed", strconv.FormatInt(r.Seed, 10), r.Seed != 0},
+	} {
+		if s.ok && !set[s.flag] && fs.Lookup(s.flag) != nil {
+			if err := fs.Set(s.flag, s.value); err != nil {
+				log.Fatalf("invalid render setting for %v; %v", s.flag, err)
+			}
+		}
+	}
+}
+
+// integratorFlags holds the flags that configure how samples are computed.
+type integratorFlags struct {
+	samples    *int
+	integrator *string
+	maxDepth   *int
+	rrDepth    *int
+	sampler    *string
+}
+
+func newIntegratorFlags(fs *flag.FlagSet, samples int) *integratorFlags {
+	return &integratorFlags{
+		samples:    fs.Int("samples", samples, "number of samples per ray"),
+		integrator: fs.String("integrator", "path", "the integrator to use: path, mis, direct, ao or normals"),
+		maxDepth:   fs.Int("max-depth", integrator.DefaultMaxDepth, "the maximum number of bounces"),
+		rrDepth:    fs.Int("rr-depth", 5, "the number of bounces after which paths are terminated by Russian roulette, 0 disables it"),
+		sampler:    fs.String("sampler", "random", "the sampler to use: random, stratified, halton or sobol"),
+	}
+}
+
+func (inf *integratorFlags) newIntegrator() integrator.Integrator {
+	switch *inf.integrator {
+	case "path":
+		return integrator.NewPathTracer(*inf.maxDepth, *inf.rrDepth)
+	case "mis":
+		return integrator.NewMISPathTracer(integrator.HeuristicPower, *inf.maxDepth, *inf.rrDepth)
+	case "direct":
+		return integrator.NewDirectLighting(*inf.maxDepth)
+	case "ao":
+		return integrator.NewAmbientOcclusion(16, 100.0)
+	case "normals":
+		return integrator.NewNormals()
+	default:
+		log.Fatalf("unknown integrator %q", *inf.integrator)
+	}
+
+	return nil
+}
+
+func (inf *integratorFlags) samplerType() int {
+	switch *inf.sampler {
+	case "random":
+		return sampler.TypeRandom
+	case "stratified":
+		return sampler.TypeStratified
+	case "halton":
+		return sampler.TypeHalton
+	case "sobol":
+		return sampler.TypeSobol
+	default:
+		log.Fatalf("unknown sampler %q", *inf.sampler)
+	}
+
+	return 0
+}
+
+// adaptiveFlags holds the flags that configure adaptive sampling.
+type adaptiveFlags struct {
+	adaptive    *bool
+	minSamples  *int
+	passSamples *int
+	threshold   *float64
+}
+
+func newAdaptiveFlags(fs *flag.FlagSet) *adaptiveFlags {
+	return &adaptiveFlags{
+		adaptive:    fs.Bool("adaptive", false, "stop sampling pixels once their error is below the threshold; -samples is then the maximum"),
+		minSamples:  fs.Int("min-samples", 16, "the number of samples every pixel receives in adaptive mode"),
+		passSamples: fs.Int("pass-samples", 16, "the number of samples added to unconverged pixels on every adaptive pass"),
+		threshold:   fs.Float64("threshold", 0.01, "the error below which a pixel stops receiving samples in adaptive mode"),
+	}
+}
+
+// apply sets the adaptive sampling options.
+func (af *adaptiveFlags) apply(opts *render.Options) {
+	opts.Adaptive = *af.adaptive
+	opts.MinSamples = *af.minSamples
+	opts.PassSamples = *af.passSamples
+	opts.Threshold = *af.threshold
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitable"
+)
+
+// runInfo prints statistics about the selected scene.
+func runInfo(args []string) {
+	fs := flag.NewFlagSet("info", flag.ExitOnError)
+	sf := newSceneFlags(fs, 500, 500)
+	fs.Parse(args)
+
+	doc := sf.document()
+	s := sf.scene(doc)
+	stats := hitable.NewStats(s.World())
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "primitives:\t%v\n", stats.NumPrimitives())
+	types := make([]string, 0, len(stats.Primitives))
+	for t := range stats.Primitives {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		fmt.Fprintf(w, "  %v:\t%v\n", t, stats.Primitives[t])
+	}
+	fmt.Fprintf(w, "BVH nodes:\t%v\n", stats.BVHNodes)
+	fmt.Fprintf(w, "BVH depth:\t%v\n", stats.BVHDepth)
+	if box, ok := s.World().BoundingBox(0, 1); ok {
+		min, max := box.Min(), box.Max()
+		fmt.Fprintf(w, "bounding box:\t(%.4g, %.4g, %.4g) - (%.4g, %.4g, %.4g)\n", min.X, min.Y, min.Z, max.X, max.Y, max.Z)
+	} else {
+		fmt.Fprintf(w, "bounding box:\tunbounded\n")
+	}
+	fmt.Fprintf(w, "lights:\t%v\n", stats.Lights)
+	fmt.Fprintf(w, "materials:\t%v\n", len(doc.Materials))
+	fmt.Fprintf(w, "textures:\t%v\n", len(doc.Textures))
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scenefile"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scenes"
+)
+
+// runList prints the built-in scenes and the types that scene files can use.
+func runList(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	fs.Parse(args)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "scenes:")
+	for _, e := range scenes.Entries() {
+		fmt.Fprintf(w, "  %v\t%v\n", e.Name, e.Description)
+	}
+	w.Flush()
+
+	fmt.Printf("\nmaterials: %v\n", strings.Join(scenefile.MaterialTypes(), ", "))
+	fmt.Printf("textures: %v\n", strings.Join(scenefile.TextureTypes(), ", "))
+	fmt.Printf("hitables: %v\n", strings.Join(scenefile.HitableTypes(), ", "))
+}
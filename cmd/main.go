@@ -1,37 +1,50 @@
 package main
 
 import (
-	"flag"
 	"fmt"
-	"image"
-	"math/rand"
-	"time"
-
-	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/render"
-	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scenes"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
-func main() {
-
-	numWorkers := flag.Int("num-workers", 1, "the number of worker threads")
-	nx := flag.Int("x", 500, "output image x size")
-	ny := flag.Int("y", 500, "output image y size")
-	ns := flag.Int("samples", 1000, "number of samples per ray")
-
-	flag.Parse()
+// commands maps the subcommand names to their implementations.
+var commands = []struct {
+	name        string
+	description string
+	run         func(args []string)
+}{
+	{"render", "render an image of a scene", runRender},
+	{"list", "list the built-in scenes and the types scene files can use", runList},
+	{"info", "print statistics about a scene", runInfo},
+	{"merge", "combine partial renders of the same scene made with different seeds", runMerge},
+	{"worker", "render tiles for render -farm", runWorker},
+	{"bench", "time fixed-seed renders with different numbers of workers", runBench},
+}
 
-	canvas := image.NewNRGBA(image.Rectangle{Min: image.Point{X: 0, Y: 0}, Max: image.Point{X: *nx, Y: *ny}})
-	rand.Seed(time.Now().UnixNano())
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %v <command> [flags]\n\ncommands:\n", filepath.Base(os.Args[0]))
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8v %v\n", c.name, c.description)
+	}
+	fmt.Fprintf(os.Stderr, "\nrender is used when no command is given. Run %v <command> -h to list its flags.\n", filepath.Base(os.Args[0]))
+}
 
-	world, cam := scenes.CornellBox(float64(*nx) / float64(*ny))
-	render.Render(cam, world, canvas, *ns, *numWorkers)
+func main() {
+	name, args := "render", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
 
-	fmt.Printf("P3\n%v %v\n255\n", *nx, *ny)
-	for j := *ny - 1; j >= 0; j-- {
-		for i := 0; i < *nx; i++ {
-			pixel := canvas.At(i, j)
-			r, g, b, _ := pixel.RGBA()
-			fmt.Printf("%v %v %v\n", r>>8, g>>8, b>>8)
+	for _, c := range commands {
+		if c.name == name {
+			c.run(args)
+			return
 		}
 	}
+
+	if name != "help" {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	}
+	usage()
+	os.Exit(2)
 }
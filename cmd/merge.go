@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/render"
+)
+
+// mergeFlags lists the settings that partial renders must share to be merged. The number
+// of samples and the adaptive sampling settings can differ, as every pixel is weighted by
+// the number of samples it took.
+var mergeFlags = []string{"scene", "scene-file", "scene-seed", "x", "y", "integrator", "max-depth", "rr-depth", "sampler"}
+
+// runMerge combines partial renders saved with render -partial into a single image.
+func runMerge(args []string) {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	of := newOutputFlags(fs)
+	fs.Parse(args)
+	of.check()
+
+	if fs.NArg() == 0 {
+		log.Fatalf("usage: merge [flags] partial...")
+	}
+
+	partials := []*render.Checkpoint{}
+	for _, fileName := range fs.Args() {
+		c, err := render.LoadCheckpoint(fileName)
+		if err != nil {
+			log.Fatalf("failed to load partial render; %v", err)
+		}
+		partials = append(partials, c)
+		for _, name := range mergeFlags {
+			if want, got := partials[0].Settings[name], c.Settings[name]; got != want {
+				log.Fatalf("%v was rendered with -%v=%v, want %q", fileName, name, got, want)
+			}
+		}
+	}
+
+	film, err := render.Merge(partials...)
+	if err != nil {
+		log.Fatalf("failed to merge renders; %v", err)
+	}
+
+	of.write(film)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/canvas"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/postprocess"
+)
+
+// outputFlags holds the flags that select how images are post-processed and written.
+type outputFlags struct {
+	output   *string
+	format   *string
+	exposure *float64
+	toneMap  *string
+	transfer *string
+	dither   *bool
+	pipeline *postprocess.Pipeline
+}
+
+func newOutputFlags(fs *flag.FlagSet) *outputFlags {
+	return &outputFlags{
+		output:   fs.String("o", "", "write the image to this file instead of stdout; the format is inferred from the extension"),
+		format:   fs.String("format", "ppm", "the output format written to stdout: ppm, png, pfm or exr"),
+		exposure: fs.Float64("exposure", 0, "exposure adjustment in stops"),
+		toneMap:  fs.String("tonemap", "none", "the tone mapping operator: none, reinhard, aces or filmic"),
+		transfer: fs.String("transfer", "gamma2", "the transfer function: gamma2 or srgb"),
+		dither:   fs.Bool("dither", false, "apply ordered dithering before quantizing"),
+	}
+}
+
+// check validates the flags once they are parsed.
+func (of *outputFlags) check() {
+	var err error
+	of.pipeline, err = newPipeline(*of.exposure, *of.toneMap, *of.transfer, *of.dither)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *of.output != "" {
+		*of.format, err = formatFromFileName(*of.output)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	switch *of.format {
+	case "ppm", "png", "pfm", "exr":
+	default:
+		log.Fatalf("unknown output format %q", *of.format)
+	}
+}
+
+// encode prepares the film to be written in the selected format.
+func (of *outputFlags) encode(film *canvas.Film) (canvas.Canvas, error) {
+	return encode(film, *of.format, of.pipeline)
+}
+
+// write writes the film to the output file or stdout.
+func (of *outputFlags) write(film *canvas.Film) {
+	out, err := of.encode(film)
+	if err != nil {
+		log.Fatalf("failed to process image; %v", err)
+	}
+
+	if *of.output != "" {
+		err = writeFile(*of.output, out)
+	} else {
+		err = out.Write(os.Stdout)
+	}
+	if err != nil {
+		log.Fatalf("failed to write image; %v", err)
+	}
+}
+
+// formatFromFileName returns the output format matching the extension of fileName.
+func formatFromFileName(fileName string) (string, error) {
+	switch ext := strings.ToLower(filepath.Ext(fileName)); ext {
+	case ".ppm", ".png", ".pfm", ".exr":
+		return ext[1:], nil
+	default:
+		return "", fmt.Errorf("cannot infer the output format of %q, expected a .ppm, .png, .pfm or .exr extension", fileName)
+	}
+}
+
+// encode prepares the film to be written in the given format.
+func encode(film *canvas.Film, format string, pipeline *postprocess.Pipeline) (canvas.Canvas, error) {
+	switch format {
+	case "pfm":
+		// Work on a copy so that the film is left untouched.
+		f := *film
+		f.Encoding = canvas.EncodingPFM
+		return &f, nil
+	case "exr":
+		return film, nil
+	case "ppm", "png":
+		img, err := pipeline.Process(film)
+		if err != nil {
+			return nil, err
+		}
+		img.Encoding = canvas.EncodingPNG
+		if format == "ppm" {
+			img.Encoding = canvas.EncodingPPM
+		}
+		return img, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// writeFile writes the canvas to a temporary file that then replaces fileName,
+// so that readers never see a partially written image.
+func writeFile(fileName string, c canvas.Canvas) error {
+	tmp := fileName + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, fileName)
+}
+
+func newPipeline(exposure float64, toneMap string, transfer string, dither bool) (*postprocess.Pipeline, error) {
+	operators := []postprocess.Operator{}
+	if exposure != 0 {
+		operators = append(operators, postprocess.NewExposure(exposure))
+	}
+
+	switch toneMap {
+	case "none":
+	case "reinhard":
+		operators = append(operators, postprocess.NewReinhard(0))
+	case "aces":
+		operators = append(operators, postprocess.NewACES())
+	case "filmic":
+		operators = append(operators, postprocess.NewFilmic())
+	default:
+		return nil, fmt.Errorf("unknown tone mapping operator %q", toneMap)
+	}
+
+	switch transfer {
+	case "gamma2":
+		operators = append(operators, postprocess.NewGamma(2.0))
+	case "srgb":
+		operators = append(operators, postprocess.NewSRGB())
+	default:
+		return nil, fmt.Errorf("unknown transfer function %q", transfer)
+	}
+
+	if dither {
+		operators = append(operators, postprocess.NewDither())
+	}
+
+	return postprocess.New(operators...), nil
+}
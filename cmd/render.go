@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/canvas"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/farm"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/postprocess"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/render"
+)
+
+// runRender renders an image of the selected scene.
+func runRender(args []string) {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	sf := newSceneFlags(fs, 500, 500)
+	inf := newIntegratorFlags(fs, 1000)
+	of := newOutputFlags(fs)
+	numWorkers := fs.Int("num-workers", 1, "the number of worker threads")
+	timeout := fs.Duration("timeout", 0, "stop rendering after this long and write the partial image")
+	progress := fs.Bool("progress", false, "report the render progress on stderr")
+	tileSize := fs.Int("tile-size", 32, "the width and height of the render tiles")
+	tileOrder := fs.String("tile-order", "spiral", "the order tiles are rendered in: scanline, spiral or hilbert")
+	af := newAdaptiveFlags(fs)
+	progressive := fs.Bool("progressive", false, "render one sample per pixel at a time over the whole image")
+	snapshot := fs.String("snapshot", "", "periodically write the partial image to this file during progressive or adaptive renders")
+	snapshotPasses := fs.Int("snapshot-passes", 0, "the number of passes between snapshots")
+	snapshotInterval := fs.Duration("snapshot-interval", 0, "the minimum time between snapshots")
+	heatmap := fs.String("heatmap", "", "write a PNG showing the number of samples taken by every pixel to this file")
+	checkpoint := fs.String("checkpoint", "", "periodically save the state of the render to this file so that it can be resumed; it is removed once the render completes")
+	checkpointInterval := fs.Duration("checkpoint-interval", 10*time.Minute, "the minimum time between checkpoints")
+	resume := fs.String("resume", "", "resume the render saved in this checkpoint file using its settings; new checkpoints are saved to the same file unless -checkpoint is set")
+	partial := fs.String("partial", "", "save the accumulated samples to this file so that they can be merged with renders of the same scene that use other seeds")
+	farmWorkers := fs.String("farm", "", "render on this comma separated list of addresses of processes started with the worker command, which must be able to load the same scene file")
+	farmLocal := fs.Int("farm-local", 0, "start this many worker processes on this machine and render on them; each one uses -num-workers threads")
+	farmTileSize := fs.Int("farm-tile-size", 64, "the width and height of the tiles sent to farm workers")
+	farmTimeout := fs.Duration("farm-timeout", 0, "the maximum time a farm worker can take to render a tile before it is sent to another worker; 0 means no limit")
+
+	fs.Parse(args)
+
+	useFarm := *farmWorkers != "" || *farmLocal > 0
+	if useFarm && (*resume != "" || *checkpoint != "" || *snapshot != "") {
+		log.Fatalf("-resume, -checkpoint and -snapshot cannot be used with -farm")
+	}
+
+	var resumed *render.Checkpoint
+	if *resume != "" {
+		var err error
+		resumed, err = render.LoadCheckpoint(*resume)
+		if err != nil {
+			log.Fatalf("failed to load checkpoint; %v", err)
+		}
+		restoreSettings(fs, resumed)
+		if *checkpoint == "" {
+			*checkpoint = *resume
+		}
+	}
+
+	doc := sf.document()
+	in := inf.newIntegrator()
+	samplerType := inf.samplerType()
+
+	var order int
+	switch *tileOrder {
+	case "scanline":
+		order = render.TileOrderScanline
+	case "spiral":
+		order = render.TileOrderSpiral
+	case "hilbert":
+		order = render.TileOrderHilbert
+	default:
+		log.Fatalf("unknown tile order %q", *tileOrder)
+	}
+
+	of.check()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	opts := &render.Options{
+		NumSamples:  *inf.samples,
+		NumWorkers:  *numWorkers,
+		Integrator:  in,
+		Seed:        *sf.seed,
+		TileSize:    *tileSize,
+		TileOrder:   order,
+		Sampler:     samplerType,
+		Progressive: *progressive,
+	}
+	af.apply(opts)
+	if *snapshot != "" {
+		opts.SnapshotPasses = *snapshotPasses
+		opts.SnapshotInterval = *snapshotInterval
+		opts.Snapshot = func(film *canvas.Film, pass int) {
+			out, err := of.encode(film)
+			if err == nil {
+				err = writeFile(*snapshot, out)
+			}
+			if err != nil {
+				log.Printf("failed to write snapshot of pass %v; %v", pass, err)
+			}
+		}
+	}
+	settings := saveSettings(fs)
+	if *checkpoint != "" {
+		opts.CheckpointInterval = *checkpointInterval
+		opts.Checkpoint = func(film *canvas.Film) {
+			c := &render.Checkpoint{Film: film, Seed: opts.Seed, Sampler: opts.Sampler, Settings: settings}
+			if err := render.SaveCheckpoint(*checkpoint, c); err != nil {
+				log.Printf("failed to save checkpoint; %v", err)
+			}
+		}
+	}
+	if *progress {
+		opts.Progress = func(p render.Progress) {
+			fmt.Fprintf(os.Stderr, "\rpass %v: %v/%v tiles, %.0f samples/s, ETA %v    ",
+				p.Pass, p.TilesCompleted, p.TilesTotal, p.SamplesPerSecond, p.ETA.Round(time.Second))
+		}
+	}
+
+	film := canvas.NewFilm(*sf.width, *sf.height)
+	if resumed != nil {
+		if resumed.Sampler != opts.Sampler {
+			log.Fatalf("the checkpoint was rendered with a different sampler")
+		}
+		film = resumed.Film
+	}
+	var err error
+	if useFarm {
+		workers := []string{}
+		if *farmWorkers != "" {
+			workers = strings.Split(*farmWorkers, ",")
+		}
+		stopWorkers := func() {}
+		if *farmLocal > 0 {
+			var addrs []string
+			addrs, stopWorkers = startLocalWorkers(*farmLocal, *numWorkers)
+			workers = append(workers, addrs...)
+		}
+		// The workers rebuild the scene and the options from the flags.
+		farmSettings := map[string]string{"seed": strconv.FormatInt(*sf.seed, 10)}
+		for name, value := range settings {
+			farmSettings[name] = value
+		}
+		err = farm.Render(ctx, film, &farm.Options{
+			Workers:    workers,
+			Settings:   farmSettings,
+			TileSize:   *farmTileSize,
+			TileOrder:  order,
+			JobTimeout: *farmTimeout,
+			Progress:   opts.Progress,
+		})
+		stopWorkers()
+	} else {
+		err = render.RenderContext(ctx, sf.scene(doc), film, opts)
+	}
+	if err != nil {
+		log.Printf("render stopped early; %v", err)
+	} else if *checkpoint != "" {
+		if err := os.Remove(*checkpoint); err != nil && !os.IsNotExist(err) {
+			log.Printf("failed to remove checkpoint; %v", err)
+		}
+	}
+	if *progress {
+		fmt.Fprintln(os.Stderr)
+	}
+
+	if *partial != "" {
+		c := &render.Checkpoint{Film: film, Seed: opts.Seed, Sampler: opts.Sampler, Settings: settings}
+		if err := render.SaveCheckpoint(*partial, c); err != nil {
+			log.Fatalf("failed to save partial render; %v", err)
+		}
+	}
+
+	if *heatmap != "" {
+		img, err := postprocess.Heatmap(film)
+		if err != nil {
+			log.Fatalf("failed to create heatmap; %v", err)
+		}
+		if err := writeFile(*heatmap, img); err != nil {
+			log.Fatalf("failed to write heatmap; %v", err)
+		}
+	}
+
+	of.write(film)
+}
+
+// checkpointFlags lists the flags other than the seed that determine the sample values.
+// They are saved with checkpoints and partial renders and cannot change when a render
+// is resumed. Partial renders can only be merged if they match.
+var checkpointFlags = []string{"scene", "scene-file", "scene-seed", "x", "y", "samples", "integrator", "max-depth", "rr-depth", "sampler", "adaptive", "min-samples", "pass-samples", "threshold"}
+
+// saveSettings returns the values of the flags saved with checkpoints.
+func saveSettings(fs *flag.FlagSet) map[string]string {
+	settings := make(map[string]string)
+	for _, name := range checkpointFlags {
+		settings[name] = fs.Lookup(name).Value.String()
+	}
+
+	return settings
+}
+
+// restoreSettings sets the flags saved with a checkpoint and the seed. Flags given on the
+// command line must match the saved values.
+func restoreSettings(fs *flag.FlagSet, c *render.Checkpoint) {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	values := map[string]string{"seed": strconv.FormatInt(c.Seed, 10)}
+	for _, name := range checkpointFlags {
+		value, ok := c.Settings[name]
+		if !ok {
+			log.Fatalf("the checkpoint does not contain the value of -%v", name)
+		}
+		values[name] = value
+	}
+
+	for name, value := range values {
+		if f := fs.Lookup(name); set[name] && f.Value.String() != value {
+			log.Fatalf("-%v=%v does not match the value %q saved in the checkpoint", name, f.Value, value)
+		}
+		if err := fs.Set(name, value); err != nil {
+			log.Fatalf("invalid checkpoint setting for -%v; %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/farm"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/render"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scene"
+)
+
+// runWorker serves the tiles of renders started with render -farm.
+func runWorker(args []string) {
+	fs := flag.NewFlagSet("worker", flag.ExitOnError)
+	listen := fs.String("listen", "localhost:7700", "the address to accept jobs on; use port 0 to pick a free port")
+	numWorkers := fs.Int("num-workers", runtime.NumCPU(), "the number of worker threads used for every tile")
+	fs.Parse(args)
+
+	l, err := net.Listen("tcp", *listen)
+	if err != nil {
+		log.Fatalf("failed to listen; %v", err)
+	}
+	// render -farm-local reads the address from the first line of the output.
+	fmt.Printf("listening on %v\n", l.Addr())
+
+	if err := http.Serve(l, farm.NewWorker(loadSettings, *numWorkers)); err != nil {
+		log.Fatalf("failed to serve; %v", err)
+	}
+}
+
+// loadSettings builds the scene and the render options described by the flag values
+// that render -farm sends with every job.
+func loadSettings(settings map[string]string) (*scene.Scene, *render.Options, error) {
+	fs := flag.NewFlagSet("job", flag.ContinueOnError)
+	sf := newSceneFlags(fs, 0, 0)
+	inf := newIntegratorFlags(fs, 0)
+	af := newAdaptiveFlags(fs)
+	for name, value := range settings {
+		if fs.Lookup(name) == nil {
+			return nil, nil, fmt.Errorf("unknown setting %q", name)
+		}
+		if err := fs.Set(name, value); err != nil {
+			return nil, nil, fmt.Errorf("invalid value for -%v; %w", name, err)
+		}
+	}
+
+	doc, err := sf.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	s, err := doc.Scene(float64(*sf.width) / float64(*sf.height))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build scene; %w", err)
+	}
+
+	opts := &render.Options{
+		NumSamples: *inf.samples,
+		Integrator: inf.newIntegrator(),
+		Seed:       *sf.seed,
+		Sampler:    inf.samplerType(),
+	}
+	af.apply(opts)
+
+	return s, opts, nil
+}
+
+// startLocalWorkers starts n worker processes listening on free local ports and returns
+// their addresses together with a function that stops them.
+func startLocalWorkers(n int, numWorkers int) ([]string, func()) {
+	exe, err := os.Executable()
+	if err != nil {
+		log.Fatalf("failed to find the executable; %v", err)
+	}
+
+	procs := []*exec.Cmd{}
+	stop := func() {
+		for _, p := range procs {
+			p.Process.Kill()
+			p.Wait()
+		}
+	}
+
+	addrs := []string{}
+	for i := 0; i < n; i++ {
+		p := exec.Command(exe, "worker", "-listen", "127.0.0.1:0", "-num-workers", strconv.Itoa(numWorkers))
+		p.Stderr = os.Stderr
+		out, err := p.StdoutPipe()
+		if err == nil {
+			err = p.Start()
+		}
+		if err != nil {
+			stop()
+			log.Fatalf("failed to start worker; %v", err)
+		}
+		procs = append(procs, p)
+
+		line, err := bufio.NewReader(out).ReadString('\n')
+		if err != nil || !strings.HasPrefix(line, "listening on ") {
+			stop()
+			log.Fatalf("worker %v failed to start; %v", i, err)
+		}
+		addrs = append(addrs, strings.TrimSpace(strings.TrimPrefix(line, "listening on ")))
+	}
+
+	return addrs, stop
+}
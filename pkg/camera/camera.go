@@ -3,9 +3,9 @@ package camera
 
 import (
 	"math"
-	"math/rand"
 
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
 )
 
@@ -54,21 +54,34 @@ func New(lookFrom *vec3.Vec3Impl, lookAt *vec3.Vec3Impl, vup *vec3.Vec3Impl,
 }
 
 // GetRay returns the ray associated for the supplied u and v.
-func (c *Camera) GetRay(s float64, t float64) *ray.RayImpl {
-	rd := vec3.ScalarMul(randomInUnitDisc(), c.lensRadius)
+func (c *Camera) GetRay(s float64, t float64, sampler sampler.Sampler) *ray.RayImpl {
+	rd := vec3.ScalarMul(randomInUnitDisc(sampler), c.lensRadius)
 	offset := vec3.Add(vec3.ScalarMul(c.u, rd.X), vec3.ScalarMul(c.v, rd.Y))
-	time := c.time0 + rand.Float64()*(c.time1-c.time0)
+	time := c.time0 + sampler.Get1D()*(c.time1-c.time0)
 	return ray.New(vec3.Add(c.origin, offset),
 		// lowerLeftCorner + s*horizontal + t*vertical - origin - offset
 		vec3.Sub(vec3.Add(c.lowerLeftCorner, vec3.ScalarMul(c.horizontal, s),
 			vec3.ScalarMul(c.vertical, t)), c.origin, offset), time)
 }
 
-func randomInUnitDisc() *vec3.Vec3Impl {
-	for {
-		p := vec3.Sub(vec3.ScalarMul(&vec3.Vec3Impl{X: rand.Float64(), Y: rand.Float64()}, 2.0), &vec3.Vec3Impl{X: 1.0, Y: 1.0})
-		if vec3.Dot(p, p) < 1.0 {
-			return p
-		}
+// randomInUnitDisc uses the concentric mapping to turn a 2D sample into a point
+// on the unit disc while preserving its stratification.
+func randomInUnitDisc(sampler sampler.Sampler) *vec3.Vec3Impl {
+	x, y := sampler.Get2D()
+	x = 2*x - 1
+	y = 2*y - 1
+	if x == 0 && y == 0 {
+		return &vec3.Vec3Impl{}
 	}
+
+	var r, theta float64
+	if math.Abs(x) > math.Abs(y) {
+		r = x
+		theta = (math.Pi / 4) * (y / x)
+	} else {
+		r = y
+		theta = (math.Pi / 2) - (math.Pi/4)*(x/y)
+	}
+
+	return &vec3.Vec3Impl{X: r * math.Cos(theta), Y: r * math.Sin(theta)}
 }
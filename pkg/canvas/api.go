@@ -18,6 +18,33 @@ const (
 	PixelFormatRGBA
 )
 
+const (
+	// EncodingInvalid is the invalid encoding.
+	EncodingInvalid int = iota
+	// EncodingPPM is the binary (P6) portable pixmap encoding.
+	EncodingPPM
+	// EncodingPNG is the portable network graphics encoding.
+	EncodingPNG
+	// EncodingPFM is the portable float map encoding.
+	EncodingPFM
+	// EncodingEXR is the OpenEXR scanline encoding.
+	EncodingEXR
+)
+
+const (
+	// EXRPixelTypeHalf stores 16-bit floating point values.
+	EXRPixelTypeHalf int = 1
+	// EXRPixelTypeFloat stores 32-bit floating point values.
+	EXRPixelTypeFloat int = 2
+)
+
+const (
+	// EXRCompressionNone stores the pixel data uncompressed.
+	EXRCompressionNone int = 0
+	// EXRCompressionZIP compresses blocks of 16 scanlines with zlib.
+	EXRCompressionZIP int = 3
+)
+
 // Canvas defines the methods for the canvas operations.
 type Canvas interface {
 	Write(w io.Writer) error
@@ -14,16 +14,21 @@ type CanvasImpl struct {
 	SizeY       int
 	PixelSize   int
 	PixelFormat int
-	Palette     []int32
-	Buffer      []byte
+	// Palette contains the 0xRRGGBB colours used by indexed images.
+	Palette []int32
+	Buffer  []byte
+	// Encoding selects the file format used by Write.
+	Encoding int
 }
 
-// New returns a new CanvasImpl.
+// New returns a new CanvasImpl that will be written as PNG.
+// The palette is only used by indexed images and contains packed RGB triplets.
 func New(sizeX int, sizeY int, pixelFormat int, palette []byte) (*CanvasImpl, error) {
 	c := &CanvasImpl{
 		SizeX:       sizeX,
 		SizeY:       sizeY,
 		PixelFormat: pixelFormat,
+		Encoding:    EncodingPNG,
 	}
 
 	switch pixelFormat {
@@ -31,6 +36,12 @@ func New(sizeX int, sizeY int, pixelFormat int, palette []byte) (*CanvasImpl, er
 		c.PixelSize = 1
 	case PixelFormatIndexed:
 		c.PixelSize = 1
+		if len(palette)%3 != 0 || len(palette) == 0 || len(palette) > 256*3 {
+			return nil, fmt.Errorf("invalid palette size %v", len(palette))
+		}
+		for i := 0; i < len(palette); i += 3 {
+			c.Palette = append(c.Palette, int32(palette[i])<<16|int32(palette[i+1])<<8|int32(palette[i+2]))
+		}
 	case PixelFormatRGB:
 		c.PixelSize = 3
 	case PixelFormatRGBA, PixelFormatARGB:
@@ -45,5 +56,38 @@ func New(sizeX int, sizeY int, pixelFormat int, palette []byte) (*CanvasImpl, er
 
 // Write exports the image data to a suitable format.
 func (c *CanvasImpl) Write(w io.Writer) error {
-	return nil
+	switch c.Encoding {
+	case EncodingPPM:
+		return c.writePPM(w)
+	case EncodingPNG:
+		return c.writePNG(w)
+	default:
+		return fmt.Errorf("unsupported encoding %v for 8-bit canvases", c.Encoding)
+	}
+}
+
+// rgba returns the colour of the pixel at the given position.
+func (c *CanvasImpl) rgba(x int, y int) (uint8, uint8, uint8, uint8) {
+	i := (y*c.SizeX + x) * c.PixelSize
+	switch c.PixelFormat {
+	case PixelFormatBW:
+		if c.Buffer[i] != 0 {
+			return 255, 255, 255, 255
+		}
+		return 0, 0, 0, 255
+	case PixelFormatIndexed:
+		if int(c.Buffer[i]) >= len(c.Palette) {
+			return 0, 0, 0, 255
+		}
+		p := c.Palette[c.Buffer[i]]
+		return uint8(p >> 16), uint8(p >> 8), uint8(p), 255
+	case PixelFormatRGB:
+		return c.Buffer[i], c.Buffer[i+1], c.Buffer[i+2], 255
+	case PixelFormatRGBA:
+		return c.Buffer[i], c.Buffer[i+1], c.Buffer[i+2], c.Buffer[i+3]
+	case PixelFormatARGB:
+		return c.Buffer[i+1], c.Buffer[i+2], c.Buffer[i+3], c.Buffer[i]
+	}
+
+	return 0, 0, 0, 0
 }
@@ -0,0 +1,337 @@
+package canvas
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWritePNG(t *testing.T) {
+	testData := []struct {
+		name        string
+		pixelFormat int
+		palette     []byte
+		buffer      []byte
+		want        []color.NRGBA
+	}{
+		{
+			name:        "Black and white",
+			pixelFormat: PixelFormatBW,
+			buffer:      []byte{0, 1},
+			want:        []color.NRGBA{{A: 255}, {R: 255, G: 255, B: 255, A: 255}},
+		},
+		{
+			name:        "Indexed",
+			pixelFormat: PixelFormatIndexed,
+			palette:     []byte{10, 20, 30, 40, 50, 60},
+			buffer:      []byte{1, 0},
+			want:        []color.NRGBA{{R: 40, G: 50, B: 60, A: 255}, {R: 10, G: 20, B: 30, A: 255}},
+		},
+		{
+			name:        "Indexed out of range",
+			pixelFormat: PixelFormatIndexed,
+			palette:     []byte{10, 20, 30, 40, 50, 60},
+			buffer:      []byte{1, 7},
+			want:        []color.NRGBA{{R: 40, G: 50, B: 60, A: 255}, {A: 255}},
+		},
+		{
+			name:        "RGB",
+			pixelFormat: PixelFormatRGB,
+			buffer:      []byte{1, 2, 3, 4, 5, 6},
+			want:        []color.NRGBA{{R: 1, G: 2, B: 3, A: 255}, {R: 4, G: 5, B: 6, A: 255}},
+		},
+		{
+			name:        "RGBA",
+			pixelFormat: PixelFormatRGBA,
+			buffer:      []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			want:        []color.NRGBA{{R: 1, G: 2, B: 3, A: 4}, {R: 5, G: 6, B: 7, A: 8}},
+		},
+		{
+			name:        "ARGB",
+			pixelFormat: PixelFormatARGB,
+			buffer:      []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			want:        []color.NRGBA{{R: 2, G: 3, B: 4, A: 1}, {R: 6, G: 7, B: 8, A: 5}},
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := New(2, 1, test.pixelFormat, test.palette)
+			if err != nil {
+				t.Fatalf("New() returned error: %v", err)
+			}
+			copy(c.Buffer, test.buffer)
+
+			var b bytes.Buffer
+			if err := c.Write(&b); err != nil {
+				t.Fatalf("Write() returned error: %v", err)
+			}
+
+			img, err := png.Decode(&b)
+			if err != nil {
+				t.Fatalf("png.Decode() returned error: %v", err)
+			}
+
+			got := []color.NRGBA{}
+			for x := 0; x < 2; x++ {
+				got = append(got, color.NRGBAModel.Convert(img.At(x, 0)).(color.NRGBA))
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Write() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWritePPM(t *testing.T) {
+	c, err := New(2, 1, PixelFormatRGBA, nil)
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+	copy(c.Buffer, []byte{1, 2, 3, 4, 5, 6, 7, 8})
+	c.Encoding = EncodingPPM
+
+	var b bytes.Buffer
+	if err := c.Write(&b); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+
+	want := append([]byte("P6\n2 1\n255\n"), 1, 2, 3, 5, 6, 7)
+	if diff := cmp.Diff(want, b.Bytes()); diff != "" {
+		t.Errorf("Write() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWritePFM(t *testing.T) {
+	f := makeFilm()
+	f.Encoding = EncodingPFM
+
+	var b bytes.Buffer
+	if err := f.Write(&b); err != nil {
+		t.Fatalf("Write() returned error: %v", err)
+	}
+
+	header := "PF\n2 2\n-1.0\n"
+	if got := b.String()[:len(header)]; got != header {
+		t.Fatalf("Write() header = %q, want %q", got, header)
+	}
+
+	got := make([]float32, 12)
+	if err := binary.Read(bytes.NewReader(b.Bytes()[len(header):]), binary.LittleEndian, got); err != nil {
+		t.Fatalf("binary.Read() returned error: %v", err)
+	}
+
+	// Bottom row first.
+	want := []float32{7, 8, 9, 10, 11, 12, 1, 2, 3, 4, 5, 6}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Write() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFloat32ToHalf(t *testing.T) {
+	testData := []struct {
+		f    float32
+		want uint16
+	}{
+		{f: 0, want: 0x0000},
+		{f: 1, want: 0x3c00},
+		{f: -2, want: 0xc000},
+		{f: 0.5, want: 0x3800},
+		{f: 65504, want: 0x7bff},
+		{f: 1e6, want: 0x7c00},
+		{f: float32(math.Inf(-1)), want: 0xfc00},
+		{f: 5.960464477539063e-08, want: 0x0001},
+		{f: 1e-10, want: 0x0000},
+	}
+
+	for _, test := range testData {
+		if got := float32ToHalf(test.f); got != test.want {
+			t.Errorf("float32ToHalf(%v) = %#04x, want %#04x", test.f, got, test.want)
+		}
+	}
+}
+
+func TestWriteEXR(t *testing.T) {
+	testData := []struct {
+		name        string
+		pixelType   int
+		compression int
+	}{
+		{name: "Half uncompressed", pixelType: EXRPixelTypeHalf, compression: EXRCompressionNone},
+		{name: "Float uncompressed", pixelType: EXRPixelTypeFloat, compression: EXRCompressionNone},
+		{name: "Half ZIP", pixelType: EXRPixelTypeHalf, compression: EXRCompressionZIP},
+		{name: "Float ZIP", pixelType: EXRPixelTypeFloat, compression: EXRCompressionZIP},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			f := NewFilm(40, 37)
+			for y := 0; y < f.SizeY; y++ {
+				for x := 0; x < f.SizeX; x++ {
+					f.AddSample(x, y, &vec3.Vec3Impl{X: 0.25, Y: float64(x), Z: float64(y)})
+				}
+			}
+			f.EXRPixelType = test.pixelType
+			f.EXRCompression = test.compression
+
+			var b bytes.Buffer
+			if err := f.Write(&b); err != nil {
+				t.Fatalf("Write() returned error: %v", err)
+			}
+
+			got, err := readEXR(b.Bytes(), f.SizeX, f.SizeY, test.pixelType)
+			if err != nil {
+				t.Fatalf("readEXR() returned error: %v", err)
+			}
+
+			for y := 0; y < f.SizeY; y++ {
+				for x := 0; x < f.SizeX; x++ {
+					want := f.Pixel(x, y)
+					p := got[y*f.SizeX+x]
+					if p[0] != float32(want.X) || p[1] != float32(want.Y) || p[2] != float32(want.Z) {
+						t.Fatalf("pixel (%v, %v) = %v, want %v", x, y, p, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func makeFilm() *Film {
+	f := NewFilm(2, 2)
+	f.AddSample(0, 0, &vec3.Vec3Impl{X: 1, Y: 2, Z: 3})
+	f.AddSample(1, 0, &vec3.Vec3Impl{X: 4, Y: 5, Z: 6})
+	f.AddSample(0, 1, &vec3.Vec3Impl{X: 7, Y: 8, Z: 9})
+	// Two samples averaging to 10, 11, 12.
+	f.AddSample(1, 1, &vec3.Vec3Impl{X: 9, Y: 10, Z: 11})
+	f.AddSample(1, 1, &vec3.Vec3Impl{X: 11, Y: 12, Z: 13})
+	return f
+}
+
+// readEXR is a minimal decoder for the files produced by writeEXR. It returns RGB values.
+func readEXR(data []byte, sizeX int, sizeY int, pixelType int) ([][3]float32, error) {
+	r := bufio.NewReader(bytes.NewReader(data))
+	var magic, version int32
+	binary.Read(r, binary.LittleEndian, &magic)
+	binary.Read(r, binary.LittleEndian, &version)
+	if magic != exrMagic || version != exrVersion {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	compression := -1
+	for {
+		name, err := r.ReadString(0)
+		if err != nil {
+			return nil, err
+		}
+		if name == "\x00" {
+			break
+		}
+		if _, err := r.ReadString(0); err != nil {
+			return nil, err
+		}
+		var size int32
+		binary.Read(r, binary.LittleEndian, &size)
+		value := make([]byte, size)
+		if _, err := io.ReadFull(r, value); err != nil {
+			return nil, err
+		}
+		if name == "compression\x00" {
+			compression = int(value[0])
+		}
+	}
+
+	linesPerChunk := 1
+	if compression == EXRCompressionZIP {
+		linesPerChunk = 16
+	}
+	numChunks := (sizeY + linesPerChunk - 1) / linesPerChunk
+	offsets := make([]uint64, numChunks)
+	binary.Read(r, binary.LittleEndian, offsets)
+
+	bytesPerValue := 2
+	if pixelType == EXRPixelTypeFloat {
+		bytesPerValue = 4
+	}
+
+	pixels := make([][3]float32, sizeX*sizeY)
+	for _, offset := range offsets {
+		chunk := data[offset:]
+		y0 := int(int32(binary.LittleEndian.Uint32(chunk)))
+		size := int(binary.LittleEndian.Uint32(chunk[4:]))
+		payload := chunk[8 : 8+size]
+		numLines := linesPerChunk
+		if y0+numLines > sizeY {
+			numLines = sizeY - y0
+		}
+		rawSize := numLines * sizeX * 3 * bytesPerValue
+		if size < rawSize {
+			zr, err := zlib.NewReader(bytes.NewReader(payload))
+			if err != nil {
+				return nil, err
+			}
+			tmp, err := io.ReadAll(zr)
+			if err != nil {
+				return nil, err
+			}
+			for i := 1; i < len(tmp); i++ {
+				tmp[i] = byte(int(tmp[i-1]) + int(tmp[i]) - 128)
+			}
+			payload = make([]byte, len(tmp))
+			half := (len(tmp) + 1) / 2
+			for i := range payload {
+				if i%2 == 0 {
+					payload[i] = tmp[i/2]
+				} else {
+					payload[i] = tmp[half+i/2]
+				}
+			}
+		}
+
+		i := 0
+		for y := y0; y < y0+numLines; y++ {
+			// Channels are stored as B, G, R.
+			for c := 2; c >= 0; c-- {
+				for x := 0; x < sizeX; x++ {
+					var v float32
+					if bytesPerValue == 2 {
+						v = halfToFloat32(binary.LittleEndian.Uint16(payload[i:]))
+					} else {
+						v = math.Float32frombits(binary.LittleEndian.Uint32(payload[i:]))
+					}
+					pixels[y*sizeX+x][c] = v
+					i += bytesPerValue
+				}
+			}
+		}
+	}
+
+	return pixels, nil
+}
+
+func halfToFloat32(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := int((h >> 10) & 0x1f)
+	mant := uint32(h & 0x3ff)
+	switch exp {
+	case 0:
+		v := float32(mant) * float32(math.Pow(2, -24))
+		if sign != 0 {
+			v = -v
+		}
+		return v
+	case 0x1f:
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	}
+
+	return math.Float32frombits(sign | uint32(exp-15+127)<<23 | mant<<13)
+}
@@ -0,0 +1,222 @@
+package canvas
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	exrMagic = 20000630
+	// Single part scanline file.
+	exrVersion = 2
+)
+
+// writeEXR writes the film as a single part scanline OpenEXR file with B, G and R channels.
+func (f *Film) writeEXR(w io.Writer) error {
+	var linesPerChunk int
+	switch f.EXRCompression {
+	case EXRCompressionNone:
+		linesPerChunk = 1
+	case EXRCompressionZIP:
+		linesPerChunk = 16
+	default:
+		return fmt.Errorf("unsupported EXR compression %v", f.EXRCompression)
+	}
+
+	var bytesPerValue int
+	switch f.EXRPixelType {
+	case EXRPixelTypeHalf:
+		bytesPerValue = 2
+	case EXRPixelTypeFloat:
+		bytesPerValue = 4
+	default:
+		return fmt.Errorf("unsupported EXR pixel type %v", f.EXRPixelType)
+	}
+
+	header := f.exrHeader()
+	numChunks := (f.SizeY + linesPerChunk - 1) / linesPerChunk
+	chunks := make([][]byte, numChunks)
+	for i := range chunks {
+		y0 := i * linesPerChunk
+		y1 := y0 + linesPerChunk
+		if y1 > f.SizeY {
+			y1 = f.SizeY
+		}
+
+		raw := f.exrScanlines(y0, y1, bytesPerValue)
+		data := raw
+		if f.EXRCompression == EXRCompressionZIP {
+			compressed, err := exrZIP(raw)
+			if err != nil {
+				return err
+			}
+			// Readers treat chunks that are not smaller than the raw data as uncompressed.
+			if len(compressed) < len(raw) {
+				data = compressed
+			}
+		}
+
+		chunk := make([]byte, 8+len(data))
+		binary.LittleEndian.PutUint32(chunk[0:], uint32(int32(y0)))
+		binary.LittleEndian.PutUint32(chunk[4:], uint32(len(data)))
+		copy(chunk[8:], data)
+		chunks[i] = chunk
+	}
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+
+	offset := uint64(len(header) + 8*numChunks)
+	buf := make([]byte, 8)
+	for _, chunk := range chunks {
+		binary.LittleEndian.PutUint64(buf, offset)
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+		offset += uint64(len(chunk))
+	}
+
+	for _, chunk := range chunks {
+		if _, err := bw.Write(chunk); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+func (f *Film) exrHeader() []byte {
+	var b bytes.Buffer
+	putInt32 := func(v int32) {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+	putFloat32 := func(v float32) {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+	attribute := func(name string, typ string, size int) {
+		b.WriteString(name)
+		b.WriteByte(0)
+		b.WriteString(typ)
+		b.WriteByte(0)
+		putInt32(int32(size))
+	}
+
+	putInt32(exrMagic)
+	putInt32(exrVersion)
+
+	// Channels must be sorted alphabetically.
+	attribute("channels", "chlist", 3*18+1)
+	for _, name := range []string{"B", "G", "R"} {
+		b.WriteString(name)
+		b.WriteByte(0)
+		putInt32(int32(f.EXRPixelType))
+		// pLinear and reserved bytes.
+		b.Write([]byte{0, 0, 0, 0})
+		// x and y sampling.
+		putInt32(1)
+		putInt32(1)
+	}
+	b.WriteByte(0)
+
+	attribute("compression", "compression", 1)
+	b.WriteByte(byte(f.EXRCompression))
+
+	for _, name := range []string{"dataWindow", "displayWindow"} {
+		attribute(name, "box2i", 16)
+		putInt32(0)
+		putInt32(0)
+		putInt32(int32(f.SizeX - 1))
+		putInt32(int32(f.SizeY - 1))
+	}
+
+	// Increasing Y.
+	attribute("lineOrder", "lineOrder", 1)
+	b.WriteByte(0)
+
+	attribute("pixelAspectRatio", "float", 4)
+	putFloat32(1.0)
+
+	attribute("screenWindowCenter", "v2f", 8)
+	putFloat32(0)
+	putFloat32(0)
+
+	attribute("screenWindowWidth", "float", 4)
+	putFloat32(1.0)
+
+	// End of header.
+	b.WriteByte(0)
+
+	return b.Bytes()
+}
+
+// exrScanlines returns the uncompressed pixel data for the [y0, y1) scanlines.
+// Each scanline stores all the values of a channel before moving on to the next one.
+func (f *Film) exrScanlines(y0 int, y1 int, bytesPerValue int) []byte {
+	data := make([]byte, (y1-y0)*f.SizeX*3*bytesPerValue)
+	i := 0
+	for y := y0; y < y1; y++ {
+		pixels := make([][3]float64, f.SizeX)
+		for x := range pixels {
+			p := f.Pixel(x, y)
+			pixels[x] = [3]float64{p.Z, p.Y, p.X}
+		}
+		for c := 0; c < 3; c++ {
+			for x := range pixels {
+				v := float32(pixels[x][c])
+				if bytesPerValue == 2 {
+					binary.LittleEndian.PutUint16(data[i:], float32ToHalf(v))
+				} else {
+					binary.LittleEndian.PutUint32(data[i:], math.Float32bits(v))
+				}
+				i += bytesPerValue
+			}
+		}
+	}
+
+	return data
+}
+
+// exrZIP applies the OpenEXR byte reordering and delta predictor before deflating the data.
+func exrZIP(raw []byte) ([]byte, error) {
+	if len(raw) == 0 {
+		return raw, nil
+	}
+
+	tmp := make([]byte, len(raw))
+	t1 := 0
+	t2 := (len(raw) + 1) / 2
+	for i := 0; i < len(raw); i++ {
+		if i%2 == 0 {
+			tmp[t1] = raw[i]
+			t1++
+		} else {
+			tmp[t2] = raw[i]
+			t2++
+		}
+	}
+
+	p := int(tmp[0])
+	for i := 1; i < len(tmp); i++ {
+		d := int(tmp[i]) - p + (128 + 256)
+		p = int(tmp[i])
+		tmp[i] = byte(d)
+	}
+
+	var b bytes.Buffer
+	zw := zlib.NewWriter(&b)
+	if _, err := zw.Write(tmp); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,118 @@
+package canvas
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// Ensure interface compliance.
+var _ Canvas = (*Film)(nil)
+
+// Film represents a floating point frame buffer where linear radiance samples are accumulated.
+// Row 0 is the top of the image.
+type Film struct {
+	SizeX int
+	SizeY int
+	// Buffer contains the sum of the RGB samples for each pixel.
+	Buffer []float64
+	// SumSquares contains the sum of the squared luminance of the samples for each pixel.
+	SumSquares []float64
+	// Samples contains the number of samples accumulated for each pixel.
+	Samples []int
+	// Encoding selects the file format used by Write.
+	Encoding int
+	// EXRPixelType selects the precision of OpenEXR files.
+	EXRPixelType int
+	// EXRCompression selects the compression of OpenEXR files.
+	EXRCompression int
+}
+
+// NewFilm returns a new empty Film that will be written as a half float, ZIP compressed OpenEXR file.
+func NewFilm(sizeX int, sizeY int) *Film {
+	return &Film{
+		SizeX:          sizeX,
+		SizeY:          sizeY,
+		Buffer:         make([]float64, sizeX*sizeY*3),
+		SumSquares:     make([]float64, sizeX*sizeY),
+		Samples:        make([]int, sizeX*sizeY),
+		Encoding:       EncodingEXR,
+		EXRPixelType:   EXRPixelTypeHalf,
+		EXRCompression: EXRCompressionZIP,
+	}
+}
+
+// Clone returns a copy of the film.
+func (f *Film) Clone() *Film {
+	c := *f
+	c.Buffer = append([]float64(nil), f.Buffer...)
+	c.SumSquares = append([]float64(nil), f.SumSquares...)
+	c.Samples = append([]int(nil), f.Samples...)
+	return &c
+}
+
+// AddSample accumulates a radiance sample in the given pixel.
+func (f *Film) AddSample(x int, y int, col *vec3.Vec3Impl) {
+	i := y*f.SizeX + x
+	f.Buffer[i*3] += col.X
+	f.Buffer[i*3+1] += col.Y
+	f.Buffer[i*3+2] += col.Z
+	l := luminance(col.X, col.Y, col.Z)
+	f.SumSquares[i] += l * l
+	f.Samples[i]++
+}
+
+// Pixel returns the average of the samples accumulated in the given pixel.
+func (f *Film) Pixel(x int, y int) *vec3.Vec3Impl {
+	i := y*f.SizeX + x
+	n := f.Samples[i]
+	if n == 0 {
+		return &vec3.Vec3Impl{}
+	}
+
+	return &vec3.Vec3Impl{
+		X: f.Buffer[i*3] / float64(n),
+		Y: f.Buffer[i*3+1] / float64(n),
+		Z: f.Buffer[i*3+2] / float64(n),
+	}
+}
+
+// Variance returns the estimated variance of the mean luminance of the given pixel.
+// It is 0 for pixels with less than two samples.
+func (f *Film) Variance(x int, y int) float64 {
+	i := y*f.SizeX + x
+	n := float64(f.Samples[i])
+	if n < 2 {
+		return 0
+	}
+
+	mean := luminance(f.Buffer[i*3], f.Buffer[i*3+1], f.Buffer[i*3+2]) / n
+	// Unbiased sample variance divided by the number of samples.
+	variance := (f.SumSquares[i] - n*mean*mean) / (n - 1)
+	return math.Max(variance, 0) / n
+}
+
+// Luminance returns the mean luminance of the given pixel.
+func (f *Film) Luminance(x int, y int) float64 {
+	p := f.Pixel(x, y)
+	return luminance(p.X, p.Y, p.Z)
+}
+
+// luminance returns the Rec. 709 luminance of a linear RGB value.
+func luminance(r float64, g float64, b float64) float64 {
+	return 0.2126*r + 0.7152*g + 0.0722*b
+}
+
+// Write exports the averaged linear radiance to a floating point format.
+func (f *Film) Write(w io.Writer) error {
+	switch f.Encoding {
+	case EncodingPFM:
+		return f.writePFM(w)
+	case EncodingEXR:
+		return f.writeEXR(w)
+	default:
+		return fmt.Errorf("unsupported encoding %v for films", f.Encoding)
+	}
+}
@@ -0,0 +1,51 @@
+package canvas
+
+import "math"
+
+// float32ToHalf converts a 32-bit float to its IEEE 754 half precision representation
+// rounding to the nearest even value.
+func float32ToHalf(f float32) uint16 {
+	bits := math.Float32bits(f)
+	sign := uint16((bits >> 16) & 0x8000)
+	exp := int((bits >> 23) & 0xff)
+	mant := bits & 0x7fffff
+
+	// Inf and NaN.
+	if exp == 0xff {
+		if mant != 0 {
+			return sign | 0x7e00
+		}
+		return sign | 0x7c00
+	}
+
+	e := exp - 127 + 15
+	// Overflow.
+	if e >= 0x1f {
+		return sign | 0x7c00
+	}
+
+	// Subnormal or zero.
+	if e <= 0 {
+		if e < -10 {
+			return sign
+		}
+		mant |= 0x800000
+		shift := uint(14 - e)
+		half := uint16(mant >> shift)
+		rem := mant & ((1 << shift) - 1)
+		halfway := uint32(1) << (shift - 1)
+		if rem > halfway || (rem == halfway && half&1 == 1) {
+			half++
+		}
+		return sign | half
+	}
+
+	half := sign | uint16(e<<10) | uint16(mant>>13)
+	rem := mant & 0x1fff
+	// A carry into the exponent correctly rounds up to the next power of two or infinity.
+	if rem > 0x1000 || (rem == 0x1000 && half&1 == 1) {
+		half++
+	}
+
+	return half
+}
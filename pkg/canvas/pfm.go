@@ -0,0 +1,34 @@
+package canvas
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// writePFM writes the film as a little endian colour portable float map.
+func (f *Film) writePFM(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	// A negative scale denotes little endian data.
+	if _, err := fmt.Fprintf(bw, "PF\n%v %v\n-1.0\n", f.SizeX, f.SizeY); err != nil {
+		return err
+	}
+
+	// PFM scanlines go from bottom to top.
+	buf := make([]byte, 4)
+	for y := f.SizeY - 1; y >= 0; y-- {
+		for x := 0; x < f.SizeX; x++ {
+			p := f.Pixel(x, y)
+			for _, v := range []float64{p.X, p.Y, p.Z} {
+				binary.LittleEndian.PutUint32(buf, math.Float32bits(float32(v)))
+				if _, err := bw.Write(buf); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return bw.Flush()
+}
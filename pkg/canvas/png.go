@@ -0,0 +1,57 @@
+package canvas
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// writePNG writes the canvas as a PNG file. Black and white canvases are stored with
+// a bit depth of 1 and indexed canvases keep their palette.
+func (c *CanvasImpl) writePNG(w io.Writer) error {
+	return png.Encode(w, c.Image())
+}
+
+// Image returns the canvas contents as an image.Image.
+func (c *CanvasImpl) Image() image.Image {
+	rect := image.Rect(0, 0, c.SizeX, c.SizeY)
+	switch c.PixelFormat {
+	case PixelFormatBW:
+		img := image.NewPaletted(rect, color.Palette{color.Black, color.White})
+		for i := range c.Buffer {
+			if c.Buffer[i] != 0 {
+				img.Pix[i] = 1
+			}
+		}
+		return img
+	case PixelFormatIndexed:
+		palette := make(color.Palette, len(c.Palette))
+		for i, p := range c.Palette {
+			palette[i] = color.RGBA{R: uint8(p >> 16), G: uint8(p >> 8), B: uint8(p), A: 255}
+		}
+		img := image.NewPaletted(rect, palette)
+		copy(img.Pix, c.Buffer)
+		// Indices outside the palette are black, as in rgba. They can only exist if
+		// the palette has less than 256 colours so there is always room for black.
+		for i, idx := range img.Pix {
+			if int(idx) >= len(c.Palette) {
+				if len(img.Palette) == len(c.Palette) {
+					img.Palette = append(img.Palette, color.RGBA{A: 255})
+				}
+				img.Pix[i] = uint8(len(c.Palette))
+			}
+		}
+		return img
+	}
+
+	img := image.NewNRGBA(rect)
+	for y := 0; y < c.SizeY; y++ {
+		for x := 0; x < c.SizeX; x++ {
+			r, g, b, a := c.rgba(x, y)
+			img.SetNRGBA(x, y, color.NRGBA{R: r, G: g, B: b, A: a})
+		}
+	}
+
+	return img
+}
@@ -0,0 +1,26 @@
+package canvas
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// writePPM writes the canvas as a binary (P6) portable pixmap. Alpha is discarded.
+func (c *CanvasImpl) writePPM(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if _, err := fmt.Fprintf(bw, "P6\n%v %v\n255\n", c.SizeX, c.SizeY); err != nil {
+		return err
+	}
+
+	for y := 0; y < c.SizeY; y++ {
+		for x := 0; x < c.SizeX; x++ {
+			r, g, b, _ := c.rgba(x, y)
+			if _, err := bw.Write([]byte{r, g, b}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,229 @@
+package farm
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/canvas"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/render"
+)
+
+const (
+	defaultTileSize    = 64
+	defaultMaxFailures = 3
+	defaultRetryDelay  = time.Second
+)
+
+// Options contains the settings used by the coordinator.
+type Options struct {
+	// Workers are the addresses of the workers, either host:port or an http URL.
+	Workers []string
+	// Settings describe the scene and the render options. They are sent with every job.
+	Settings map[string]string
+	// TileSize is the width and height of the tiles sent to the workers. Defaults to 64.
+	TileSize int
+	// TileOrder is the order in which tiles are sent.
+	TileOrder int
+	// MaxFailures is the number of consecutive failed jobs after which a worker is
+	// considered dead and no longer used. Defaults to 3.
+	MaxFailures int
+	// RetryDelay is the time a worker is left alone after a failed job. Defaults to 1s.
+	RetryDelay time.Duration
+	// JobTimeout is the maximum time a worker can take to render a tile before the job
+	// fails. There is no limit when 0.
+	JobTimeout time.Duration
+	// Progress is called every time a tile is completed. It is never called concurrently.
+	Progress func(p render.Progress)
+	// Client sends the jobs. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// Render splits the film into tiles and renders them on the workers. Every tile is sent to
+// one worker at a time and the tiles of failed jobs are sent again, to other workers if
+// they are available. The completed tiles replace the contents of the film.
+// Render returns an error if the context is done or every worker is dead.
+func Render(ctx context.Context, film *canvas.Film, opts *Options) error {
+	if len(opts.Workers) == 0 {
+		return errors.New("no workers to render on")
+	}
+
+	c := &coordinator{
+		opts:        opts,
+		client:      opts.Client,
+		maxFailures: opts.MaxFailures,
+		retryDelay:  opts.RetryDelay,
+		film:        film,
+	}
+	if c.client == nil {
+		c.client = http.DefaultClient
+	}
+	if c.maxFailures < 1 {
+		c.maxFailures = defaultMaxFailures
+	}
+	if c.retryDelay <= 0 {
+		c.retryDelay = defaultRetryDelay
+	}
+	tileSize := opts.TileSize
+	if tileSize <= 0 {
+		tileSize = defaultTileSize
+	}
+
+	tiles := render.Tiles(film.SizeX, film.SizeY, tileSize, opts.TileOrder)
+	// The queue has room for every tile so failed tiles can always be put back.
+	queue := make(chan render.Tile, len(tiles))
+	for _, t := range tiles {
+		queue <- t
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	results := make(chan *Result)
+	dead := make(chan error)
+	wg := sync.WaitGroup{}
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+	for _, addr := range opts.Workers {
+		wg.Add(1)
+		go c.worker(ctx, addr, queue, results, dead, &wg)
+	}
+
+	start := time.Now()
+	p := render.Progress{Pass: 1, TilesTotal: len(tiles)}
+	alive := len(opts.Workers)
+	for p.TilesCompleted < p.TilesTotal {
+		select {
+		case res := <-results:
+			res.copyTo(film)
+			p.TilesCompleted++
+			p.Samples += res.numSamples()
+			if opts.Progress != nil {
+				p.Elapsed = time.Since(start)
+				p.SamplesPerSecond = float64(p.Samples) / p.Elapsed.Seconds()
+				p.ETA = time.Duration(float64(p.Elapsed) * float64(p.TilesTotal-p.TilesCompleted) / float64(p.TilesCompleted))
+				opts.Progress(p)
+			}
+		case err := <-dead:
+			if alive--; alive == 0 {
+				return fmt.Errorf("all the workers failed; %w", err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// coordinator holds the state shared by the goroutines that send jobs to the workers.
+type coordinator struct {
+	opts        *Options
+	client      *http.Client
+	maxFailures int
+	retryDelay  time.Duration
+	film        *canvas.Film
+}
+
+// worker sends the queued tiles to the worker at addr one at a time until the context is
+// done or the worker fails maxFailures jobs in a row. Failed tiles are queued again.
+func (c *coordinator) worker(ctx context.Context, addr string, queue chan render.Tile, results chan<- *Result, dead chan<- error, wg *sync.WaitGroup) {
+	defer wg.Done()
+	failures := 0
+	for {
+		var t render.Tile
+		select {
+		case t = <-queue:
+		case <-ctx.Done():
+			return
+		}
+
+		res, err := c.send(ctx, addr, t)
+		if err == nil {
+			failures = 0
+			select {
+			case results <- res:
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+
+		queue <- t
+		if ctx.Err() != nil {
+			return
+		}
+		if failures++; failures >= c.maxFailures {
+			select {
+			case dead <- fmt.Errorf("%v: %w", addr, err):
+			case <-ctx.Done():
+			}
+			return
+		}
+
+		select {
+		case <-time.After(c.retryDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// send asks the worker at addr to render the tile.
+func (c *coordinator) send(ctx context.Context, addr string, t render.Tile) (*Result, error) {
+	if c.opts.JobTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.JobTimeout)
+		defer cancel()
+	}
+
+	job := &Job{
+		Settings: c.opts.Settings,
+		SizeX:    c.film.SizeX,
+		SizeY:    c.film.SizeY,
+		Tile:     t,
+	}
+	body := &bytes.Buffer{}
+	if err := gob.NewEncoder(body).Encode(job); err != nil {
+		return nil, err
+	}
+
+	url := addr
+	if !strings.Contains(url, "://") {
+		url = "http://" + url
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(url, "/")+renderPath, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("worker returned %v: %v", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	res := &Result{}
+	if err := gob.NewDecoder(resp.Body).Decode(res); err != nil {
+		return nil, fmt.Errorf("failed to decode result; %w", err)
+	}
+	n := (t.X1 - t.X0) * (t.Y1 - t.Y0)
+	if res.Tile != t || len(res.Buffer) != n*3 || len(res.SumSquares) != n || len(res.Samples) != n {
+		return nil, fmt.Errorf("the result does not match tile %+v", t)
+	}
+
+	return res, nil
+}
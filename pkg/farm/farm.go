@@ -0,0 +1,82 @@
+// Package farm distributes renders across processes. A coordinator splits the image into
+// tiles and sends them over HTTP to workers, which build the scene from the settings sent
+// with every tile and return the accumulated samples. Sampling is deterministic for every
+// pixel so the result is the same as rendering the image in a single process.
+//
+// Jobs and results are gob encoded and sent as the body and response of POST /render requests.
+package farm
+
+import (
+	"fmt"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/canvas"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/render"
+)
+
+// renderPath is the path workers serve jobs on.
+const renderPath = "/render"
+
+// Job asks a worker to render a tile of an image.
+type Job struct {
+	// Settings describe the scene and the render options. They are application defined
+	// and passed to the Loader of the worker.
+	Settings map[string]string
+	// SizeX and SizeY are the size of the whole image.
+	SizeX int
+	SizeY int
+	Tile  render.Tile
+}
+
+// Result contains the samples of a rendered tile. The buffers hold the tile rows from top
+// to bottom in the same layout as a film.
+type Result struct {
+	Tile       render.Tile
+	Buffer     []float64
+	SumSquares []float64
+	Samples    []int
+}
+
+// newResult copies the pixels of the tile out of the film.
+func newResult(film *canvas.Film, t render.Tile) *Result {
+	res := &Result{Tile: t}
+	for y := t.Y0; y < t.Y1; y++ {
+		i0, i1 := y*film.SizeX+t.X0, y*film.SizeX+t.X1
+		res.Buffer = append(res.Buffer, film.Buffer[i0*3:i1*3]...)
+		res.SumSquares = append(res.SumSquares, film.SumSquares[i0:i1]...)
+		res.Samples = append(res.Samples, film.Samples[i0:i1]...)
+	}
+
+	return res
+}
+
+// copyTo stores the pixels of the tile in the film.
+func (res *Result) copyTo(film *canvas.Film) {
+	t := res.Tile
+	w := t.X1 - t.X0
+	for y := t.Y0; y < t.Y1; y++ {
+		i0, i1 := y*film.SizeX+t.X0, y*film.SizeX+t.X1
+		j := (y - t.Y0) * w
+		copy(film.Buffer[i0*3:i1*3], res.Buffer[j*3:(j+w)*3])
+		copy(film.SumSquares[i0:i1], res.SumSquares[j:j+w])
+		copy(film.Samples[i0:i1], res.Samples[j:j+w])
+	}
+}
+
+// numSamples returns the number of samples taken by the tile.
+func (res *Result) numSamples() int64 {
+	var n int64
+	for _, s := range res.Samples {
+		n += int64(s)
+	}
+
+	return n
+}
+
+// checkTile returns an error if the tile is empty or does not fit in the image.
+func checkTile(t render.Tile, sizeX int, sizeY int) error {
+	if t.X0 < 0 || t.Y0 < 0 || t.X1 > sizeX || t.Y1 > sizeY || t.X0 >= t.X1 || t.Y0 >= t.Y1 {
+		return fmt.Errorf("tile %+v does not fit in a %vx%v image", t, sizeX, sizeY)
+	}
+
+	return nil
+}
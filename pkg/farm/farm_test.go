@@ -0,0 +1,216 @@
+package farm
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/canvas"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/render"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scene"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scenes"
+	"github.com/google/go-cmp/cmp"
+)
+
+var testOptions = map[string]render.Options{
+	"tiles":    {NumSamples: 4, Seed: 7, TileSize: 5, Sampler: sampler.TypeSobol},
+	"adaptive": {NumSamples: 16, Seed: 7, TileSize: 5, Adaptive: true, MinSamples: 4, Threshold: 0.05},
+}
+
+// testLoader renders the Cornell box with the options named by the "options" setting.
+func testLoader(settings map[string]string) (*scene.Scene, *render.Options, error) {
+	opts, ok := testOptions[settings["options"]]
+	if !ok {
+		return nil, nil, errors.New("unknown options")
+	}
+
+	return scenes.CornellBox(24.0 / 16.0), &opts, nil
+}
+
+// flakyHandler fails the first numFailures requests.
+type flakyHandler struct {
+	mu          sync.Mutex
+	numFailures int
+	next        http.Handler
+}
+
+func (h *flakyHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	h.mu.Lock()
+	fail := h.numFailures > 0
+	h.numFailures--
+	h.mu.Unlock()
+	if fail {
+		http.Error(rw, "out of memory", http.StatusInternalServerError)
+		return
+	}
+	h.next.ServeHTTP(rw, req)
+}
+
+func TestRender(t *testing.T) {
+	for _, name := range []string{"tiles", "adaptive"} {
+		t.Run(name, func(t *testing.T) {
+			opts := testOptions[name]
+			opts.NumWorkers = 2
+			want := canvas.NewFilm(24, 16)
+			if err := render.Render(scenes.CornellBox(24.0/16.0), want, &opts); err != nil {
+				t.Fatalf("Render() = %v", err)
+			}
+
+			healthy := httptest.NewServer(NewWorker(testLoader, 2))
+			defer healthy.Close()
+			flaky := httptest.NewServer(&flakyHandler{numFailures: 2, next: NewWorker(testLoader, 1)})
+			defer flaky.Close()
+			// A worker that is dead from the start.
+			dead := httptest.NewServer(http.NotFoundHandler())
+			dead.Close()
+
+			got := canvas.NewFilm(24, 16)
+			var last render.Progress
+			err := Render(context.Background(), got, &Options{
+				Workers:     []string{healthy.URL, flaky.Listener.Addr().String(), dead.URL},
+				Settings:    map[string]string{"options": name},
+				TileSize:    7,
+				MaxFailures: 3,
+				RetryDelay:  time.Millisecond,
+				Progress: func(p render.Progress) {
+					last = p
+				},
+			})
+			if err != nil {
+				t.Fatalf("Render() = %v", err)
+			}
+
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Render() mismatch (-want +got):\n%s", diff)
+			}
+			if last.TilesCompleted != 12 || last.TilesTotal != 12 {
+				t.Errorf("final progress = %+v, want 12 of 12 tiles completed", last)
+			}
+		})
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	healthy := httptest.NewServer(NewWorker(testLoader, 1))
+	defer healthy.Close()
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testData := []struct {
+		name     string
+		ctx      context.Context
+		workers  []string
+		settings map[string]string
+	}{
+		{
+			name:     "No workers",
+			ctx:      context.Background(),
+			settings: map[string]string{"options": "tiles"},
+		},
+		{
+			name:     "Dead workers",
+			ctx:      context.Background(),
+			workers:  []string{dead.URL, dead.URL},
+			settings: map[string]string{"options": "tiles"},
+		},
+		{
+			name:     "Unknown settings",
+			ctx:      context.Background(),
+			workers:  []string{healthy.URL},
+			settings: map[string]string{"options": "unknown"},
+		},
+		{
+			name:     "Cancelled",
+			ctx:      cancelled,
+			workers:  []string{healthy.URL},
+			settings: map[string]string{"options": "tiles"},
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			err := Render(test.ctx, canvas.NewFilm(24, 16), &Options{
+				Workers:    test.workers,
+				Settings:   test.settings,
+				RetryDelay: time.Millisecond,
+			})
+			if err == nil {
+				t.Error("Render() = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestWorkerBadRequests(t *testing.T) {
+	encode := func(job *Job) *bytes.Buffer {
+		buf := &bytes.Buffer{}
+		if err := gob.NewEncoder(buf).Encode(job); err != nil {
+			t.Fatalf("Encode() = %v", err)
+		}
+		return buf
+	}
+
+	testData := []struct {
+		name   string
+		method string
+		path   string
+		body   *bytes.Buffer
+		want   int
+	}{
+		{
+			name:   "Wrong path",
+			method: http.MethodPost,
+			path:   "/",
+			body:   &bytes.Buffer{},
+			want:   http.StatusNotFound,
+		},
+		{
+			name:   "Wrong method",
+			method: http.MethodGet,
+			path:   renderPath,
+			body:   &bytes.Buffer{},
+			want:   http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "Not a job",
+			method: http.MethodPost,
+			path:   renderPath,
+			body:   bytes.NewBufferString("tile"),
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "Tile outside the image",
+			method: http.MethodPost,
+			path:   renderPath,
+			body:   encode(&Job{Settings: map[string]string{"options": "tiles"}, SizeX: 24, SizeY: 16, Tile: render.Tile{X0: 20, X1: 25, Y1: 4}}),
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "Unknown settings",
+			method: http.MethodPost,
+			path:   renderPath,
+			body:   encode(&Job{Settings: map[string]string{"options": "unknown"}, SizeX: 24, SizeY: 16, Tile: render.Tile{X1: 4, Y1: 4}}),
+			want:   http.StatusInternalServerError,
+		},
+	}
+
+	w := NewWorker(testLoader, 1)
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			w.ServeHTTP(rec, httptest.NewRequest(test.method, test.path, test.body))
+			if rec.Code != test.want {
+				t.Errorf("ServeHTTP() status = %v, want %v", rec.Code, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package farm
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/canvas"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/render"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scene"
+)
+
+// Ensure interface compliance.
+var _ http.Handler = (*Worker)(nil)
+
+// Loader builds the scene and the render options described by the settings of a job.
+// The options must not depend on the tile being rendered.
+type Loader func(settings map[string]string) (*scene.Scene, *render.Options, error)
+
+// Worker renders the jobs sent by a coordinator.
+type Worker struct {
+	load       Loader
+	numWorkers int
+
+	// mu guards the fields below and serializes the calls to load, as building
+	// scenes may use the global random number generator.
+	mu sync.Mutex
+	// key identifies the settings of the last loaded scene.
+	key   string
+	scene *scene.Scene
+	opts  *render.Options
+	// films holds cleared films that can be reused by later jobs.
+	films []*canvas.Film
+}
+
+// NewWorker returns a new Worker that renders every tile with numWorkers goroutines.
+func NewWorker(load Loader, numWorkers int) *Worker {
+	return &Worker{
+		load:       load,
+		numWorkers: numWorkers,
+	}
+}
+
+// ServeHTTP handles the render requests.
+func (w *Worker) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != renderPath {
+		http.NotFound(rw, req)
+		return
+	}
+	if req.Method != http.MethodPost {
+		http.Error(rw, "jobs must be sent with POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	job := &Job{}
+	if err := gob.NewDecoder(req.Body).Decode(job); err != nil {
+		http.Error(rw, fmt.Sprintf("failed to decode job; %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := checkTile(job.Tile, job.SizeX, job.SizeY); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res, err := w.render(req.Context(), job)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/octet-stream")
+	// There is nobody left to report the error to if the coordinator went away.
+	gob.NewEncoder(rw).Encode(res)
+}
+
+// render renders the tile of the job.
+func (w *Worker) render(ctx context.Context, job *Job) (*Result, error) {
+	s, opts, err := w.loadScene(job.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	o := *opts
+	o.NumWorkers = w.numWorkers
+	o.Region = &job.Tile
+	o.Snapshot = nil
+	o.Progress = nil
+	o.Checkpoint = nil
+
+	film := w.getFilm(job.SizeX, job.SizeY)
+	defer w.putFilm(film, job.Tile)
+	if err := render.RenderContext(ctx, s, film, &o); err != nil {
+		return nil, err
+	}
+
+	return newResult(film, job.Tile), nil
+}
+
+// loadScene returns the scene and options described by the settings, reusing the last ones
+// if the settings did not change.
+func (w *Worker) loadScene(settings map[string]string) (*scene.Scene, *render.Options, error) {
+	names := []string{}
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sb := strings.Builder{}
+	for _, name := range names {
+		fmt.Fprintf(&sb, "%q=%q\n", name, settings[name])
+	}
+	key := sb.String()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.scene != nil && w.key == key {
+		return w.scene, w.opts, nil
+	}
+
+	s, opts, err := w.load(settings)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load scene; %w", err)
+	}
+	w.key, w.scene, w.opts = key, s, opts
+
+	return s, opts, nil
+}
+
+// getFilm returns an empty film of the given size. Only the tile of a job is rendered
+// so films are cleared and reused instead of allocating a whole image for every job.
+func (w *Worker) getFilm(sizeX int, sizeY int) *canvas.Film {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for len(w.films) > 0 {
+		film := w.films[len(w.films)-1]
+		w.films = w.films[:len(w.films)-1]
+		if film.SizeX == sizeX && film.SizeY == sizeY {
+			return film
+		}
+	}
+
+	return canvas.NewFilm(sizeX, sizeY)
+}
+
+// putFilm clears the tile and makes the film available to other jobs.
+func (w *Worker) putFilm(film *canvas.Film, t render.Tile) {
+	for y := t.Y0; y < t.Y1; y++ {
+		for i := y*film.SizeX + t.X0; i < y*film.SizeX+t.X1; i++ {
+			film.Buffer[i*3], film.Buffer[i*3+1], film.Buffer[i*3+2] = 0, 0, 0
+			film.SumSquares[i] = 0
+			film.Samples[i] = 0
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.films = append(w.films, film)
+}
@@ -6,13 +6,18 @@ import (
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitrecord"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
 )
 
 // Hitable defines the methods to compute ray/geometry operations.
+// The sampler passed to Hit is only used by hitables that make stochastic decisions
+// and can be nil when evaluating deterministic geometry.
+// PDFValue and Random take the time of the ray being traced so that moving hitables
+// are sampled at the right position.
 type Hitable interface {
-	Hit(r ray.Ray, tMin float64, tMax float64) (*hitrecord.HitRecord, material.Material, bool)
+	Hit(r ray.Ray, tMin float64, tMax float64, sampler sampler.Sampler) (*hitrecord.HitRecord, material.Material, bool)
 	BoundingBox(time0 float64, time1 float64) (*aabb.AABB, bool)
-	PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl) float64
-	Random(o *vec3.Vec3Impl) *vec3.Vec3Impl
+	PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl, time float64) float64
+	Random(o *vec3.Vec3Impl, time float64, sampler sampler.Sampler) *vec3.Vec3Impl
 }
@@ -1,10 +1,13 @@
 package hitable
 
 import (
+	"math"
+
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/aabb"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitrecord"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
 )
 
@@ -14,10 +17,18 @@ var _ Hitable = (*Box)(nil)
 // Box represents a box.
 type Box struct {
 	sides HitableSlice
+	faces []boxFace
 	pMin  *vec3.Vec3Impl
 	pMax  *vec3.Vec3Impl
 }
 
+// boxFace contains the geometry used to importance sample a side of a box.
+type boxFace struct {
+	normal *vec3.Vec3Impl
+	center *vec3.Vec3Impl
+	area   float64
+}
+
 func NewBox(p0 *vec3.Vec3Impl, p1 *vec3.Vec3Impl, mat material.Material) *Box {
 	pMin := p0
 	pMax := p1
@@ -31,25 +42,101 @@ func NewBox(p0 *vec3.Vec3Impl, p1 *vec3.Vec3Impl, mat material.Material) *Box {
 		NewFlipNormals(NewYZRect(p0.Y, p1.Y, p0.Z, p1.Z, p0.X, mat)),
 	}
 
+	center := vec3.ScalarMul(vec3.Add(p0, p1), 0.5)
+	size := vec3.Sub(p1, p0)
+	faces := []boxFace{}
+	for _, f := range []struct {
+		normal vec3.Vec3Impl
+		half   float64
+		area   float64
+	}{
+		{vec3.Vec3Impl{Z: 1}, size.Z / 2, size.X * size.Y},
+		{vec3.Vec3Impl{Z: -1}, size.Z / 2, size.X * size.Y},
+		{vec3.Vec3Impl{Y: 1}, size.Y / 2, size.X * size.Z},
+		{vec3.Vec3Impl{Y: -1}, size.Y / 2, size.X * size.Z},
+		{vec3.Vec3Impl{X: 1}, size.X / 2, size.Y * size.Z},
+		{vec3.Vec3Impl{X: -1}, size.X / 2, size.Y * size.Z},
+	} {
+		normal := f.normal
+		faces = append(faces, boxFace{
+			normal: &normal,
+			center: vec3.Add(center, vec3.ScalarMul(&normal, f.half)),
+			area:   math.Abs(f.area),
+		})
+	}
+
 	return &Box{
 		sides: *NewSlice(box),
+		faces: faces,
 		pMin:  pMin,
 		pMax:  pMax,
 	}
 }
 
-func (b *Box) Hit(r ray.Ray, tMin float64, tMax float64) (*hitrecord.HitRecord, material.Material, bool) {
-	return b.sides.Hit(r, tMin, tMax)
+func (b *Box) Hit(r ray.Ray, tMin float64, tMax float64, sampler sampler.Sampler) (*hitrecord.HitRecord, material.Material, bool) {
+	return b.sides.Hit(r, tMin, tMax, sampler)
 }
 
 func (b *Box) BoundingBox(time0 float64, time1 float64) (*aabb.AABB, bool) {
 	return b.sides.BoundingBox(time0, time1)
 }
 
-func (b *Box) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl) float64 {
-	return 0.0
+// PDFValue returns the density of a direction generated by Random, which only samples
+// the sides that face the origin.
+func (b *Box) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl, time float64) float64 {
+	weights := b.faceWeights(o)
+	sum := 0.0
+	for i, w := range weights {
+		if w > 0 {
+			sum += w * b.sides.hitables[i].PDFValue(o, v, time)
+		}
+	}
+
+	return sum
 }
 
-func (b *Box) Random(o *vec3.Vec3Impl) *vec3.Vec3Impl {
-	return &vec3.Vec3Impl{X: 1}
+func (b *Box) Random(o *vec3.Vec3Impl, time float64, sampler sampler.Sampler) *vec3.Vec3Impl {
+	weights := b.faceWeights(o)
+	u := sampler.Get1D()
+	last := 0
+	for i, w := range weights {
+		if w <= 0 {
+			continue
+		}
+		last = i
+		if u < w {
+			break
+		}
+		u -= w
+	}
+
+	return b.sides.hitables[last].Random(o, time, sampler)
+}
+
+// faceWeights returns the probability of sampling every side of the box from o.
+// Sides are weighted by their approximate solid angle and the ones facing away from o
+// are never sampled. All the sides are equally likely when o is inside the box.
+func (b *Box) faceWeights(o *vec3.Vec3Impl) []float64 {
+	weights := make([]float64, len(b.faces))
+	sum := 0.0
+	for i, f := range b.faces {
+		d := vec3.Sub(o, f.center)
+		cosine := vec3.Dot(d, f.normal)
+		if cosine <= 0 {
+			continue
+		}
+		distance := d.Length()
+		weights[i] = f.area * cosine / (distance * distance * distance)
+		sum += weights[i]
+	}
+
+	for i := range weights {
+		if sum > 0 {
+			weights[i] /= sum
+		} else {
+			weights[i] = 1 / float64(len(weights))
+		}
+	}
+
+	return weights
 }
@@ -3,6 +3,7 @@ package hitable
 import (
 	"fmt"
 	"log"
+	"math"
 	"math/rand"
 	"sort"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitrecord"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
 )
 
@@ -103,10 +105,10 @@ func NewBVH(hitables []Hitable, time0 float64, time1 float64) *BVHNode {
 	return bn
 }
 
-func (bn *BVHNode) Hit(r ray.Ray, tMin float64, tMax float64) (*hitrecord.HitRecord, material.Material, bool) {
+func (bn *BVHNode) Hit(r ray.Ray, tMin float64, tMax float64, sampler sampler.Sampler) (*hitrecord.HitRecord, material.Material, bool) {
 	if bn.box.Hit(r, tMin, tMax) {
-		leftRec, leftMat, hitLeft := bn.left.Hit(r, tMin, tMax)
-		rightRec, rightMat, hitRight := bn.right.Hit(r, tMin, tMax)
+		leftRec, leftMat, hitLeft := bn.left.Hit(r, tMin, tMax, sampler)
+		rightRec, rightMat, hitRight := bn.right.Hit(r, tMin, tMax, sampler)
 
 		if hitLeft && hitRight {
 			if leftRec.T() < rightRec.T() {
@@ -131,10 +133,37 @@ func (bn *BVHNode) BoundingBox(time0 float64, time1 float64) (*aabb.AABB, bool)
 	return bn.box, true
 }
 
-func (bn *BVHNode) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl) float64 {
-	return 0.0
+// PDFValue returns the density of a direction generated by Random, which picks either child with the same probability.
+func (bn *BVHNode) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl, time float64) float64 {
+	if bn.left == bn.right {
+		return bn.left.PDFValue(o, v, time)
+	}
+
+	return 0.5*bn.left.PDFValue(o, v, time) + 0.5*bn.right.PDFValue(o, v, time)
+}
+
+func (bn *BVHNode) Random(o *vec3.Vec3Impl, time float64, sampler sampler.Sampler) *vec3.Vec3Impl {
+	if bn.left == bn.right || sampler.Get1D() < 0.5 {
+		return bn.left.Random(o, time, sampler)
+	}
+
+	return bn.right.Random(o, time, sampler)
 }
 
-func (bn *BVHNode) Random(o *vec3.Vec3Impl) *vec3.Vec3Impl {
-	return &vec3.Vec3Impl{X: 1}
+// visit calls fn with every leaf whose bounding box is crossed by the ray.
+func (bn *BVHNode) visit(r ray.Ray, fn func(h Hitable)) {
+	if !bn.box.Hit(r, 0.001, math.MaxFloat64) {
+		return
+	}
+
+	for i, child := range []Hitable{bn.left, bn.right} {
+		if i == 1 && bn.right == bn.left {
+			break
+		}
+		if node, ok := child.(*BVHNode); ok {
+			node.visit(r, fn)
+		} else {
+			fn(child)
+		}
+	}
 }
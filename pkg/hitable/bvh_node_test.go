@@ -1,6 +1,7 @@
 package hitable
 
 import (
+	"math/rand"
 	"testing"
 
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/aabb"
@@ -122,7 +123,8 @@ func TestNewBVH(t *testing.T) {
 
 	for _, test := range testData {
 		t.Run(test.name, func(t *testing.T) {
-
+			// NewBVH picks the split axis at random.
+			rand.Seed(0)
 			got := NewBVH(test.hitables, test.time0, test.time0)
 			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(BVHNode{}),
 				cmp.AllowUnexported(Sphere{}),
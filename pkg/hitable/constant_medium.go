@@ -2,12 +2,12 @@ package hitable
 
 import (
 	"math"
-	"math/rand"
 
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/aabb"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitrecord"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/texture"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
 )
@@ -31,9 +31,9 @@ func NewConstantMedium(hitable Hitable, density float64, a texture.Texture) *Con
 	}
 }
 
-func (cm *ConstantMedium) Hit(r ray.Ray, tMin float64, tMax float64) (*hitrecord.HitRecord, material.Material, bool) {
-	if rec1, _, ok := cm.hitable.Hit(r, -math.MaxFloat64, math.MaxFloat64); ok {
-		if rec2, _, ok := cm.hitable.Hit(r, rec1.T()+0.0001, math.MaxFloat64); ok {
+func (cm *ConstantMedium) Hit(r ray.Ray, tMin float64, tMax float64, sampler sampler.Sampler) (*hitrecord.HitRecord, material.Material, bool) {
+	if rec1, _, ok := cm.hitable.Hit(r, -math.MaxFloat64, math.MaxFloat64, sampler); ok {
+		if rec2, _, ok := cm.hitable.Hit(r, rec1.T()+0.0001, math.MaxFloat64, sampler); ok {
 			rec1t := rec1.T()
 			rec2t := rec2.T()
 			if rec1t < tMin {
@@ -50,7 +50,7 @@ func (cm *ConstantMedium) Hit(r ray.Ray, tMin float64, tMax float64) (*hitrecord
 			}
 
 			distanceInsideBoundary := (rec2t - rec1t) * r.Direction().Length()
-			hitDistance := -(1 / cm.density) * math.Log(rand.Float64())
+			hitDistance := -(1 / cm.density) * math.Log(sampler.Get1D())
 			if hitDistance < distanceInsideBoundary {
 				t := rec1t + hitDistance/r.Direction().Length()
 				// arbitrary
@@ -68,10 +68,11 @@ func (cm *ConstantMedium) BoundingBox(time0 float64, time1 float64) (*aabb.AABB,
 	return cm.hitable.BoundingBox(time0, time1)
 }
 
-func (cm *ConstantMedium) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl) float64 {
-	return 0.0
+// PDFValue returns the density of directions towards the boundary of the medium.
+func (cm *ConstantMedium) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl, time float64) float64 {
+	return cm.hitable.PDFValue(o, v, time)
 }
 
-func (cm *ConstantMedium) Random(o *vec3.Vec3Impl) *vec3.Vec3Impl {
-	return &vec3.Vec3Impl{X: 1}
+func (cm *ConstantMedium) Random(o *vec3.Vec3Impl, time float64, sampler sampler.Sampler) *vec3.Vec3Impl {
+	return cm.hitable.Random(o, time, sampler)
 }
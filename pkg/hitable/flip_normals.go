@@ -5,6 +5,7 @@ import (
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitrecord"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
 )
 
@@ -23,9 +24,9 @@ func NewFlipNormals(hitable Hitable) *FlipNormals {
 	}
 }
 
-func (fn *FlipNormals) Hit(r ray.Ray, tMin float64, tMax float64) (*hitrecord.HitRecord, material.Material, bool) {
-	if hr, mat, ok := fn.hitable.Hit(r, tMin, tMax); ok {
-		return hitrecord.New(hr.T(), hr.U(), hr.V(), hr.P(), vec3.ScalarMul(hr.Normal(), -1)), mat, true
+func (fn *FlipNormals) Hit(r ray.Ray, tMin float64, tMax float64, sampler sampler.Sampler) (*hitrecord.HitRecord, material.Material, bool) {
+	if hr, mat, ok := fn.hitable.Hit(r, tMin, tMax, sampler); ok {
+		return hr.Transformed(hr.P(), vec3.ScalarMul(hr.Normal(), -1)), mat, true
 	}
 	return nil, nil, false
 }
@@ -34,10 +35,10 @@ func (fn *FlipNormals) BoundingBox(time0 float64, time1 float64) (*aabb.AABB, bo
 	return fn.hitable.BoundingBox(time0, time1)
 }
 
-func (fn *FlipNormals) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl) float64 {
-	return fn.hitable.PDFValue(o, v)
+func (fn *FlipNormals) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl, time float64) float64 {
+	return fn.hitable.PDFValue(o, v, time)
 }
 
-func (fn *FlipNormals) Random(o *vec3.Vec3Impl) *vec3.Vec3Impl {
-	return fn.hitable.Random(o)
+func (fn *FlipNormals) Random(o *vec3.Vec3Impl, time float64, sampler sampler.Sampler) *vec3.Vec3Impl {
+	return fn.hitable.Random(o, time, sampler)
 }
@@ -1,12 +1,11 @@
 package hitable
 
 import (
-	"math/rand"
-
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/aabb"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitrecord"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
 )
 
@@ -25,15 +24,20 @@ func NewSlice(hitables []Hitable) *HitableSlice {
 	}
 }
 
+// Len returns the number of hitables in the slice.
+func (hs *HitableSlice) Len() int {
+	return len(hs.hitables)
+}
+
 // Hit computes whether a ray intersects with any of the elements in the slice.
-func (hs *HitableSlice) Hit(r ray.Ray, tMin float64, tMax float64) (*hitrecord.HitRecord, material.Material, bool) {
+func (hs *HitableSlice) Hit(r ray.Ray, tMin float64, tMax float64, sampler sampler.Sampler) (*hitrecord.HitRecord, material.Material, bool) {
 	var rec *hitrecord.HitRecord
 	var mat material.Material
 	var hitAnything bool
 	closestSoFar := tMax
 
 	for _, h := range hs.hitables {
-		if tempRec, tempMat, ok := h.Hit(r, tMin, closestSoFar); ok {
+		if tempRec, tempMat, ok := h.Hit(r, tMin, closestSoFar, sampler); ok {
 			rec = tempRec
 			mat = tempMat
 			hitAnything = ok
@@ -70,16 +74,20 @@ func (hs *HitableSlice) BoundingBox(time0 float64, time1 float64) (*aabb.AABB, b
 	return box, true
 }
 
-func (hs *HitableSlice) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl) float64 {
+func (hs *HitableSlice) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl, time float64) float64 {
 	weight := 1.0 / float64(len(hs.hitables))
 	sum := float64(0)
 	for _, h := range hs.hitables {
-		sum += weight * h.PDFValue(o, v)
+		sum += weight * h.PDFValue(o, v, time)
 	}
 	return sum
 }
 
-func (hs *HitableSlice) Random(o *vec3.Vec3Impl) *vec3.Vec3Impl {
-	index := int(rand.Float64() * float64(len(hs.hitables)))
-	return hs.hitables[index].Random(o)
+func (hs *HitableSlice) Random(o *vec3.Vec3Impl, time float64, sampler sampler.Sampler) *vec3.Vec3Impl {
+	if len(hs.hitables) == 0 {
+		return &vec3.Vec3Impl{X: 1}
+	}
+
+	index := int(sampler.Get1D() * float64(len(hs.hitables)))
+	return hs.hitables[index].Random(o, time, sampler)
 }
@@ -0,0 +1,70 @@
+package hitable
+
+import (
+	"math"
+	"testing"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/pdf"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+func TestPDFValue(t *testing.T) {
+	box := func() Hitable {
+		return NewBox(&vec3.Vec3Impl{X: 1, Y: -0.5, Z: -1}, &vec3.Vec3Impl{X: 2, Y: 1.5, Z: 0.5}, nil)
+	}
+	sphere := func(x, y, z float64) Hitable {
+		c := &vec3.Vec3Impl{X: x, Y: y, Z: z}
+		return NewSphere(c, c, 0, 1, 0.5, nil)
+	}
+
+	testData := []struct {
+		name   string
+		target Hitable
+		origin *vec3.Vec3Impl
+		time   float64
+	}{
+		{"box", box(), &vec3.Vec3Impl{}, 0},
+		{"box from inside", box(), &vec3.Vec3Impl{X: 1.5, Y: 0.5, Z: -0.2}, 0},
+		{"rotated box", NewRotateY(box(), 30), &vec3.Vec3Impl{}, 0},
+		{"translated rotated box", NewTranslate(NewRotateY(box(), -15), &vec3.Vec3Impl{X: -1, Y: 2}), &vec3.Vec3Impl{}, 0},
+		{"translated rect", NewTranslate(NewXZRect(-1, 1, -1, 1, 0, nil), &vec3.Vec3Impl{X: 0.5, Y: 1.5}), &vec3.Vec3Impl{}, 0},
+		{"flipped sphere", NewFlipNormals(sphere(0, 2, 0)), &vec3.Vec3Impl{}, 0},
+		{"bvh", NewBVH([]Hitable{sphere(0, 2, 0), sphere(2, 0, 0), sphere(0, -1.5, 1.5)}, 0, 1), &vec3.Vec3Impl{}, 0},
+		{"moving sphere", NewSphere(&vec3.Vec3Impl{Y: 2}, &vec3.Vec3Impl{X: 4, Y: 2}, 0, 1, 0.5, nil), &vec3.Vec3Impl{}, 0.75},
+		{"triangle", NewTriangle(&vec3.Vec3Impl{X: -1, Y: 1, Z: -1}, &vec3.Vec3Impl{X: 1, Y: 1, Z: -1}, &vec3.Vec3Impl{Y: 2}, nil), &vec3.Vec3Impl{}, 0},
+		{"mesh", makeCubeMesh(&vec3.Vec3Impl{X: 1, Y: 1, Z: -2}), &vec3.Vec3Impl{}, 0},
+		{"mesh from inside", makeCubeMesh(&vec3.Vec3Impl{X: 0.2}), &vec3.Vec3Impl{}, 0},
+		// Dense enough for every ray entering the medium to scatter.
+		{"constant medium", NewConstantMedium(box(), 1000, nil), &vec3.Vec3Impl{}, 0},
+		{"lights", NewSlice([]Hitable{box(), sphere(-2, 0, 0)}), &vec3.Vec3Impl{}, 0},
+	}
+
+	for _, test := range testData {
+		// Integrate over the sphere by averaging the PDF at uniformly distributed directions.
+		const numSamples = 1 << 17
+		uniform := pdf.NewSphere()
+		smp := sampler.NewSobol(1)
+		sum := 0.0
+		for i := 0; i < numSamples; i++ {
+			smp.StartPixelSample(0, 0, i)
+			sum += test.target.PDFValue(test.origin, uniform.Generate(smp), test.time)
+		}
+		if got := sum / numSamples * 4 * math.Pi; math.Abs(got-1) > 0.02 {
+			t.Errorf("%v: integral of PDFValue() = %v, want 1", test.name, got)
+		}
+
+		// Sampled directions must point at the target.
+		for i := 0; i < 1000; i++ {
+			smp.StartPixelSample(1, 0, i)
+			v := test.target.Random(test.origin, test.time, smp)
+			if test.target.PDFValue(test.origin, v, test.time) <= 0 {
+				t.Fatalf("%v: PDFValue() of sampled direction %v is not positive", test.name, v)
+			}
+			if _, _, ok := test.target.Hit(ray.New(test.origin, v, test.time), 0.001, math.MaxFloat64, smp); !ok {
+				t.Fatalf("%v: sampled direction %v misses the target", test.name, v)
+			}
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitrecord"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
 )
 
@@ -77,33 +78,11 @@ func NewRotateY(hitable Hitable, angle float64) *RotateY {
 	}
 }
 
-func (ry *RotateY) Hit(r ray.Ray, tMin float64, tMax float64) (*hitrecord.HitRecord, material.Material, bool) {
-	origin := &vec3.Vec3Impl{
-		X: ry.cosTheta*r.Origin().X - ry.sinTheta*r.Origin().Z,
-		Y: r.Origin().Y,
-		Z: ry.sinTheta*r.Origin().X + ry.cosTheta*r.Origin().Z,
-	}
-	direction := &vec3.Vec3Impl{
-		X: ry.cosTheta*r.Direction().X - ry.sinTheta*r.Direction().Z,
-		Y: r.Direction().Y,
-		Z: ry.sinTheta*r.Direction().X + ry.cosTheta*r.Direction().Z,
-	}
-
-	rotatedRay := ray.New(origin, direction, r.Time())
-
-	if hr, mat, ok := ry.hitable.Hit(rotatedRay, tMin, tMax); ok {
-		p := &vec3.Vec3Impl{
-			X: ry.cosTheta*hr.P().X + ry.sinTheta*hr.P().Z,
-			Y: hr.P().Y,
-			Z: -ry.sinTheta*hr.P().X + ry.cosTheta*hr.P().Z,
-		}
-		normal := &vec3.Vec3Impl{
-			X: ry.cosTheta*hr.Normal().X + ry.sinTheta*hr.Normal().Z,
-			Y: hr.Normal().Y,
-			Z: -ry.sinTheta*hr.Normal().X + ry.cosTheta*hr.Normal().Z,
-		}
+func (ry *RotateY) Hit(r ray.Ray, tMin float64, tMax float64, sampler sampler.Sampler) (*hitrecord.HitRecord, material.Material, bool) {
+	rotatedRay := ray.New(ry.toObject(r.Origin()), ry.toObject(r.Direction()), r.Time())
 
-		return hitrecord.New(hr.T(), hr.U(), hr.V(), p, normal), mat, true
+	if hr, mat, ok := ry.hitable.Hit(rotatedRay, tMin, tMax, sampler); ok {
+		return hr.Transformed(ry.toWorld(hr.P()), ry.toWorld(hr.Normal())), mat, true
 	}
 
 	return nil, nil, false
@@ -113,10 +92,29 @@ func (ry *RotateY) BoundingBox(time0 float64, time1 float64) (*aabb.AABB, bool)
 	return ry.bbox, ry.hasBox
 }
 
-func (ry *RotateY) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl) float64 {
-	return ry.hitable.PDFValue(o, v)
+// PDFValue rotates the query into object space. Rotations preserve solid angles.
+func (ry *RotateY) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl, time float64) float64 {
+	return ry.hitable.PDFValue(ry.toObject(o), ry.toObject(v), time)
 }
 
-func (ry *RotateY) Random(o *vec3.Vec3Impl) *vec3.Vec3Impl {
-	return ry.hitable.Random(o)
+func (ry *RotateY) Random(o *vec3.Vec3Impl, time float64, sampler sampler.Sampler) *vec3.Vec3Impl {
+	return ry.toWorld(ry.hitable.Random(ry.toObject(o), time, sampler))
+}
+
+// toObject applies the inverse rotation to a point or direction.
+func (ry *RotateY) toObject(v *vec3.Vec3Impl) *vec3.Vec3Impl {
+	return &vec3.Vec3Impl{
+		X: ry.cosTheta*v.X - ry.sinTheta*v.Z,
+		Y: v.Y,
+		Z: ry.sinTheta*v.X + ry.cosTheta*v.Z,
+	}
+}
+
+// toWorld applies the rotation to a point or direction.
+func (ry *RotateY) toWorld(v *vec3.Vec3Impl) *vec3.Vec3Impl {
+	return &vec3.Vec3Impl{
+		X: ry.cosTheta*v.X + ry.sinTheta*v.Z,
+		Y: v.Y,
+		Z: -ry.sinTheta*v.X + ry.cosTheta*v.Z,
+	}
 }
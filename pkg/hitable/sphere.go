@@ -8,6 +8,7 @@ import (
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/onb"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
 )
 
@@ -45,7 +46,7 @@ func NewSphere(center0 *vec3.Vec3Impl, center1 *vec3.Vec3Impl, time0 float64, ti
 }
 
 // Hit computes whether a ray intersects with the defined sphere.
-func (s *Sphere) Hit(r ray.Ray, tMin float64, tMax float64) (*hitrecord.HitRecord, material.Material, bool) {
+func (s *Sphere) Hit(r ray.Ray, tMin float64, tMax float64, sampler sampler.Sampler) (*hitrecord.HitRecord, material.Material, bool) {
 	oc := vec3.Sub(r.Origin(), s.center(r.Time()))
 	a := vec3.Dot(r.Direction(), r.Direction())
 	b := vec3.Dot(oc, r.Direction())
@@ -91,12 +92,17 @@ func (s *Sphere) BoundingBox(time0 float64, time1 float64) (*aabb.AABB, bool) {
 }
 
 func (s *Sphere) center(time float64) *vec3.Vec3Impl {
+	// Static spheres are often created with an empty time interval.
+	if s.time1 == s.time0 {
+		return s.center0
+	}
+
 	return vec3.Add(s.center0, vec3.ScalarMul(vec3.Sub(s.center1, s.center0), ((time-s.time0)/(s.time1-s.time0))))
 }
 
-func (s *Sphere) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl) float64 {
-	if _, _, ok := s.Hit((ray.New(o, v, 0)), 0.001, math.MaxFloat64); ok {
-		cosThetaMax := math.Sqrt(1 - s.radius*s.radius/vec3.Sub(s.center0, o).SquaredLength())
+func (s *Sphere) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl, time float64) float64 {
+	if _, _, ok := s.Hit((ray.New(o, v, time)), 0.001, math.MaxFloat64, nil); ok {
+		cosThetaMax := math.Sqrt(1 - s.radius*s.radius/vec3.Sub(s.center(time), o).SquaredLength())
 		solidAngle := 2 * math.Pi * (1 - cosThetaMax)
 		return 1 / solidAngle
 	}
@@ -104,10 +110,10 @@ func (s *Sphere) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl) float64 {
 	return 0.0
 }
 
-func (s *Sphere) Random(o *vec3.Vec3Impl) *vec3.Vec3Impl {
-	direction := vec3.Sub(s.center0, o)
+func (s *Sphere) Random(o *vec3.Vec3Impl, time float64, sampler sampler.Sampler) *vec3.Vec3Impl {
+	direction := vec3.Sub(s.center(time), o)
 	distanceSquared := direction.SquaredLength()
 	uvw := onb.New()
 	uvw.BuildFromW(direction)
-	return uvw.Local(vec3.RandomToSphere(s.radius, distanceSquared))
+	return uvw.Local(vec3.RandomToSphere(s.radius, distanceSquared, sampler))
 }
@@ -0,0 +1,108 @@
+package hitable
+
+import (
+	"fmt"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
+)
+
+// Stats holds statistics about a hierarchy of hitables.
+type Stats struct {
+	// Primitives counts the primitives by type. Triangle meshes contribute their triangles.
+	Primitives map[string]int
+	// BVHNodes is the number of BVH nodes, including the ones inside triangle meshes.
+	BVHNodes int
+	// BVHDepth is the largest number of BVH nodes on a path from the root to a primitive.
+	BVHDepth int
+	// Lights is the number of primitives with a diffuse light material. Triangle meshes count as one light.
+	Lights int
+}
+
+// NewStats collects the statistics of the given hitable.
+func NewStats(h Hitable) *Stats {
+	s := &Stats{Primitives: make(map[string]int)}
+	s.BVHDepth = s.visit(h)
+	return s
+}
+
+// NumPrimitives returns the total number of primitives.
+func (s *Stats) NumPrimitives() int {
+	n := 0
+	for _, count := range s.Primitives {
+		n += count
+	}
+
+	return n
+}
+
+// visit records the hitable and returns the BVH depth below it.
+func (s *Stats) visit(h Hitable) int {
+	switch h := h.(type) {
+	case *BVHNode:
+		s.BVHNodes++
+		// Nodes built from a single hitable reference it twice.
+		if h.left == h.right {
+			return 1 + s.visit(h.left)
+		}
+		return 1 + maxInt(s.visit(h.left), s.visit(h.right))
+	case *HitableSlice:
+		depth := 0
+		for _, child := range h.hitables {
+			depth = maxInt(depth, s.visit(child))
+		}
+		return depth
+	case *FlipNormals:
+		return s.visit(h.hitable)
+	case *Translate:
+		return s.visit(h.hitable)
+	case *RotateY:
+		return s.visit(h.hitable)
+	case *TriangleMesh:
+		// The triangles share the material of the mesh and are not counted as lights on their own.
+		lights := s.Lights
+		depth := s.visit(h.bvh)
+		s.Lights = lights
+		s.countLight(h.material)
+		return depth
+	case *Sphere:
+		s.Primitives["sphere"]++
+		s.countLight(h.material)
+	case *XYRect:
+		s.Primitives["xy_rect"]++
+		s.countLight(h.material)
+	case *XZRect:
+		s.Primitives["xz_rect"]++
+		s.countLight(h.material)
+	case *YZRect:
+		s.Primitives["yz_rect"]++
+		s.countLight(h.material)
+	case *Box:
+		s.Primitives["box"]++
+		// All the sides share the material of the first one.
+		s.countLight(h.sides.hitables[0].(*XYRect).material)
+	case *Triangle:
+		s.Primitives["triangle"]++
+		s.countLight(h.material)
+	case *ConstantMedium:
+		s.Primitives["constant_medium"]++
+	default:
+		s.Primitives[fmt.Sprintf("%T", h)]++
+	}
+
+	return 0
+}
+
+// countLight counts the primitive as a light if its material is a diffuse light.
+func (s *Stats) countLight(mat material.Material) {
+	if _, ok := mat.(*material.DiffuseLight); ok {
+		s.Lights++
+	}
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,46 @@
+package hitable
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/texture"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewStats(t *testing.T) {
+	rand.Seed(0)
+	spheres := []Hitable{}
+	for i := 0; i < 4; i++ {
+		spheres = append(spheres, makeSphere(float64(i), 0, 0, 0.5))
+	}
+	light := material.NewDiffuseLight(texture.NewConstant(&vec3.Vec3Impl{X: 4, Y: 4, Z: 4}))
+	mesh := makeCubeMesh(&vec3.Vec3Impl{})
+	mesh.material = light
+	world := NewSlice([]Hitable{
+		NewFlipNormals(NewXZRect(0, 1, 0, 1, 1, light)),
+		NewTranslate(NewRotateY(NewBox(&vec3.Vec3Impl{}, &vec3.Vec3Impl{X: 1, Y: 1, Z: 1}, light), 15), &vec3.Vec3Impl{X: 2}),
+		NewBVH(spheres, 0, 1),
+		NewBVH([]Hitable{mesh}, 0, 1),
+		NewSphere(&vec3.Vec3Impl{Y: 3}, &vec3.Vec3Impl{Y: 3}, 0, 1, 1, material.NewDielectric(1.5)),
+	})
+
+	got := NewStats(world)
+	want := &Stats{
+		Primitives: map[string]int{"xz_rect": 1, "box": 1, "sphere": 5, "triangle": 12},
+		// 3 nodes for the spheres, 1 wrapping the mesh and 15 inside it.
+		BVHNodes: 19,
+		// The mesh node followed by the 4 levels of the mesh BVH.
+		BVHDepth: 5,
+		// The rect, the box and the mesh. The glass sphere is not a light.
+		Lights: 3,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("NewStats() mismatch (-want +got):\n%s", diff)
+	}
+	if got, want := got.NumPrimitives(), 19; got != want {
+		t.Errorf("NumPrimitives() = %v, want %v", got, want)
+	}
+}
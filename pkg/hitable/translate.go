@@ -5,6 +5,7 @@ import (
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitrecord"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
 )
 
@@ -25,10 +26,10 @@ func NewTranslate(hitable Hitable, offset *vec3.Vec3Impl) *Translate {
 	}
 }
 
-func (tr *Translate) Hit(r ray.Ray, tMin float64, tMax float64) (*hitrecord.HitRecord, material.Material, bool) {
+func (tr *Translate) Hit(r ray.Ray, tMin float64, tMax float64, sampler sampler.Sampler) (*hitrecord.HitRecord, material.Material, bool) {
 	movedRay := ray.New(vec3.Sub(r.Origin(), tr.offset), r.Direction(), r.Time())
-	if hr, mat, ok := tr.hitable.Hit(movedRay, tMin, tMax); ok {
-		return hitrecord.New(hr.T(), hr.U(), hr.V(), vec3.Add(hr.P(), tr.offset), hr.Normal()), mat, true
+	if hr, mat, ok := tr.hitable.Hit(movedRay, tMin, tMax, sampler); ok {
+		return hr.Transformed(vec3.Add(hr.P(), tr.offset), hr.Normal()), mat, true
 	}
 
 	return nil, nil, false
@@ -42,10 +43,11 @@ func (tr *Translate) BoundingBox(time0 float64, time1 float64) (*aabb.AABB, bool
 	return nil, false
 }
 
-func (tr *Translate) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl) float64 {
-	return tr.hitable.PDFValue(o, v)
+// PDFValue moves the origin into object space. Directions are not affected by translations.
+func (tr *Translate) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl, time float64) float64 {
+	return tr.hitable.PDFValue(vec3.Sub(o, tr.offset), v, time)
 }
 
-func (tr *Translate) Random(o *vec3.Vec3Impl) *vec3.Vec3Impl {
-	return tr.hitable.Random(o)
+func (tr *Translate) Random(o *vec3.Vec3Impl, time float64, sampler sampler.Sampler) *vec3.Vec3Impl {
+	return tr.hitable.Random(vec3.Sub(o, tr.offset), time, sampler)
 }
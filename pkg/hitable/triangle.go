@@ -0,0 +1,175 @@
+package hitable
+
+import (
+	"math"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/aabb"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitrecord"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// Ensure interface compliance.
+var _ Hitable = (*Triangle)(nil)
+
+const (
+	// Rays closer than this to the plane of a triangle are considered parallel to it.
+	triangleEpsilon = 1e-12
+	// Padding added to the bounding box so that axis aligned triangles do not produce flat boxes.
+	trianglePadding = 0.0001
+)
+
+// Triangle represents a triangle. Like the rects, triangles are one-sided and their
+// geometric normal follows the counter-clockwise winding of the vertices.
+type Triangle struct {
+	vertices [3]*vec3.Vec3Impl
+	// normals are the optional per-vertex shading normals.
+	normals [3]*vec3.Vec3Impl
+	// uvs are the per-vertex texture coordinates stored in X and Y.
+	uvs [3]*vec3.Vec3Impl
+	// colors are the optional per-vertex colors set by triangle meshes.
+	colors   [3]*vec3.Vec3Impl
+	normal   *vec3.Vec3Impl
+	area     float64
+	material material.Material
+}
+
+// NewTriangle returns an instance of a flat triangle where the texture coordinates are
+// the barycentric coordinates of the hit point.
+func NewTriangle(v0 *vec3.Vec3Impl, v1 *vec3.Vec3Impl, v2 *vec3.Vec3Impl, mat material.Material) *Triangle {
+	return NewTriangleWithAttributes([3]*vec3.Vec3Impl{v0, v1, v2}, [3]*vec3.Vec3Impl{}, [3]*vec3.Vec3Impl{}, mat)
+}
+
+// NewTriangleWithAttributes returns an instance of a triangle with per-vertex shading normals
+// and texture coordinates. Shading normals are interpolated when all three are set and the
+// texture coordinates default to (0, 0), (1, 0) and (0, 1) when any of them is missing.
+func NewTriangleWithAttributes(vertices [3]*vec3.Vec3Impl, normals [3]*vec3.Vec3Impl, uvs [3]*vec3.Vec3Impl, mat material.Material) *Triangle {
+	if uvs[0] == nil || uvs[1] == nil || uvs[2] == nil {
+		uvs = [3]*vec3.Vec3Impl{{}, {X: 1}, {Y: 1}}
+	}
+	if normals[0] == nil || normals[1] == nil || normals[2] == nil {
+		normals = [3]*vec3.Vec3Impl{}
+	}
+
+	cross := vec3.Cross(vec3.Sub(vertices[1], vertices[0]), vec3.Sub(vertices[2], vertices[0]))
+	area := cross.Length() / 2
+	normal := &vec3.Vec3Impl{}
+	if area > 0 {
+		normal = vec3.UnitVector(cross)
+	}
+
+	return &Triangle{
+		vertices: vertices,
+		normals:  normals,
+		uvs:      uvs,
+		normal:   normal,
+		area:     area,
+		material: mat,
+	}
+}
+
+// intersect implements the Möller–Trumbore algorithm and returns the ray parameter
+// and the barycentric coordinates of the second and third vertices.
+func (tr *Triangle) intersect(r ray.Ray, tMin float64, tMax float64) (float64, float64, float64, bool) {
+	e1 := vec3.Sub(tr.vertices[1], tr.vertices[0])
+	e2 := vec3.Sub(tr.vertices[2], tr.vertices[0])
+	p := vec3.Cross(r.Direction(), e2)
+	det := vec3.Dot(e1, p)
+	if math.Abs(det) < triangleEpsilon {
+		return 0, 0, 0, false
+	}
+
+	invDet := 1 / det
+	s := vec3.Sub(r.Origin(), tr.vertices[0])
+	b1 := vec3.Dot(s, p) * invDet
+	if b1 < 0 || b1 > 1 {
+		return 0, 0, 0, false
+	}
+
+	q := vec3.Cross(s, e1)
+	b2 := vec3.Dot(r.Direction(), q) * invDet
+	if b2 < 0 || b1+b2 > 1 {
+		return 0, 0, 0, false
+	}
+
+	t := vec3.Dot(e2, q) * invDet
+	if t < tMin || t > tMax {
+		return 0, 0, 0, false
+	}
+
+	return t, b1, b2, true
+}
+
+func (tr *Triangle) Hit(r ray.Ray, tMin float64, tMax float64, sampler sampler.Sampler) (*hitrecord.HitRecord, material.Material, bool) {
+	t, b1, b2, ok := tr.intersect(r, tMin, tMax)
+	if !ok {
+		return nil, nil, false
+	}
+
+	b0 := 1 - b1 - b2
+	u := b0*tr.uvs[0].X + b1*tr.uvs[1].X + b2*tr.uvs[2].X
+	v := b0*tr.uvs[0].Y + b1*tr.uvs[1].Y + b2*tr.uvs[2].Y
+
+	normal := tr.normal
+	if tr.normals[0] != nil {
+		shading := vec3.Add(vec3.ScalarMul(tr.normals[0], b0), vec3.ScalarMul(tr.normals[1], b1), vec3.ScalarMul(tr.normals[2], b2))
+		if l := shading.Length(); l > 0 {
+			normal = vec3.ScalarDiv(shading, l)
+			// Keep the shading normal on the same side as the geometry.
+			if vec3.Dot(normal, tr.normal) < 0 {
+				normal = vec3.ScalarMul(normal, -1)
+			}
+		}
+	}
+
+	hr := hitrecord.New(t, u, v, r.PointAtParameter(t), normal)
+	if tr.colors[0] != nil {
+		hr.SetColor(vec3.Add(vec3.ScalarMul(tr.colors[0], b0), vec3.ScalarMul(tr.colors[1], b1), vec3.ScalarMul(tr.colors[2], b2)))
+	}
+
+	return hr, tr.material, true
+}
+
+func (tr *Triangle) BoundingBox(time0 float64, time1 float64) (*aabb.AABB, bool) {
+	min := &vec3.Vec3Impl{X: math.MaxFloat64, Y: math.MaxFloat64, Z: math.MaxFloat64}
+	max := &vec3.Vec3Impl{X: -math.MaxFloat64, Y: -math.MaxFloat64, Z: -math.MaxFloat64}
+	for _, v := range tr.vertices {
+		min.X = math.Min(min.X, v.X-trianglePadding)
+		min.Y = math.Min(min.Y, v.Y-trianglePadding)
+		min.Z = math.Min(min.Z, v.Z-trianglePadding)
+		max.X = math.Max(max.X, v.X+trianglePadding)
+		max.Y = math.Max(max.Y, v.Y+trianglePadding)
+		max.Z = math.Max(max.Z, v.Z+trianglePadding)
+	}
+
+	return aabb.New(min, max), true
+}
+
+// PDFValue returns the solid angle density of a direction generated by Random.
+func (tr *Triangle) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl, time float64) float64 {
+	t, _, _, ok := tr.intersect(ray.New(o, v, time), 0.001, math.MaxFloat64)
+	if !ok {
+		return 0
+	}
+
+	distanceSquared := t * t * v.SquaredLength()
+	cosine := math.Abs(vec3.Dot(v, tr.normal)) / v.Length()
+	return distanceSquared / (cosine * tr.area)
+}
+
+// Random returns the direction from o to a point uniformly distributed on the triangle.
+func (tr *Triangle) Random(o *vec3.Vec3Impl, time float64, sampler sampler.Sampler) *vec3.Vec3Impl {
+	r1, r2 := sampler.Get2D()
+	s := math.Sqrt(r1)
+	b1 := 1 - s
+	b2 := r2 * s
+	p := vec3.Add(vec3.ScalarMul(tr.vertices[0], 1-b1-b2), vec3.ScalarMul(tr.vertices[1], b1), vec3.ScalarMul(tr.vertices[2], b2))
+	return vec3.Sub(p, o)
+}
+
+// Area returns the area of the triangle.
+func (tr *Triangle) Area() float64 {
+	return tr.area
+}
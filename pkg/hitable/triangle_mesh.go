@@ -0,0 +1,136 @@
+package hitable
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/aabb"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitrecord"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/pdf"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// Ensure interface compliance.
+var _ Hitable = (*TriangleMesh)(nil)
+
+// TriangleMesh represents an indexed triangle mesh. The vertex attributes are shared
+// between the triangles and intersections are accelerated with an internal BVH.
+type TriangleMesh struct {
+	vertices  []*vec3.Vec3Impl
+	normals   []*vec3.Vec3Impl
+	uvs       []*vec3.Vec3Impl
+	colors    []*vec3.Vec3Impl
+	indices   []int
+	triangles []*Triangle
+	bvh       *BVHNode
+	// area is used to sample the triangles proportionally to their area.
+	area      *pdf.Discrete
+	totalArea float64
+	material  material.Material
+}
+
+// NewTriangleMesh returns a new triangle mesh. Every three indices define a triangle and each
+// index selects the vertex, normal and texture coordinates at that position. Normals and
+// texture coordinates are optional and must either be empty or match the number of vertices.
+func NewTriangleMesh(vertices []*vec3.Vec3Impl, normals []*vec3.Vec3Impl, uvs []*vec3.Vec3Impl, indices []int, mat material.Material) (*TriangleMesh, error) {
+	return NewColoredTriangleMesh(vertices, normals, uvs, nil, indices, mat)
+}
+
+// NewColoredTriangleMesh returns a new triangle mesh with optional per-vertex colors. The colors
+// are interpolated at the hit point and can be read by the material through the hit record.
+func NewColoredTriangleMesh(vertices []*vec3.Vec3Impl, normals []*vec3.Vec3Impl, uvs []*vec3.Vec3Impl, colors []*vec3.Vec3Impl, indices []int, mat material.Material) (*TriangleMesh, error) {
+	if len(indices) == 0 || len(indices)%3 != 0 {
+		return nil, fmt.Errorf("the number of indices must be a positive multiple of 3, got %v", len(indices))
+	}
+	if len(normals) != 0 && len(normals) != len(vertices) {
+		return nil, fmt.Errorf("got %v normals for %v vertices", len(normals), len(vertices))
+	}
+	if len(uvs) != 0 && len(uvs) != len(vertices) {
+		return nil, fmt.Errorf("got %v texture coordinates for %v vertices", len(uvs), len(vertices))
+	}
+	if len(colors) != 0 && len(colors) != len(vertices) {
+		return nil, fmt.Errorf("got %v colors for %v vertices", len(colors), len(vertices))
+	}
+
+	triangles := []*Triangle{}
+	hitables := []Hitable{}
+	areas := []float64{}
+	totalArea := 0.0
+	for i := 0; i < len(indices); i += 3 {
+		var v, n, uv, c [3]*vec3.Vec3Impl
+		for j := 0; j < 3; j++ {
+			index := indices[i+j]
+			if index < 0 || index >= len(vertices) {
+				return nil, fmt.Errorf("index %v of triangle %v is out of range", index, i/3)
+			}
+			v[j] = vertices[index]
+			if len(normals) > 0 {
+				n[j] = normals[index]
+			}
+			if len(uvs) > 0 {
+				uv[j] = uvs[index]
+			}
+			if len(colors) > 0 {
+				c[j] = colors[index]
+			}
+		}
+		tri := NewTriangleWithAttributes(v, n, uv, mat)
+		tri.colors = c
+		triangles = append(triangles, tri)
+		hitables = append(hitables, tri)
+		areas = append(areas, tri.Area())
+		totalArea += tri.Area()
+	}
+
+	area, err := pdf.NewDiscrete(areas)
+	if err != nil {
+		return nil, errors.New("all the triangles are degenerate")
+	}
+
+	return &TriangleMesh{
+		vertices:  vertices,
+		normals:   normals,
+		uvs:       uvs,
+		colors:    colors,
+		indices:   indices,
+		triangles: triangles,
+		bvh:       NewBVH(hitables, 0, 1),
+		area:      area,
+		totalArea: totalArea,
+		material:  mat,
+	}, nil
+}
+
+func (tm *TriangleMesh) Hit(r ray.Ray, tMin float64, tMax float64, sampler sampler.Sampler) (*hitrecord.HitRecord, material.Material, bool) {
+	return tm.bvh.Hit(r, tMin, tMax, sampler)
+}
+
+func (tm *TriangleMesh) BoundingBox(time0 float64, time1 float64) (*aabb.AABB, bool) {
+	return tm.bvh.BoundingBox(time0, time1)
+}
+
+// PDFValue returns the density of a direction generated by Random. A direction can reach
+// several triangles so the density of all of them is added up.
+func (tm *TriangleMesh) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl, time float64) float64 {
+	sum := 0.0
+	tm.bvh.visit(ray.New(o, v, time), func(h Hitable) {
+		if tri, ok := h.(*Triangle); ok && tri.area > 0 {
+			sum += tri.area * tri.PDFValue(o, v, time)
+		}
+	})
+
+	return sum / tm.totalArea
+}
+
+// Random returns the direction from o to a point uniformly distributed on the surface of the mesh.
+func (tm *TriangleMesh) Random(o *vec3.Vec3Impl, time float64, sampler sampler.Sampler) *vec3.Vec3Impl {
+	return tm.triangles[tm.area.Sample(sampler.Get1D())].Random(o, time, sampler)
+}
+
+// NumTriangles returns the number of triangles in the mesh.
+func (tm *TriangleMesh) NumTriangles() int {
+	return len(tm.triangles)
+}
@@ -0,0 +1,191 @@
+package hitable
+
+import (
+	"math"
+	"testing"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestTriangleHit(t *testing.T) {
+	v0 := &vec3.Vec3Impl{}
+	v1 := &vec3.Vec3Impl{X: 2}
+	v2 := &vec3.Vec3Impl{Y: 2}
+	smooth := NewTriangleWithAttributes(
+		[3]*vec3.Vec3Impl{v0, v1, v2},
+		[3]*vec3.Vec3Impl{{Z: 1}, {X: 1}, {Z: 1}},
+		[3]*vec3.Vec3Impl{{X: 0.5, Y: 0.5}, {X: 1, Y: 0.5}, {X: 0.5, Y: 1}},
+		nil)
+
+	testData := []struct {
+		name       string
+		triangle   *Triangle
+		r          ray.Ray
+		wantHit    bool
+		wantT      float64
+		wantU      float64
+		wantV      float64
+		wantNormal *vec3.Vec3Impl
+	}{
+		{
+			name:       "Front face",
+			triangle:   NewTriangle(v0, v1, v2, nil),
+			r:          ray.New(&vec3.Vec3Impl{X: 0.5, Y: 1, Z: 3}, &vec3.Vec3Impl{Z: -1}, 0),
+			wantHit:    true,
+			wantT:      3,
+			wantU:      0.25,
+			wantV:      0.5,
+			wantNormal: &vec3.Vec3Impl{Z: 1},
+		},
+		{
+			name:       "Back face",
+			triangle:   NewTriangle(v0, v1, v2, nil),
+			r:          ray.New(&vec3.Vec3Impl{X: 0.5, Y: 0.5, Z: -1}, &vec3.Vec3Impl{Z: 2}, 0),
+			wantHit:    true,
+			wantT:      0.5,
+			wantU:      0.25,
+			wantV:      0.25,
+			wantNormal: &vec3.Vec3Impl{Z: 1},
+		},
+		{
+			name:     "Outside the edge",
+			triangle: NewTriangle(v0, v1, v2, nil),
+			r:        ray.New(&vec3.Vec3Impl{X: 1.5, Y: 1.5, Z: 1}, &vec3.Vec3Impl{Z: -1}, 0),
+		},
+		{
+			name:     "Parallel",
+			triangle: NewTriangle(v0, v1, v2, nil),
+			r:        ray.New(&vec3.Vec3Impl{X: -1, Y: 0.5}, &vec3.Vec3Impl{X: 1}, 0),
+		},
+		{
+			name:     "Behind the origin",
+			triangle: NewTriangle(v0, v1, v2, nil),
+			r:        ray.New(&vec3.Vec3Impl{X: 0.5, Y: 0.5, Z: 1}, &vec3.Vec3Impl{Z: 1}, 0),
+		},
+		{
+			name:       "Shading normals and texture coordinates",
+			triangle:   smooth,
+			r:          ray.New(&vec3.Vec3Impl{X: 1, Z: 1}, &vec3.Vec3Impl{Z: -1}, 0),
+			wantHit:    true,
+			wantT:      1,
+			wantU:      0.75,
+			wantV:      0.5,
+			wantNormal: vec3.UnitVector(&vec3.Vec3Impl{X: 1, Z: 1}),
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			rec, _, ok := test.triangle.Hit(test.r, 0.001, math.MaxFloat64, nil)
+			if ok != test.wantHit {
+				t.Fatalf("Hit() = %v, want %v", ok, test.wantHit)
+			}
+			if !ok {
+				return
+			}
+
+			opt := cmpopts.EquateApprox(0, 1e-9)
+			if diff := cmp.Diff([]float64{test.wantT, test.wantU, test.wantV}, []float64{rec.T(), rec.U(), rec.V()}, opt); diff != "" {
+				t.Errorf("Hit() t, u, v mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.wantNormal, rec.Normal(), opt); diff != "" {
+				t.Errorf("Hit() normal mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewTriangleMeshErrors(t *testing.T) {
+	vertices := []*vec3.Vec3Impl{{}, {X: 1}, {Y: 1}}
+	testData := []struct {
+		name    string
+		normals []*vec3.Vec3Impl
+		uvs     []*vec3.Vec3Impl
+		indices []int
+	}{
+		{"No indices", nil, nil, nil},
+		{"Incomplete triangle", nil, nil, []int{0, 1}},
+		{"Index out of range", nil, nil, []int{0, 1, 3}},
+		{"Negative index", nil, nil, []int{0, -1, 2}},
+		{"Missing normals", []*vec3.Vec3Impl{{Z: 1}}, nil, []int{0, 1, 2}},
+		{"Missing texture coordinates", nil, []*vec3.Vec3Impl{{}}, []int{0, 1, 2}},
+		{"Degenerate", nil, nil, []int{0, 1, 1}},
+	}
+
+	for _, test := range testData {
+		if _, err := NewTriangleMesh(vertices, test.normals, test.uvs, test.indices, nil); err == nil {
+			t.Errorf("%v: NewTriangleMesh() succeeded, want an error", test.name)
+		}
+	}
+}
+
+// makeCubeMesh returns a unit cube centered at c made of 12 triangles with outward normals.
+func makeCubeMesh(c *vec3.Vec3Impl) *TriangleMesh {
+	vertices := []*vec3.Vec3Impl{}
+	for i := 0; i < 8; i++ {
+		vertices = append(vertices, vec3.Add(c, &vec3.Vec3Impl{
+			X: float64(i&1) - 0.5,
+			Y: float64(i>>1&1) - 0.5,
+			Z: float64(i>>2&1) - 0.5,
+		}))
+	}
+	indices := []int{
+		0, 2, 1, 1, 2, 3, // -Z
+		4, 5, 6, 5, 7, 6, // +Z
+		0, 1, 4, 1, 5, 4, // -Y
+		2, 6, 3, 3, 6, 7, // +Y
+		0, 4, 2, 2, 4, 6, // -X
+		1, 3, 5, 3, 7, 5, // +X
+	}
+	mesh, err := NewTriangleMesh(vertices, nil, nil, indices, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	return mesh
+}
+
+func TestTriangleMeshHit(t *testing.T) {
+	mesh := makeCubeMesh(&vec3.Vec3Impl{Z: -3})
+	if got := mesh.NumTriangles(); got != 12 {
+		t.Errorf("NumTriangles() = %v, want 12", got)
+	}
+
+	rec, _, ok := mesh.Hit(ray.New(&vec3.Vec3Impl{X: 0.1, Y: 0.2}, &vec3.Vec3Impl{Z: -1}, 0), 0.001, math.MaxFloat64, nil)
+	if !ok {
+		t.Fatal("Hit() = false, want true")
+	}
+	if diff := cmp.Diff(2.5, rec.T(), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("Hit() t mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(&vec3.Vec3Impl{Z: 1}, rec.Normal(), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("Hit() normal mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTriangleMeshColors(t *testing.T) {
+	vertices := []*vec3.Vec3Impl{{}, {X: 1}, {Y: 1}}
+	colors := []*vec3.Vec3Impl{{X: 1}, {Y: 1}, {Z: 1}}
+	mesh, err := NewColoredTriangleMesh(vertices, nil, nil, colors, []int{0, 1, 2}, nil)
+	if err != nil {
+		t.Fatalf("NewColoredTriangleMesh() returned error: %v", err)
+	}
+
+	// The transforms must keep the interpolated color of the hit record.
+	moved := NewTranslate(NewRotateY(NewFlipNormals(mesh), 0), &vec3.Vec3Impl{X: 2})
+	rec, _, ok := moved.Hit(ray.New(&vec3.Vec3Impl{X: 2.25, Y: 0.5, Z: 1}, &vec3.Vec3Impl{Z: -1}, 0), 0.001, math.MaxFloat64, nil)
+	if !ok {
+		t.Fatal("Hit() = false, want true")
+	}
+	want := &vec3.Vec3Impl{X: 0.25, Y: 0.25, Z: 0.5}
+	if diff := cmp.Diff(want, rec.Color(), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("Hit() color mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := NewColoredTriangleMesh(vertices, nil, nil, colors[:2], []int{0, 1, 2}, nil); err == nil {
+		t.Error("NewColoredTriangleMesh() with too few colors succeeded, want an error")
+	}
+}
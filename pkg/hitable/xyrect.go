@@ -1,10 +1,13 @@
 package hitable
 
 import (
+	"math"
+
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/aabb"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitrecord"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
 )
 
@@ -33,7 +36,7 @@ func NewXYRect(x0 float64, x1 float64, y0 float64, y1 float64, k float64, mat ma
 	}
 }
 
-func (xyr *XYRect) Hit(r ray.Ray, tMin float64, tMax float64) (*hitrecord.HitRecord, material.Material, bool) {
+func (xyr *XYRect) Hit(r ray.Ray, tMin float64, tMax float64, sampler sampler.Sampler) (*hitrecord.HitRecord, material.Material, bool) {
 	t := (xyr.k - r.Origin().Z) / r.Direction().Z
 	if t < tMin || t > tMax {
 		return nil, nil, false
@@ -64,10 +67,25 @@ func (xyr *XYRect) BoundingBox(time0 float64, time1 float64) (*aabb.AABB, bool)
 		}), true
 }
 
-func (xyr *XYRect) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl) float64 {
-	return 0.0
+func (xyr *XYRect) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl, time float64) float64 {
+	r := ray.New(o, v, time)
+	if rec, _, ok := xyr.Hit(r, 0.001, math.MaxFloat64, nil); ok {
+		area := (xyr.x1 - xyr.x0) * (xyr.y1 - xyr.y0)
+		distanceSquared := rec.T() * rec.T() * v.SquaredLength()
+		cosine := math.Abs(vec3.Dot(v, vec3.ScalarDiv(rec.Normal(), v.Length())))
+		return distanceSquared / (cosine * area)
+	}
+
+	return 0
 }
 
-func (xyr *XYRect) Random(o *vec3.Vec3Impl) *vec3.Vec3Impl {
-	return &vec3.Vec3Impl{X: 1}
+func (xyr *XYRect) Random(o *vec3.Vec3Impl, time float64, sampler sampler.Sampler) *vec3.Vec3Impl {
+	r1, r2 := sampler.Get2D()
+	randomPoint := &vec3.Vec3Impl{
+		X: xyr.x0 + r1*(xyr.x1-xyr.x0),
+		Y: xyr.y0 + r2*(xyr.y1-xyr.y0),
+		Z: xyr.k,
+	}
+
+	return vec3.Sub(randomPoint, o)
 }
@@ -2,12 +2,12 @@ package hitable
 
 import (
 	"math"
-	"math/rand"
 
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/aabb"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitrecord"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
 )
 
@@ -36,7 +36,7 @@ func NewXZRect(x0 float64, x1 float64, z0 float64, z1 float64, k float64, mat ma
 	}
 }
 
-func (xzr *XZRect) Hit(r ray.Ray, tMin float64, tMax float64) (*hitrecord.HitRecord, material.Material, bool) {
+func (xzr *XZRect) Hit(r ray.Ray, tMin float64, tMax float64, sampler sampler.Sampler) (*hitrecord.HitRecord, material.Material, bool) {
 	t := (xzr.k - r.Origin().Y) / r.Direction().Y
 	if t < tMin || t > tMax {
 		return nil, nil, false
@@ -67,9 +67,9 @@ func (xzr *XZRect) BoundingBox(time0 float64, time1 float64) (*aabb.AABB, bool)
 		}), true
 }
 
-func (xzr *XZRect) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl) float64 {
-	r := ray.New(o, v, 0)
-	if rec, _, ok := xzr.Hit(r, 0.001, math.MaxFloat64); ok {
+func (xzr *XZRect) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl, time float64) float64 {
+	r := ray.New(o, v, time)
+	if rec, _, ok := xzr.Hit(r, 0.001, math.MaxFloat64, nil); ok {
 		area := (xzr.x1 - xzr.x0) * (xzr.z1 - xzr.z0)
 		distanceSquared := rec.T() * rec.T() * v.SquaredLength()
 		cosine := math.Abs(vec3.Dot(v, vec3.ScalarDiv(rec.Normal(), v.Length())))
@@ -79,11 +79,12 @@ func (xzr *XZRect) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl) float64 {
 	return 0
 }
 
-func (xzr *XZRect) Random(o *vec3.Vec3Impl) *vec3.Vec3Impl {
+func (xzr *XZRect) Random(o *vec3.Vec3Impl, time float64, sampler sampler.Sampler) *vec3.Vec3Impl {
+	r1, r2 := sampler.Get2D()
 	randomPoint := &vec3.Vec3Impl{
-		X: xzr.x0 + rand.Float64()*(xzr.x1-xzr.x0),
+		X: xzr.x0 + r1*(xzr.x1-xzr.x0),
 		Y: xzr.k,
-		Z: xzr.z0 + rand.Float64()*(xzr.z1-xzr.z0),
+		Z: xzr.z0 + r2*(xzr.z1-xzr.z0),
 	}
 
 	return vec3.Sub(randomPoint, o)
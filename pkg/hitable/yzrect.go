@@ -1,10 +1,13 @@
 package hitable
 
 import (
+	"math"
+
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/aabb"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitrecord"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
 )
 
@@ -33,7 +36,7 @@ func NewYZRect(y0 float64, y1 float64, z0 float64, z1 float64, k float64, mat ma
 	}
 }
 
-func (yzr *YZRect) Hit(r ray.Ray, tMin float64, tMax float64) (*hitrecord.HitRecord, material.Material, bool) {
+func (yzr *YZRect) Hit(r ray.Ray, tMin float64, tMax float64, sampler sampler.Sampler) (*hitrecord.HitRecord, material.Material, bool) {
 	t := (yzr.k - r.Origin().X) / r.Direction().X
 	if t < tMin || t > tMax {
 		return nil, nil, false
@@ -64,10 +67,25 @@ func (yzr *YZRect) BoundingBox(time0 float64, time1 float64) (*aabb.AABB, bool)
 		}), true
 }
 
-func (yzr *YZRect) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl) float64 {
-	return 0.0
+func (yzr *YZRect) PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl, time float64) float64 {
+	r := ray.New(o, v, time)
+	if rec, _, ok := yzr.Hit(r, 0.001, math.MaxFloat64, nil); ok {
+		area := (yzr.y1 - yzr.y0) * (yzr.z1 - yzr.z0)
+		distanceSquared := rec.T() * rec.T() * v.SquaredLength()
+		cosine := math.Abs(vec3.Dot(v, vec3.ScalarDiv(rec.Normal(), v.Length())))
+		return distanceSquared / (cosine * area)
+	}
+
+	return 0
 }
 
-func (yzr *YZRect) Random(o *vec3.Vec3Impl) *vec3.Vec3Impl {
-	return &vec3.Vec3Impl{X: 1}
+func (yzr *YZRect) Random(o *vec3.Vec3Impl, time float64, sampler sampler.Sampler) *vec3.Vec3Impl {
+	r1, r2 := sampler.Get2D()
+	randomPoint := &vec3.Vec3Impl{
+		Y: yzr.y0 + r1*(yzr.y1-yzr.y0),
+		Z: yzr.z0 + r2*(yzr.z1-yzr.z0),
+		X: yzr.k,
+	}
+
+	return vec3.Sub(randomPoint, o)
 }
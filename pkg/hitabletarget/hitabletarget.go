@@ -2,10 +2,14 @@
 // This is done avoid a circular dependency between pdf, hitable and material.
 package hitabletarget
 
-import "github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+import (
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
 
 // HitableTarget defines the methods used to embed hitables in a PDF.
+// Both methods receive the time of the ray the PDF is used for.
 type HitableTarget interface {
-	PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl) float64
-	Random(o *vec3.Vec3Impl) *vec3.Vec3Impl
+	PDFValue(o *vec3.Vec3Impl, v *vec3.Vec3Impl, time float64) float64
+	Random(o *vec3.Vec3Impl, time float64, sampler sampler.Sampler) *vec3.Vec3Impl
 }
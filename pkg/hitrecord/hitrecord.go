@@ -9,6 +9,8 @@ type HitRecord struct {
 	t      float64
 	p      *vec3.Vec3Impl
 	normal *vec3.Vec3Impl
+	// color is the interpolated vertex color of meshes that have one.
+	color *vec3.Vec3Impl
 }
 
 func New(t float64, u float64, v float64, p *vec3.Vec3Impl, normal *vec3.Vec3Impl) *HitRecord {
@@ -45,3 +47,22 @@ func (hr *HitRecord) U() float64 {
 func (hr *HitRecord) V() float64 {
 	return hr.v
 }
+
+// Color returns the interpolated vertex color or nil if the object has no vertex colors.
+func (hr *HitRecord) Color() *vec3.Vec3Impl {
+	return hr.color
+}
+
+// SetColor sets the interpolated vertex color.
+func (hr *HitRecord) SetColor(color *vec3.Vec3Impl) {
+	hr.color = color
+}
+
+// Transformed returns a copy of the hit record with a different point and normal.
+// It is used by the hitables that move other hitables around.
+func (hr *HitRecord) Transformed(p *vec3.Vec3Impl, normal *vec3.Vec3Impl) *HitRecord {
+	nhr := *hr
+	nhr.p = p
+	nhr.normal = normal
+	return &nhr
+}
@@ -0,0 +1,55 @@
+package integrator
+
+import (
+	"math"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/pdf"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scene"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// Ensure interface compliance.
+var _ Integrator = (*AmbientOcclusion)(nil)
+
+// AmbientOcclusion implements an integrator that computes how exposed each point is to its surroundings.
+type AmbientOcclusion struct {
+	numSamples  int
+	maxDistance float64
+}
+
+// NewAmbientOcclusion returns an instance of the ambient occlusion integrator.
+// Occluders further away than maxDistance are ignored.
+func NewAmbientOcclusion(numSamples int, maxDistance float64) *AmbientOcclusion {
+	return &AmbientOcclusion{
+		numSamples:  numSamples,
+		maxDistance: maxDistance,
+	}
+}
+
+// Radiance returns the fraction of unoccluded directions around the first intersection as a grey value.
+func (ao *AmbientOcclusion) Radiance(r ray.Ray, s *scene.Scene, sampler sampler.Sampler) *vec3.Vec3Impl {
+	rec, _, ok := s.World().Hit(r, 0.001, math.MaxFloat64, sampler)
+	if !ok {
+		return &vec3.Vec3Impl{X: 1, Y: 1, Z: 1}
+	}
+
+	// Make sure we sample the hemisphere facing the incoming ray.
+	normal := rec.Normal()
+	if vec3.Dot(normal, r.Direction()) > 0 {
+		normal = vec3.ScalarMul(normal, -1)
+	}
+
+	p := pdf.NewCosine(normal)
+	unoccluded := 0
+	for i := 0; i < ao.numSamples; i++ {
+		direction := vec3.UnitVector(p.Generate(sampler))
+		if _, _, ok := s.World().Hit(ray.New(rec.P(), direction, r.Time()), 0.001, ao.maxDistance, sampler); !ok {
+			unoccluded++
+		}
+	}
+
+	v := float64(unoccluded) / float64(ao.numSamples)
+	return &vec3.Vec3Impl{X: v, Y: v, Z: v}
+}
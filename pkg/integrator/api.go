@@ -0,0 +1,15 @@
+// Package integrator implements the different light transport algorithms used by the renderer.
+package integrator
+
+import (
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scene"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// Integrator defines the methods used to compute the radiance arriving along a ray.
+type Integrator interface {
+	// Radiance returns the radiance carried by the supplied ray in the given scene.
+	Radiance(r ray.Ray, s *scene.Scene, sampler sampler.Sampler) *vec3.Vec3Impl
+}
@@ -0,0 +1,75 @@
+package integrator
+
+import (
+	"math"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/pdf"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scene"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// Ensure interface compliance.
+var _ Integrator = (*DirectLighting)(nil)
+
+// DirectLighting implements an integrator that only accounts for light arriving directly from emitters.
+// Specular surfaces are followed until a non-specular surface is found.
+type DirectLighting struct {
+	maxDepth int
+}
+
+// NewDirectLighting returns an instance of the direct lighting integrator that follows up to
+// maxDepth specular bounces. A maxDepth of 0 selects DefaultMaxDepth.
+func NewDirectLighting(maxDepth int) *DirectLighting {
+	return &DirectLighting{
+		maxDepth: orDefaultMaxDepth(maxDepth),
+	}
+}
+
+// Radiance returns the radiance carried by the supplied ray.
+func (dl *DirectLighting) Radiance(r ray.Ray, s *scene.Scene, sampler sampler.Sampler) *vec3.Vec3Impl {
+	radiance := &vec3.Vec3Impl{}
+	throughput := &vec3.Vec3Impl{X: 1, Y: 1, Z: 1}
+
+	for depth := 0; ; depth++ {
+		rec, mat, ok := s.World().Hit(r, 0.001, math.MaxFloat64, sampler)
+		if !ok {
+			return radiance
+		}
+
+		emitted := mat.Emitted(r, rec, rec.U(), rec.V(), rec.P())
+		radiance = vec3.Add(radiance, vec3.Mul(throughput, emitted))
+		_, srec, ok := mat.Scatter(r, rec, sampler)
+		if !ok || depth >= dl.maxDepth {
+			return radiance
+		}
+
+		if srec.IsSpecular() {
+			throughput = vec3.Mul(throughput, srec.Attenuation())
+			r = srec.SpecularRay()
+			continue
+		}
+
+		if s.Lights().Len() == 0 {
+			return radiance
+		}
+
+		pLight := pdf.NewHitable(s.Lights(), rec.P(), r.Time())
+		scattered := ray.New(rec.P(), pLight.Generate(sampler), r.Time())
+		pdfVal := pLight.Value(scattered.Direction())
+		if pdfVal <= 0 {
+			return radiance
+		}
+
+		lightRec, lightMat, ok := s.World().Hit(scattered, 0.001, math.MaxFloat64, sampler)
+		if !ok {
+			return radiance
+		}
+
+		// radiance + throughput * albedo * scatteringPDF() * lightEmitted / pdf
+		lightEmitted := lightMat.Emitted(scattered, lightRec, lightRec.U(), lightRec.V(), lightRec.P())
+		v1 := vec3.ScalarMul(lightEmitted, mat.ScatteringPDF(r, rec, scattered)/pdfVal)
+		return vec3.Add(radiance, vec3.Mul(throughput, vec3.Mul(srec.Attenuation(), v1)))
+	}
+}
@@ -0,0 +1,177 @@
+package integrator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/camera"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitable"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/pdf"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scene"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/texture"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// makeLitPlane returns a scene with a diffuse plane with albedo 0.5 lit by a sphere of
+// radius 0.5 and radiance 4 that sits 2 units above the origin. The radiance leaving
+// the origin is albedo/pi * pi * L * (r/d)^2 = 0.125.
+func makeLitPlane() *scene.Scene {
+	grey := material.NewLambertian(texture.NewConstant(&vec3.Vec3Impl{X: 0.5, Y: 0.5, Z: 0.5}))
+	light := material.NewDiffuseLight(texture.NewConstant(&vec3.Vec3Impl{X: 4, Y: 4, Z: 4}))
+	lightShape := hitable.NewSphere(&vec3.Vec3Impl{Y: 2}, &vec3.Vec3Impl{Y: 2}, 0, 1, 0.5, light)
+	world := hitable.NewSlice([]hitable.Hitable{
+		hitable.NewXZRect(-100, 100, -100, 100, 0, grey),
+		lightShape,
+	})
+	cam := camera.New(&vec3.Vec3Impl{Y: 1, Z: 3}, &vec3.Vec3Impl{}, &vec3.Vec3Impl{Y: 1}, 40, 1, 0, 1, 0, 1)
+	return scene.New(world, hitable.NewSlice([]hitable.Hitable{lightShape}), cam)
+}
+
+func TestIntegratorsConverge(t *testing.T) {
+	const want = 0.125
+	testData := []struct {
+		name       string
+		integrator Integrator
+		numSamples int
+	}{
+		{"path", NewPathTracer(0, 0), 20000},
+		// Terminating paths before they reach the light adds a lot of variance.
+		{"path with russian roulette", NewPathTracer(0, 1), 400000},
+		{"mis balance", NewMISPathTracer(HeuristicBalance, 0, 0), 20000},
+		{"mis power", NewMISPathTracer(HeuristicPower, 0, 0), 20000},
+		{"mis with russian roulette", NewMISPathTracer(HeuristicPower, 0, 1), 20000},
+		{"direct", NewDirectLighting(0), 20000},
+	}
+
+	s := makeLitPlane()
+	r := ray.New(&vec3.Vec3Impl{Y: 1, Z: 3}, &vec3.Vec3Impl{Y: -1, Z: -3}, 0)
+	for _, test := range testData {
+		smp := sampler.NewRandom(1)
+		sum := 0.0
+		for i := 0; i < test.numSamples; i++ {
+			smp.StartPixelSample(0, 0, i)
+			sum += test.integrator.Radiance(r, s, smp).Y
+		}
+		if got := sum / float64(test.numSamples); math.Abs(got-want) > 0.03*want {
+			t.Errorf("%v: Radiance() = %v, want %v", test.name, got, want)
+		}
+	}
+}
+
+func TestAmbientOcclusion(t *testing.T) {
+	grey := material.NewLambertian(texture.NewConstant(&vec3.Vec3Impl{X: 0.5, Y: 0.5, Z: 0.5}))
+	world := hitable.NewSlice([]hitable.Hitable{
+		hitable.NewXZRect(-100, 100, -100, 100, 0, grey),
+		hitable.NewBox(&vec3.Vec3Impl{X: -1, Y: 0.5, Z: -1}, &vec3.Vec3Impl{X: 1, Y: 1, Z: 1}, grey),
+	})
+	cam := camera.New(&vec3.Vec3Impl{Y: 1, Z: 3}, &vec3.Vec3Impl{}, &vec3.Vec3Impl{Y: 1}, 40, 1, 0, 1, 0, 1)
+	s := scene.New(world, hitable.NewSlice(nil), cam)
+	ao := NewAmbientOcclusion(64, 10)
+
+	testData := []struct {
+		name string
+		ray  ray.Ray
+		min  float64
+		max  float64
+	}{
+		{"unoccluded", ray.New(&vec3.Vec3Impl{X: 50, Y: 1}, &vec3.Vec3Impl{Y: -1}, 0), 1, 1},
+		{"under the box", ray.New(&vec3.Vec3Impl{Y: 0.25}, &vec3.Vec3Impl{Y: -1}, 0), 0, 0.5},
+		{"miss", ray.New(&vec3.Vec3Impl{Y: 1}, &vec3.Vec3Impl{Y: 1}, 0), 1, 1},
+	}
+
+	for _, test := range testData {
+		smp := sampler.NewRandom(1)
+		smp.StartPixelSample(0, 0, 0)
+		got := ao.Radiance(test.ray, s, smp)
+		if got.X < test.min || got.X > test.max || got.Y != got.X || got.Z != got.X {
+			t.Errorf("%v: Radiance() = %v, want a grey value between %v and %v", test.name, got, test.min, test.max)
+		}
+	}
+}
+
+func TestNormals(t *testing.T) {
+	grey := material.NewLambertian(texture.NewConstant(&vec3.Vec3Impl{X: 0.5, Y: 0.5, Z: 0.5}))
+	world := hitable.NewSlice([]hitable.Hitable{
+		hitable.NewXZRect(-100, 100, -100, 100, 0, grey),
+		hitable.NewSphere(&vec3.Vec3Impl{X: 50, Y: 1}, &vec3.Vec3Impl{X: 50, Y: 1}, 0, 1, 0.5, grey),
+	})
+	cam := camera.New(&vec3.Vec3Impl{Y: 1, Z: 3}, &vec3.Vec3Impl{}, &vec3.Vec3Impl{Y: 1}, 40, 1, 0, 1, 0, 1)
+	s := scene.New(world, hitable.NewSlice(nil), cam)
+
+	testData := []struct {
+		name string
+		ray  ray.Ray
+		want *vec3.Vec3Impl
+	}{
+		{"plane facing up", ray.New(&vec3.Vec3Impl{Y: 1}, &vec3.Vec3Impl{Y: -1}, 0), &vec3.Vec3Impl{X: 0.5, Y: 1, Z: 0.5}},
+		{"sphere facing +z", ray.New(&vec3.Vec3Impl{X: 50, Y: 1, Z: 5}, &vec3.Vec3Impl{Z: -1}, 0), &vec3.Vec3Impl{X: 0.5, Y: 0.5, Z: 1}},
+		{"sphere facing +x", ray.New(&vec3.Vec3Impl{X: 55, Y: 1}, &vec3.Vec3Impl{X: -1}, 0), &vec3.Vec3Impl{X: 1, Y: 0.5, Z: 0.5}},
+		{"miss", ray.New(&vec3.Vec3Impl{Y: 1}, &vec3.Vec3Impl{Y: 1}, 0), &vec3.Vec3Impl{}},
+	}
+
+	for _, test := range testData {
+		smp := sampler.NewRandom(1)
+		smp.StartPixelSample(0, 0, 0)
+		got := NewNormals().Radiance(test.ray, s, smp)
+		if diff := cmp.Diff(test.want, got, cmpopts.EquateApprox(0, 1e-12)); diff != "" {
+			t.Errorf("%v: Radiance() mismatch (-want +got):\n%s", test.name, diff)
+		}
+	}
+}
+
+// colour is the recursive path tracer the renderer used before integrators were introduced,
+// adapted to take its random numbers from a sampler.
+func colour(r ray.Ray, s *scene.Scene, sampler sampler.Sampler, depth int) *vec3.Vec3Impl {
+	if rec, mat, ok := s.World().Hit(r, 0.001, math.MaxFloat64, sampler); ok {
+		emitted := mat.Emitted(r, rec, rec.U(), rec.V(), rec.P())
+		if depth >= DefaultMaxDepth {
+			return emitted
+		}
+		_, srec, ok := mat.Scatter(r, rec, sampler)
+		if !ok {
+			return emitted
+		}
+		if srec.IsSpecular() {
+			return vec3.Add(emitted, vec3.Mul(srec.Attenuation(), colour(srec.SpecularRay(), s, sampler, depth+1)))
+		}
+		pLight := pdf.NewHitable(s.Lights(), rec.P(), r.Time())
+		p := pdf.NewMixture(pLight, srec.PDF())
+		scattered := ray.New(rec.P(), p.Generate(sampler), r.Time())
+		pdfVal := p.Value(scattered.Direction())
+		v1 := vec3.ScalarMul(colour(scattered, s, sampler, depth+1), mat.ScatteringPDF(r, rec, scattered))
+		return vec3.Add(emitted, vec3.ScalarDiv(vec3.Mul(srec.Attenuation(), v1), pdfVal))
+	}
+
+	return &vec3.Vec3Impl{}
+}
+
+func TestPathTracerMatchesColour(t *testing.T) {
+	s := makeLitPlane()
+	// Add a mirror and a glass sphere so that specular bounces are covered too.
+	world := hitable.NewSlice([]hitable.Hitable{
+		s.World(),
+		hitable.NewSphere(&vec3.Vec3Impl{X: -1, Y: 0.5}, &vec3.Vec3Impl{X: -1, Y: 0.5}, 0, 1, 0.5, material.NewMetal(&vec3.Vec3Impl{X: 0.9, Y: 0.9, Z: 0.9}, 0)),
+		hitable.NewSphere(&vec3.Vec3Impl{X: 1, Y: 0.5}, &vec3.Vec3Impl{X: 1, Y: 0.5}, 0, 1, 0.5, material.NewDielectric(1.5)),
+	})
+	s = scene.New(world, s.Lights(), s.Camera())
+
+	pt := NewPathTracer(0, 0)
+	camSampler := sampler.NewRandom(7)
+	want := sampler.NewRandom(42)
+	got := sampler.NewRandom(42)
+	for i := 0; i < 500; i++ {
+		x, y := i%25, i/25
+		camSampler.StartPixelSample(x, y, 0)
+		r := s.Camera().GetRay(float64(x)/25, float64(y)/20, camSampler)
+		want.StartPixelSample(x, y, 0)
+		got.StartPixelSample(x, y, 0)
+		if diff := cmp.Diff(colour(r, s, want, 0), pt.Radiance(r, s, got), cmpopts.EquateApprox(1e-9, 1e-12)); diff != "" {
+			t.Fatalf("Radiance() of ray %v mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
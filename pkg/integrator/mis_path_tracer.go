@@ -0,0 +1,156 @@
+package integrator
+
+import (
+	"math"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitrecord"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scatterrecord"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scene"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// Ensure interface compliance.
+var _ Integrator = (*MISPathTracer)(nil)
+
+const (
+	// HeuristicBalance weights every sampling strategy by its PDF.
+	HeuristicBalance int = iota
+	// HeuristicPower weights every sampling strategy by the square of its PDF.
+	HeuristicPower
+)
+
+// MISPathTracer implements a path tracer with next event estimation. At every non-specular
+// vertex the lights are sampled explicitly and the material PDF is sampled to continue the path.
+// Both estimates of the light arriving from emitters are combined with multiple importance sampling,
+// so emission found by the material samples is weighted instead of being counted twice.
+type MISPathTracer struct {
+	heuristic int
+	maxDepth  int
+	rrDepth   int
+}
+
+// NewMISPathTracer returns an instance of the MIS path tracer using the given heuristic.
+// The path length is controlled like in NewPathTracer.
+func NewMISPathTracer(heuristic int, maxDepth int, rrDepth int) *MISPathTracer {
+	return &MISPathTracer{
+		heuristic: heuristic,
+		maxDepth:  orDefaultMaxDepth(maxDepth),
+		rrDepth:   rrDepth,
+	}
+}
+
+// Radiance returns the radiance carried by the supplied ray.
+func (mpt *MISPathTracer) Radiance(r ray.Ray, s *scene.Scene, sampler sampler.Sampler) *vec3.Vec3Impl {
+	radiance := &vec3.Vec3Impl{}
+	throughput := &vec3.Vec3Impl{X: 1, Y: 1, Z: 1}
+	// Emission seen by camera rays and specular bounces cannot be found by light sampling.
+	specularBounce := true
+	var prevP *vec3.Vec3Impl
+	var prevPDF float64
+
+	for depth := 0; ; depth++ {
+		rec, mat, ok := s.World().Hit(r, 0.001, math.MaxFloat64, sampler)
+		if !ok {
+			break
+		}
+
+		emitted := mat.Emitted(r, rec, rec.U(), rec.V(), rec.P())
+		if !isBlack(emitted) {
+			weight := 1.0
+			if !specularBounce && s.Lights().Len() > 0 {
+				weight = mpt.weight(prevPDF, s.Lights().PDFValue(prevP, r.Direction(), r.Time()))
+			}
+			radiance = vec3.Add(radiance, vec3.ScalarMul(vec3.Mul(throughput, emitted), weight))
+		}
+
+		if depth >= mpt.maxDepth {
+			break
+		}
+
+		_, srec, ok := mat.Scatter(r, rec, sampler)
+		if !ok {
+			break
+		}
+
+		if srec.IsSpecular() {
+			throughput = vec3.Mul(throughput, srec.Attenuation())
+			r = srec.SpecularRay()
+			specularBounce = true
+			if throughput, ok = russianRoulette(throughput, depth, mpt.rrDepth, sampler); !ok {
+				break
+			}
+			continue
+		}
+
+		if s.Lights().Len() > 0 {
+			direct := mpt.sampleLights(r, rec, mat, srec, s, sampler)
+			radiance = vec3.Add(radiance, vec3.Mul(throughput, direct))
+		}
+
+		scattered := ray.New(rec.P(), srec.PDF().Generate(sampler), r.Time())
+		pdfVal := srec.PDF().Value(scattered.Direction())
+		if pdfVal <= 0 {
+			break
+		}
+
+		// throughput * albedo * scatteringPDF / pdf
+		throughput = vec3.Mul(throughput, vec3.ScalarMul(srec.Attenuation(), mat.ScatteringPDF(r, rec, scattered)/pdfVal))
+		prevP = rec.P()
+		prevPDF = pdfVal
+		specularBounce = false
+		r = scattered
+		if throughput, ok = russianRoulette(throughput, depth, mpt.rrDepth, sampler); !ok {
+			break
+		}
+	}
+
+	return radiance
+}
+
+// sampleLights returns the MIS weighted light arriving at the hit point from a direction
+// chosen by sampling the lights.
+func (mpt *MISPathTracer) sampleLights(r ray.Ray, rec *hitrecord.HitRecord, mat material.Material,
+	srec *scatterrecord.ScatterRecord, s *scene.Scene, sampler sampler.Sampler) *vec3.Vec3Impl {
+	direction := s.Lights().Random(rec.P(), r.Time(), sampler)
+	lightPDF := s.Lights().PDFValue(rec.P(), direction, r.Time())
+	if lightPDF <= 0 {
+		return &vec3.Vec3Impl{}
+	}
+
+	shadowRay := ray.New(rec.P(), direction, r.Time())
+	scatteringPDF := mat.ScatteringPDF(r, rec, shadowRay)
+	if scatteringPDF <= 0 {
+		return &vec3.Vec3Impl{}
+	}
+
+	lightRec, lightMat, ok := s.World().Hit(shadowRay, 0.001, math.MaxFloat64, sampler)
+	if !ok {
+		return &vec3.Vec3Impl{}
+	}
+
+	emitted := lightMat.Emitted(shadowRay, lightRec, lightRec.U(), lightRec.V(), lightRec.P())
+	if isBlack(emitted) {
+		return &vec3.Vec3Impl{}
+	}
+
+	// albedo * scatteringPDF * emitted * weight / lightPDF
+	weight := mpt.weight(lightPDF, srec.PDF().Value(direction))
+	return vec3.ScalarMul(vec3.Mul(srec.Attenuation(), emitted), scatteringPDF*weight/lightPDF)
+}
+
+// weight returns the MIS weight of a sample taken with PDF f when the same direction
+// could also have been generated with PDF g.
+func (mpt *MISPathTracer) weight(f float64, g float64) float64 {
+	if mpt.heuristic == HeuristicPower {
+		f *= f
+		g *= g
+	}
+	if f+g == 0 {
+		return 0
+	}
+
+	return f / (f + g)
+}
@@ -0,0 +1,32 @@
+package integrator
+
+import (
+	"math"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scene"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// Ensure interface compliance.
+var _ Integrator = (*Normals)(nil)
+
+// Normals implements a debug integrator that visualises the surface normals.
+type Normals struct{}
+
+// NewNormals returns an instance of the normals integrator.
+func NewNormals() *Normals {
+	return &Normals{}
+}
+
+// Radiance maps the normal at the first intersection to an RGB value.
+func (n *Normals) Radiance(r ray.Ray, s *scene.Scene, sampler sampler.Sampler) *vec3.Vec3Impl {
+	if rec, _, ok := s.World().Hit(r, 0.001, math.MaxFloat64, sampler); ok {
+		normal := vec3.UnitVector(rec.Normal())
+		// 0.5 * (normal + 1)
+		return vec3.ScalarMul(vec3.Add(normal, &vec3.Vec3Impl{X: 1, Y: 1, Z: 1}), 0.5)
+	}
+
+	return &vec3.Vec3Impl{}
+}
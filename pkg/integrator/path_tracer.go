@@ -0,0 +1,87 @@
+package integrator
+
+import (
+	"math"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/pdf"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scene"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// Ensure interface compliance.
+var _ Integrator = (*PathTracer)(nil)
+
+const (
+	// DefaultMaxDepth is the maximum number of bounces used when none is given.
+	DefaultMaxDepth = 50
+)
+
+// PathTracer implements a path tracer that samples a mixture of the light and material PDFs.
+type PathTracer struct {
+	maxDepth int
+	rrDepth  int
+}
+
+// NewPathTracer returns an instance of the path tracer integrator. Paths are terminated after
+// maxDepth bounces and Russian roulette is applied from rrDepth bounces onwards.
+// A maxDepth of 0 selects DefaultMaxDepth and a rrDepth of 0 disables Russian roulette.
+func NewPathTracer(maxDepth int, rrDepth int) *PathTracer {
+	return &PathTracer{
+		maxDepth: orDefaultMaxDepth(maxDepth),
+		rrDepth:  rrDepth,
+	}
+}
+
+// Radiance returns the radiance carried by the supplied ray.
+func (pt *PathTracer) Radiance(r ray.Ray, s *scene.Scene, sampler sampler.Sampler) *vec3.Vec3Impl {
+	radiance := &vec3.Vec3Impl{}
+	throughput := &vec3.Vec3Impl{X: 1, Y: 1, Z: 1}
+
+	for depth := 0; ; depth++ {
+		rec, mat, ok := s.World().Hit(r, 0.001, math.MaxFloat64, sampler)
+		if !ok {
+			break
+		}
+
+		// radiance += throughput * emitted
+		emitted := mat.Emitted(r, rec, rec.U(), rec.V(), rec.P())
+		radiance = vec3.Add(radiance, vec3.Mul(throughput, emitted))
+		if depth >= pt.maxDepth {
+			break
+		}
+
+		_, srec, ok := mat.Scatter(r, rec, sampler)
+		if !ok {
+			break
+		}
+
+		if srec.IsSpecular() {
+			throughput = vec3.Mul(throughput, srec.Attenuation())
+			r = srec.SpecularRay()
+		} else {
+			var p pdf.PDF
+			if s.Lights().Len() > 0 {
+				pLight := pdf.NewHitable(s.Lights(), rec.P(), r.Time())
+				p = pdf.NewMixture(pLight, srec.PDF())
+			} else {
+				p = srec.PDF()
+			}
+			scattered := ray.New(rec.P(), p.Generate(sampler), r.Time())
+			pdfVal := p.Value(scattered.Direction())
+			if pdfVal <= 0 {
+				break
+			}
+			// throughput * albedo * scatteringPDF / pdf
+			throughput = vec3.Mul(throughput, vec3.ScalarMul(srec.Attenuation(), mat.ScatteringPDF(r, rec, scattered)/pdfVal))
+			r = scattered
+		}
+
+		if throughput, ok = russianRoulette(throughput, depth, pt.rrDepth, sampler); !ok {
+			break
+		}
+	}
+
+	return radiance
+}
@@ -0,0 +1,46 @@
+package integrator
+
+import (
+	"math"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+const (
+	// maxSurvivalProbability keeps a small chance of terminating paths that carry a lot of energy.
+	maxSurvivalProbability = 0.95
+)
+
+// russianRoulette randomly terminates paths that have bounced at least rrDepth times with a
+// probability that grows as their throughput decreases. Surviving paths have their throughput
+// scaled up to keep the estimate unbiased. It returns false when the path must be terminated.
+// A rrDepth of 0 disables Russian roulette.
+func russianRoulette(throughput *vec3.Vec3Impl, depth int, rrDepth int, sampler sampler.Sampler) (*vec3.Vec3Impl, bool) {
+	if isBlack(throughput) {
+		return throughput, false
+	}
+
+	if rrDepth <= 0 || depth+1 < rrDepth {
+		return throughput, true
+	}
+
+	survival := math.Min(math.Max(throughput.X, math.Max(throughput.Y, throughput.Z)), maxSurvivalProbability)
+	if sampler.Get1D() >= survival {
+		return throughput, false
+	}
+
+	return vec3.ScalarDiv(throughput, survival), true
+}
+
+func orDefaultMaxDepth(maxDepth int) int {
+	if maxDepth <= 0 {
+		return DefaultMaxDepth
+	}
+
+	return maxDepth
+}
+
+func isBlack(v *vec3.Vec3Impl) bool {
+	return v.X == 0 && v.Y == 0 && v.Z == 0
+}
@@ -0,0 +1,477 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitable"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/texture"
+)
+
+const (
+	glbMagic     = 0x46546c67
+	glbChunkJSON = 0x4e4f534a
+	glbChunkBIN  = 0x004e4942
+)
+
+// Accessor component types.
+const (
+	gltfInt8    = 5120
+	gltfUint8   = 5121
+	gltfInt16   = 5122
+	gltfUint16  = 5123
+	gltfUint32  = 5125
+	gltfFloat32 = 5126
+)
+
+var gltfComponents = map[string]int{
+	"SCALAR": 1,
+	"VEC2":   2,
+	"VEC3":   3,
+	"VEC4":   4,
+	"MAT2":   4,
+	"MAT3":   9,
+	"MAT4":   16,
+}
+
+var gltfComponentSizes = map[int]int{
+	gltfInt8:    1,
+	gltfUint8:   1,
+	gltfInt16:   2,
+	gltfUint16:  2,
+	gltfUint32:  4,
+	gltfFloat32: 4,
+}
+
+// The extensions that are understood when a file requires them.
+var gltfSupportedExtensions = map[string]bool{
+	"KHR_lights_punctual":             true,
+	"KHR_materials_emissive_strength": true,
+	"KHR_materials_ior":               true,
+	"KHR_materials_transmission":      true,
+}
+
+type gltfDocument struct {
+	Asset struct {
+		Version string `json:"version"`
+	} `json:"asset"`
+	ExtensionsRequired []string `json:"extensionsRequired"`
+	Scene              *int     `json:"scene"`
+	Scenes             []struct {
+		Nodes []int `json:"nodes"`
+	} `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes"`
+	Meshes      []gltfMesh       `json:"meshes"`
+	Accessors   []gltfAccessor   `json:"accessors"`
+	BufferViews []gltfBufferView `json:"bufferViews"`
+	Buffers     []gltfBuffer     `json:"buffers"`
+	Materials   []gltfMaterial   `json:"materials"`
+	Textures    []gltfTexture    `json:"textures"`
+	Images      []gltfImage      `json:"images"`
+	Cameras     []gltfCamera     `json:"cameras"`
+	Extensions  struct {
+		LightsPunctual struct {
+			Lights []gltfLight `json:"lights"`
+		} `json:"KHR_lights_punctual"`
+	} `json:"extensions"`
+}
+
+type gltfNode struct {
+	Children    []int       `json:"children"`
+	Mesh        *int        `json:"mesh"`
+	Camera      *int        `json:"camera"`
+	Matrix      []float64   `json:"matrix"`
+	Translation *[3]float64 `json:"translation"`
+	Rotation    *[4]float64 `json:"rotation"`
+	Scale       *[3]float64 `json:"scale"`
+	Extensions  struct {
+		LightsPunctual *struct {
+			Light int `json:"light"`
+		} `json:"KHR_lights_punctual"`
+	} `json:"extensions"`
+}
+
+type gltfMesh struct {
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices"`
+	Material   *int           `json:"material"`
+	Mode       *int           `json:"mode"`
+}
+
+type gltfAccessor struct {
+	BufferView    *int            `json:"bufferView"`
+	ByteOffset    int             `json:"byteOffset"`
+	ComponentType int             `json:"componentType"`
+	Normalized    bool            `json:"normalized"`
+	Count         int             `json:"count"`
+	Type          string          `json:"type"`
+	Sparse        json.RawMessage `json:"sparse"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride"`
+}
+
+type gltfBuffer struct {
+	URI        string `json:"uri"`
+	ByteLength int    `json:"byteLength"`
+}
+
+type gltfMaterial struct {
+	PBRMetallicRoughness struct {
+		BaseColorFactor  *[4]float64      `json:"baseColorFactor"`
+		BaseColorTexture *gltfTextureInfo `json:"baseColorTexture"`
+		MetallicFactor   *float64         `json:"metallicFactor"`
+		RoughnessFactor  *float64         `json:"roughnessFactor"`
+	} `json:"pbrMetallicRoughness"`
+	EmissiveFactor *[3]float64 `json:"emissiveFactor"`
+	Extensions     struct {
+		Transmission *struct {
+			TransmissionFactor float64 `json:"transmissionFactor"`
+		} `json:"KHR_materials_transmission"`
+		IOR *struct {
+			IOR *float64 `json:"ior"`
+		} `json:"KHR_materials_ior"`
+		EmissiveStrength *struct {
+			EmissiveStrength *float64 `json:"emissiveStrength"`
+		} `json:"KHR_materials_emissive_strength"`
+	} `json:"extensions"`
+}
+
+type gltfTextureInfo struct {
+	Index    int `json:"index"`
+	TexCoord int `json:"texCoord"`
+}
+
+type gltfTexture struct {
+	Source *int `json:"source"`
+}
+
+type gltfImage struct {
+	URI        string `json:"uri"`
+	BufferView *int   `json:"bufferView"`
+}
+
+type gltfCamera struct {
+	Type        string `json:"type"`
+	Perspective *struct {
+		YFov float64 `json:"yfov"`
+	} `json:"perspective"`
+}
+
+type gltfLight struct {
+	Type      string      `json:"type"`
+	Color     *[3]float64 `json:"color"`
+	Intensity *float64    `json:"intensity"`
+}
+
+// gltfLoader builds a model out of a parsed glTF document.
+type gltfLoader struct {
+	fsys      fs.FS
+	dir       string
+	name      string
+	doc       *gltfDocument
+	buffers   [][]byte
+	materials map[int]material.Material
+	textures  map[int]*texture.ImageTxt
+	model     *Model
+	hitables  []hitable.Hitable
+}
+
+// LoadGLTF reads a glTF 2.0 file. External buffers and images are resolved relative
+// to the directory that contains the file.
+func LoadGLTF(fileName string) (*Model, error) {
+	return ReadGLTF(os.DirFS(filepath.Dir(fileName)), filepath.Base(fileName))
+}
+
+// ReadGLTF reads the named glTF 2.0 file from fsys. Both JSON files and binary GLB files
+// are supported. The default scene is instantiated with the transformation of every node
+// applied to its meshes, cameras and lights.
+//
+// Metallic-roughness materials are mapped onto the closest available material: emissive
+// materials become diffuse lights, transmissive ones dielectrics, mostly metallic ones
+// metals whose fuzziness is the roughness and everything else Lambertian using the base
+// color texture. Point and spot lights from KHR_lights_punctual become small emissive
+// spheres of the same intensity. Directional lights, orthographic cameras, sparse accessors
+// and primitives that are not made of triangles are ignored or rejected.
+func ReadGLTF(fsys fs.FS, name string) (*Model, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	gl := &gltfLoader{
+		fsys:      fsys,
+		dir:       path.Dir(name),
+		name:      name,
+		materials: make(map[int]material.Material),
+		textures:  make(map[int]*texture.ImageTxt),
+		model:     &Model{},
+	}
+
+	jsonData, bin := data, []byte(nil)
+	if len(data) >= 4 && binary.LittleEndian.Uint32(data) == glbMagic {
+		if jsonData, bin, err = parseGLB(data); err != nil {
+			return nil, gl.errorf("%v", err)
+		}
+	}
+
+	gl.doc = &gltfDocument{}
+	if err := json.Unmarshal(jsonData, gl.doc); err != nil {
+		return nil, gl.errorf("%v", err)
+	}
+	if !strings.HasPrefix(gl.doc.Asset.Version, "2.") {
+		return nil, gl.errorf("unsupported glTF version %q", gl.doc.Asset.Version)
+	}
+	for _, ext := range gl.doc.ExtensionsRequired {
+		if !gltfSupportedExtensions[ext] {
+			return nil, gl.errorf("required extension %q is not supported", ext)
+		}
+	}
+
+	if err := gl.loadBuffers(bin); err != nil {
+		return nil, err
+	}
+
+	return gl.build()
+}
+
+// parseGLB returns the JSON and binary chunks of a GLB file.
+func parseGLB(data []byte) ([]byte, []byte, error) {
+	if len(data) < 20 {
+		return nil, nil, errors.New("GLB file is too short")
+	}
+	if version := binary.LittleEndian.Uint32(data[4:]); version != 2 {
+		return nil, nil, fmt.Errorf("unsupported GLB version %v", version)
+	}
+	length := int(binary.LittleEndian.Uint32(data[8:]))
+	if length > len(data) {
+		return nil, nil, fmt.Errorf("GLB file is truncated, expected %v bytes, got %v", length, len(data))
+	}
+
+	var jsonData, bin []byte
+	for offset := 12; offset+8 <= length; {
+		chunkLength := int(binary.LittleEndian.Uint32(data[offset:]))
+		chunkType := binary.LittleEndian.Uint32(data[offset+4:])
+		start := offset + 8
+		if chunkLength > length-start {
+			return nil, nil, fmt.Errorf("GLB chunk at offset %v is truncated", offset)
+		}
+		chunk := data[start : start+chunkLength]
+		switch {
+		case chunkType == glbChunkJSON && jsonData == nil:
+			jsonData = chunk
+		case chunkType == glbChunkBIN && bin == nil:
+			bin = chunk
+		}
+		offset = start + chunkLength
+	}
+
+	if jsonData == nil {
+		return nil, nil, errors.New("GLB file without a JSON chunk")
+	}
+
+	return jsonData, bin, nil
+}
+
+// errorf returns an error prefixed with the name of the file.
+func (gl *gltfLoader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%v: %v", gl.name, fmt.Sprintf(format, args...))
+}
+
+// readURI returns the data referenced by a data URI or a path relative to the file.
+func (gl *gltfLoader) readURI(uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, "data:") {
+		i := strings.Index(uri, ";base64,")
+		if i < 0 {
+			return nil, errors.New("only base64 data URIs are supported")
+		}
+		return base64.StdEncoding.DecodeString(uri[i+len(";base64,"):])
+	}
+
+	name, err := url.PathUnescape(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	return fs.ReadFile(gl.fsys, path.Join(gl.dir, name))
+}
+
+func (gl *gltfLoader) loadBuffers(bin []byte) error {
+	for i, b := range gl.doc.Buffers {
+		var data []byte
+		var err error
+		switch {
+		case b.URI != "":
+			data, err = gl.readURI(b.URI)
+		case i == 0 && bin != nil:
+			data = bin
+		default:
+			err = errors.New("buffer without data")
+		}
+		if err != nil {
+			return gl.errorf("buffers[%v]: %v", i, err)
+		}
+		if len(data) < b.ByteLength {
+			return gl.errorf("buffers[%v]: expected %v bytes, got %v", i, b.ByteLength, len(data))
+		}
+		gl.buffers = append(gl.buffers, data)
+	}
+
+	return nil
+}
+
+// bufferView returns the bytes of the given buffer view and its stride.
+func (gl *gltfLoader) bufferView(index int) ([]byte, int, error) {
+	if index < 0 || index >= len(gl.doc.BufferViews) {
+		return nil, 0, fmt.Errorf("buffer view %v does not exist", index)
+	}
+
+	view := gl.doc.BufferViews[index]
+	if view.Buffer < 0 || view.Buffer >= len(gl.buffers) {
+		return nil, 0, fmt.Errorf("bufferViews[%v]: buffer %v does not exist", index, view.Buffer)
+	}
+	buf := gl.buffers[view.Buffer]
+	if view.ByteOffset < 0 || view.ByteLength < 0 || view.ByteOffset+view.ByteLength > len(buf) {
+		return nil, 0, fmt.Errorf("bufferViews[%v]: range is outside of buffer %v", index, view.Buffer)
+	}
+
+	return buf[view.ByteOffset : view.ByteOffset+view.ByteLength], view.ByteStride, nil
+}
+
+// accessor returns the values of the given accessor as a flat slice together with the
+// number of components of every element. Normalized integers are mapped to [0, 1] or [-1, 1].
+func (gl *gltfLoader) accessor(index int) ([]float64, int, error) {
+	if index < 0 || index >= len(gl.doc.Accessors) {
+		return nil, 0, fmt.Errorf("accessor %v does not exist", index)
+	}
+
+	acc := gl.doc.Accessors[index]
+	errorf := func(format string, args ...interface{}) error {
+		return fmt.Errorf("accessors[%v]: %v", index, fmt.Sprintf(format, args...))
+	}
+	if len(acc.Sparse) > 0 {
+		return nil, 0, errorf("sparse accessors are not supported")
+	}
+	components, ok := gltfComponents[acc.Type]
+	if !ok {
+		return nil, 0, errorf("unknown type %q", acc.Type)
+	}
+	size, ok := gltfComponentSizes[acc.ComponentType]
+	if !ok {
+		return nil, 0, errorf("unknown component type %v", acc.ComponentType)
+	}
+	if acc.Count < 0 {
+		return nil, 0, errorf("invalid count %v", acc.Count)
+	}
+
+	values := make([]float64, acc.Count*components)
+	if acc.BufferView == nil {
+		return values, components, nil
+	}
+
+	data, stride, err := gl.bufferView(*acc.BufferView)
+	if err != nil {
+		return nil, 0, errorf("%v", err)
+	}
+	elementSize := size * components
+	if stride == 0 {
+		stride = elementSize
+	}
+	if acc.Count > 0 && (acc.ByteOffset < 0 || acc.ByteOffset+stride*(acc.Count-1)+elementSize > len(data)) {
+		return nil, 0, errorf("elements are outside of buffer view %v", *acc.BufferView)
+	}
+
+	for i := 0; i < acc.Count; i++ {
+		for j := 0; j < components; j++ {
+			b := data[acc.ByteOffset+i*stride+j*size:]
+			var v float64
+			switch acc.ComponentType {
+			case gltfInt8:
+				v = float64(int8(b[0]))
+				if acc.Normalized {
+					v = math.Max(v/127, -1)
+				}
+			case gltfUint8:
+				v = float64(b[0])
+				if acc.Normalized {
+					v /= 255
+				}
+			case gltfInt16:
+				v = float64(int16(binary.LittleEndian.Uint16(b)))
+				if acc.Normalized {
+					v = math.Max(v/32767, -1)
+				}
+			case gltfUint16:
+				v = float64(binary.LittleEndian.Uint16(b))
+				if acc.Normalized {
+					v /= 65535
+				}
+			case gltfUint32:
+				v = float64(binary.LittleEndian.Uint32(b))
+			case gltfFloat32:
+				v = float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+			}
+			values[i*components+j] = v
+		}
+	}
+
+	return values, components, nil
+}
+
+// image decodes the image used by the given texture.
+func (gl *gltfLoader) image(index int) (*texture.ImageTxt, error) {
+	if txt, ok := gl.textures[index]; ok {
+		return txt, nil
+	}
+
+	if index < 0 || index >= len(gl.doc.Textures) {
+		return nil, fmt.Errorf("texture %v does not exist", index)
+	}
+	source := gl.doc.Textures[index].Source
+	if source == nil || *source < 0 || *source >= len(gl.doc.Images) {
+		return nil, fmt.Errorf("textures[%v]: missing or invalid image", index)
+	}
+
+	img := gl.doc.Images[*source]
+	var data []byte
+	var err error
+	switch {
+	case img.BufferView != nil:
+		data, _, err = gl.bufferView(*img.BufferView)
+	case img.URI != "":
+		data, err = gl.readURI(img.URI)
+	default:
+		err = errors.New("image without data")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("images[%v]: %v", *source, err)
+	}
+
+	txt, err := decodeImage(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("images[%v]: %v", *source, err)
+	}
+
+	gl.textures[index] = txt
+	return txt, nil
+}
@@ -0,0 +1,415 @@
+package loader
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitable"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/texture"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// Primitive modes.
+const (
+	gltfTriangles     = 4
+	gltfTriangleStrip = 5
+	gltfTriangleFan   = 6
+)
+
+const (
+	// Radius of the spheres that replace point and spot lights.
+	pointLightRadius = 0.05
+	// Materials at least this metallic become metals.
+	metallicThreshold = 0.5
+)
+
+// build instantiates the nodes of the default scene.
+func (gl *gltfLoader) build() (*Model, error) {
+	var roots []int
+	switch {
+	case gl.doc.Scene != nil:
+		if *gl.doc.Scene < 0 || *gl.doc.Scene >= len(gl.doc.Scenes) {
+			return nil, gl.errorf("scene %v does not exist", *gl.doc.Scene)
+		}
+		roots = gl.doc.Scenes[*gl.doc.Scene].Nodes
+	case len(gl.doc.Scenes) > 0:
+		roots = gl.doc.Scenes[0].Nodes
+	default:
+		// Without scenes every node that is not a child of another one is a root.
+		isChild := make(map[int]bool)
+		for _, node := range gl.doc.Nodes {
+			for _, child := range node.Children {
+				isChild[child] = true
+			}
+		}
+		for i := range gl.doc.Nodes {
+			if !isChild[i] {
+				roots = append(roots, i)
+			}
+		}
+	}
+
+	visiting := make(map[int]bool)
+	for _, root := range roots {
+		if err := gl.visit(root, identity(), visiting); err != nil {
+			return nil, gl.errorf("%v", err)
+		}
+	}
+
+	if len(gl.hitables) == 0 {
+		return nil, gl.errorf("the scene has no geometry")
+	}
+
+	gl.model.hitable = hitable.NewBVH(gl.hitables, 0, 1)
+	return gl.model, nil
+}
+
+// visit instantiates a node and its children with the given parent transformation.
+func (gl *gltfLoader) visit(index int, parent mat4, visiting map[int]bool) error {
+	if index < 0 || index >= len(gl.doc.Nodes) {
+		return fmt.Errorf("node %v does not exist", index)
+	}
+	if visiting[index] {
+		return fmt.Errorf("nodes[%v] is part of a cycle", index)
+	}
+	visiting[index] = true
+	defer delete(visiting, index)
+
+	node := gl.doc.Nodes[index]
+	local, err := nodeTransform(node)
+	if err != nil {
+		return fmt.Errorf("nodes[%v]: %v", index, err)
+	}
+	world := parent.mul(local)
+
+	if node.Mesh != nil {
+		if err := gl.instantiateMesh(*node.Mesh, world); err != nil {
+			return err
+		}
+	}
+	if node.Camera != nil {
+		if err := gl.instantiateCamera(*node.Camera, world); err != nil {
+			return err
+		}
+	}
+	if node.Extensions.LightsPunctual != nil {
+		if err := gl.instantiateLight(node.Extensions.LightsPunctual.Light, world); err != nil {
+			return err
+		}
+	}
+
+	for _, child := range node.Children {
+		if err := gl.visit(child, world, visiting); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nodeTransform returns the local transformation of a node.
+func nodeTransform(node gltfNode) (mat4, error) {
+	if node.Matrix != nil {
+		var m mat4
+		if len(node.Matrix) != len(m) {
+			return m, fmt.Errorf("matrix has %v elements, want 16", len(node.Matrix))
+		}
+		copy(m[:], node.Matrix)
+		return m, nil
+	}
+
+	t, r, s := [3]float64{}, [4]float64{0, 0, 0, 1}, [3]float64{1, 1, 1}
+	if node.Translation != nil {
+		t = *node.Translation
+	}
+	if node.Rotation != nil {
+		r = *node.Rotation
+	}
+	if node.Scale != nil {
+		s = *node.Scale
+	}
+
+	return trs(t, r, s), nil
+}
+
+func (gl *gltfLoader) instantiateMesh(index int, world mat4) error {
+	if index < 0 || index >= len(gl.doc.Meshes) {
+		return fmt.Errorf("mesh %v does not exist", index)
+	}
+
+	for i, prim := range gl.doc.Meshes[index].Primitives {
+		if err := gl.instantiatePrimitive(prim, world); err != nil {
+			return fmt.Errorf("meshes[%v].primitives[%v]: %v", index, i, err)
+		}
+	}
+
+	return nil
+}
+
+func (gl *gltfLoader) instantiatePrimitive(prim gltfPrimitive, world mat4) error {
+	mode := gltfTriangles
+	if prim.Mode != nil {
+		mode = *prim.Mode
+	}
+	if mode != gltfTriangles && mode != gltfTriangleStrip && mode != gltfTriangleFan {
+		// Points and lines have no surface.
+		return nil
+	}
+
+	position, ok := prim.Attributes["POSITION"]
+	if !ok {
+		return fmt.Errorf("missing POSITION attribute")
+	}
+	positions, components, err := gl.accessor(position)
+	if err != nil {
+		return err
+	}
+	if components != 3 {
+		return fmt.Errorf("POSITION has %v components, want 3", components)
+	}
+	numVertices := len(positions) / 3
+
+	mat, texCoord, err := gl.material(prim.Material)
+	if err != nil {
+		return err
+	}
+
+	vertices := make([]*vec3.Vec3Impl, numVertices)
+	for i := range vertices {
+		vertices[i] = world.point(&vec3.Vec3Impl{X: positions[i*3], Y: positions[i*3+1], Z: positions[i*3+2]})
+	}
+
+	var normals []*vec3.Vec3Impl
+	if index, ok := prim.Attributes["NORMAL"]; ok {
+		values, components, err := gl.accessor(index)
+		if err != nil {
+			return err
+		}
+		if components != 3 || len(values) != len(positions) {
+			return fmt.Errorf("NORMAL does not match POSITION")
+		}
+		normals = make([]*vec3.Vec3Impl, numVertices)
+		for i := range normals {
+			normals[i] = world.normal(&vec3.Vec3Impl{X: values[i*3], Y: values[i*3+1], Z: values[i*3+2]})
+		}
+	}
+
+	var uvs []*vec3.Vec3Impl
+	attribute := fmt.Sprintf("TEXCOORD_%v", texCoord)
+	if index, ok := prim.Attributes[attribute]; ok {
+		values, components, err := gl.accessor(index)
+		if err != nil {
+			return err
+		}
+		if components != 2 || len(values) != numVertices*2 {
+			return fmt.Errorf("%v does not match POSITION", attribute)
+		}
+		uvs = make([]*vec3.Vec3Impl, numVertices)
+		for i := range uvs {
+			// The origin of glTF texture coordinates is the top left corner of the image.
+			uvs[i] = &vec3.Vec3Impl{X: values[i*2], Y: 1 - values[i*2+1]}
+		}
+	}
+
+	var order []int
+	if prim.Indices != nil {
+		values, components, err := gl.accessor(*prim.Indices)
+		if err != nil {
+			return err
+		}
+		if components != 1 {
+			return fmt.Errorf("indices have %v components, want 1", components)
+		}
+		order = make([]int, len(values))
+		for i, v := range values {
+			order[i] = int(v)
+		}
+	} else {
+		order = make([]int, numVertices)
+		for i := range order {
+			order[i] = i
+		}
+	}
+
+	indices := triangulate(order, mode)
+	if len(indices) == 0 {
+		return nil
+	}
+	if world.determinant() < 0 {
+		// Mirroring transformations reverse the winding of the triangles.
+		for i := 0; i < len(indices); i += 3 {
+			indices[i+1], indices[i+2] = indices[i+2], indices[i+1]
+		}
+	}
+
+	tm, err := hitable.NewTriangleMesh(vertices, normals, uvs, indices, mat)
+	if err != nil {
+		return err
+	}
+
+	gl.hitables = append(gl.hitables, tm)
+	gl.model.numTriangles += tm.NumTriangles()
+	if _, ok := mat.(*material.DiffuseLight); ok {
+		gl.model.lights = append(gl.model.lights, tm)
+	}
+
+	return nil
+}
+
+// triangulate turns the vertex order of a primitive into a list of triangles.
+func triangulate(order []int, mode int) []int {
+	indices := []int{}
+	switch mode {
+	case gltfTriangleStrip:
+		for i := 0; i+2 < len(order); i++ {
+			// Every other triangle is flipped to keep the winding consistent.
+			if i%2 == 0 {
+				indices = append(indices, order[i], order[i+1], order[i+2])
+			} else {
+				indices = append(indices, order[i+1], order[i], order[i+2])
+			}
+		}
+	case gltfTriangleFan:
+		for i := 1; i+1 < len(order); i++ {
+			indices = append(indices, order[0], order[i], order[i+1])
+		}
+	default:
+		indices = append(indices, order[:len(order)-len(order)%3]...)
+	}
+
+	return indices
+}
+
+// material returns the material with the given index and the texture coordinate set it uses.
+func (gl *gltfLoader) material(index *int) (material.Material, int, error) {
+	if index == nil {
+		return material.NewLambertian(texture.NewConstant(defaultDiffuse())), 0, nil
+	}
+	if *index < 0 || *index >= len(gl.doc.Materials) {
+		return nil, 0, fmt.Errorf("material %v does not exist", *index)
+	}
+
+	m := gl.doc.Materials[*index]
+	pbr := m.PBRMetallicRoughness
+	texCoord := 0
+	if pbr.BaseColorTexture != nil {
+		texCoord = pbr.BaseColorTexture.TexCoord
+	}
+	if mat, ok := gl.materials[*index]; ok {
+		return mat, texCoord, nil
+	}
+
+	mat, err := gl.newMaterial(m)
+	if err != nil {
+		return nil, 0, fmt.Errorf("materials[%v]: %v", *index, err)
+	}
+
+	gl.materials[*index] = mat
+	return mat, texCoord, nil
+}
+
+// newMaterial maps a metallic-roughness material onto the closest available material.
+func (gl *gltfLoader) newMaterial(m gltfMaterial) (material.Material, error) {
+	pbr := m.PBRMetallicRoughness
+	baseColor := &vec3.Vec3Impl{X: 1, Y: 1, Z: 1}
+	if pbr.BaseColorFactor != nil {
+		baseColor = &vec3.Vec3Impl{X: pbr.BaseColorFactor[0], Y: pbr.BaseColorFactor[1], Z: pbr.BaseColorFactor[2]}
+	}
+	metallic, roughness := 1.0, 1.0
+	if pbr.MetallicFactor != nil {
+		metallic = *pbr.MetallicFactor
+	}
+	if pbr.RoughnessFactor != nil {
+		roughness = *pbr.RoughnessFactor
+	}
+
+	if m.EmissiveFactor != nil {
+		emissive := &vec3.Vec3Impl{X: m.EmissiveFactor[0], Y: m.EmissiveFactor[1], Z: m.EmissiveFactor[2]}
+		if m.Extensions.EmissiveStrength != nil && m.Extensions.EmissiveStrength.EmissiveStrength != nil {
+			emissive = vec3.ScalarMul(emissive, *m.Extensions.EmissiveStrength.EmissiveStrength)
+		}
+		if !isBlack(emissive) {
+			return material.NewDiffuseLight(texture.NewConstant(emissive)), nil
+		}
+	}
+
+	if m.Extensions.Transmission != nil && m.Extensions.Transmission.TransmissionFactor > 0 {
+		ior := defaultRefractiveIndex
+		if m.Extensions.IOR != nil && m.Extensions.IOR.IOR != nil {
+			ior = *m.Extensions.IOR.IOR
+		}
+		return material.NewDielectric(ior), nil
+	}
+
+	if metallic >= metallicThreshold {
+		return material.NewMetal(baseColor, math.Min(math.Max(roughness, 0), 1)), nil
+	}
+
+	if pbr.BaseColorTexture == nil {
+		return material.NewLambertian(texture.NewConstant(baseColor)), nil
+	}
+
+	img, err := gl.image(pbr.BaseColorTexture.Index)
+	if err != nil {
+		return nil, err
+	}
+	if baseColor.X == 1 && baseColor.Y == 1 && baseColor.Z == 1 {
+		return material.NewLambertian(img), nil
+	}
+
+	return material.NewLambertian(texture.NewScaled(img, baseColor)), nil
+}
+
+// instantiateCamera adds a perspective camera looking down the -Z axis of the node.
+func (gl *gltfLoader) instantiateCamera(index int, world mat4) error {
+	if index < 0 || index >= len(gl.doc.Cameras) {
+		return fmt.Errorf("camera %v does not exist", index)
+	}
+
+	cam := gl.doc.Cameras[index]
+	if cam.Type != "perspective" || cam.Perspective == nil {
+		return nil
+	}
+
+	gl.model.cameras = append(gl.model.cameras, cameraView{
+		lookFrom: world.point(&vec3.Vec3Impl{}),
+		lookAt:   world.point(&vec3.Vec3Impl{Z: -1}),
+		vup:      world.direction(&vec3.Vec3Impl{Y: 1}),
+		vfov:     cam.Perspective.YFov * 180 / math.Pi,
+	})
+
+	return nil
+}
+
+// instantiateLight replaces point and spot lights with a small sphere that has the same
+// intensity. Spot light cones and directional lights cannot be represented and are ignored.
+func (gl *gltfLoader) instantiateLight(index int, world mat4) error {
+	lights := gl.doc.Extensions.LightsPunctual.Lights
+	if index < 0 || index >= len(lights) {
+		return fmt.Errorf("light %v does not exist", index)
+	}
+
+	light := lights[index]
+	if light.Type != "point" && light.Type != "spot" {
+		return nil
+	}
+
+	color := &vec3.Vec3Impl{X: 1, Y: 1, Z: 1}
+	if light.Color != nil {
+		color = &vec3.Vec3Impl{X: light.Color[0], Y: light.Color[1], Z: light.Color[2]}
+	}
+	intensity := 1.0
+	if light.Intensity != nil {
+		intensity = *light.Intensity
+	}
+
+	// A sphere of radius r and radiance L has an intensity of L * pi * r^2 in every direction.
+	radiance := vec3.ScalarMul(color, intensity/(math.Pi*pointLightRadius*pointLightRadius))
+	center := world.point(&vec3.Vec3Impl{})
+	sphere := hitable.NewSphere(center, center, 0, 1, pointLightRadius, material.NewDiffuseLight(texture.NewConstant(radiance)))
+	gl.hitables = append(gl.hitables, sphere)
+	gl.model.lights = append(gl.model.lights, sphere)
+
+	return nil
+}
@@ -0,0 +1,257 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// The scene contains a textured quad scaled by 2 and an emissive quad, both children of
+// a node 5 units down the -Z axis, a camera looking at them and a point light.
+const gltfScene = `{
+	"asset": {"version": "2.0"},
+	"extensionsUsed": ["KHR_lights_punctual", "KHR_materials_emissive_strength"],
+	"scene": 0,
+	"scenes": [{"nodes": [0, 3, 4]}],
+	"nodes": [
+		{"translation": [0, 0, -5], "children": [1, 2]},
+		{"mesh": 0, "scale": [2, 2, 2]},
+		{"mesh": 1, "translation": [10, 0, 0]},
+		{"camera": 0, "translation": [0, 0, 10]},
+		{"translation": [0, 5, 0], "extensions": {"KHR_lights_punctual": {"light": 0}}}
+	],
+	"cameras": [{"type": "perspective", "perspective": {"yfov": 0.8, "znear": 0.1}}],
+	"extensions": {"KHR_lights_punctual": {"lights": [{"type": "point", "intensity": 10}]}},
+	"meshes": [
+		{"primitives": [{"attributes": {"POSITION": 0, "NORMAL": 1, "TEXCOORD_0": 2}, "indices": 3, "material": 0}]},
+		{"primitives": [{"attributes": {"POSITION": 0}, "indices": 3, "material": 1}]}
+	],
+	"materials": [
+		{"pbrMetallicRoughness": {"baseColorTexture": {"index": 0}, "metallicFactor": 0}},
+		{"emissiveFactor": [1, 1, 1], "extensions": {"KHR_materials_emissive_strength": {"emissiveStrength": 2}}}
+	],
+	"textures": [{"source": 0}],
+	"images": [{"uri": "IMAGE"}],
+	"accessors": [
+		{"bufferView": 0, "componentType": 5126, "count": 4, "type": "VEC3"},
+		{"bufferView": 0, "byteOffset": 48, "componentType": 5126, "count": 4, "type": "VEC3"},
+		{"bufferView": 1, "componentType": 5126, "count": 4, "type": "VEC2"},
+		{"bufferView": 2, "componentType": 5123, "count": 6, "type": "SCALAR"}
+	],
+	"bufferViews": [
+		{"buffer": 0, "byteOffset": 0, "byteLength": 96},
+		{"buffer": 0, "byteOffset": 96, "byteLength": 32},
+		{"buffer": 0, "byteOffset": 128, "byteLength": 12}
+	],
+	"buffers": [{BUFFER"byteLength": 140}]
+}`
+
+// makeGLTFBuffer returns the vertex data of a unit quad facing +Z.
+func makeGLTFBuffer() []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, []float32{-1, -1, 0, 1, -1, 0, 1, 1, 0, -1, 1, 0})
+	binary.Write(&buf, binary.LittleEndian, []float32{0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 0, 1})
+	binary.Write(&buf, binary.LittleEndian, []float32{0, 1, 1, 1, 1, 0, 0, 0})
+	binary.Write(&buf, binary.LittleEndian, []uint16{0, 1, 2, 0, 2, 3})
+	return buf.Bytes()
+}
+
+func makePNG(t *testing.T) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			img.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode() returned error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// makeGLB packs a JSON document and a binary buffer into a GLB file.
+func makeGLB(doc string, bin []byte) []byte {
+	pad := func(data []byte, b byte) []byte {
+		for len(data)%4 != 0 {
+			data = append(data, b)
+		}
+		return data
+	}
+	jsonChunk := pad([]byte(doc), ' ')
+	binChunk := pad(append([]byte{}, bin...), 0)
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, []uint32{glbMagic, 2, uint32(12 + 8 + len(jsonChunk) + 8 + len(binChunk))})
+	binary.Write(&buf, binary.LittleEndian, []uint32{uint32(len(jsonChunk)), glbChunkJSON})
+	buf.Write(jsonChunk)
+	binary.Write(&buf, binary.LittleEndian, []uint32{uint32(len(binChunk)), glbChunkBIN})
+	buf.Write(binChunk)
+	return buf.Bytes()
+}
+
+func TestReadGLTF(t *testing.T) {
+	pngData := makePNG(t)
+	bin := makeGLTFBuffer()
+
+	gltf := strings.NewReplacer(`BUFFER`, `"uri": "quad%20data.bin", `, `IMAGE`, "textures/red.png").Replace(gltfScene)
+	glb := strings.NewReplacer(`BUFFER`, ``, `IMAGE`, "data:image/png;base64,"+base64.StdEncoding.EncodeToString(pngData)).Replace(gltfScene)
+	fsys := fstest.MapFS{
+		"assets/scene.gltf":       {Data: []byte(gltf)},
+		"assets/quad data.bin":    {Data: bin},
+		"assets/textures/red.png": {Data: pngData},
+		"assets/scene.glb":        {Data: makeGLB(glb, bin)},
+	}
+
+	s, err := sampler.New(sampler.TypeRandom, 0, 1)
+	if err != nil {
+		t.Fatalf("sampler.New() returned error: %v", err)
+	}
+
+	for _, name := range []string{"assets/scene.gltf", "assets/scene.glb"} {
+		t.Run(name, func(t *testing.T) {
+			rand.Seed(0)
+			model, err := ReadGLTF(fsys, name)
+			if err != nil {
+				t.Fatalf("ReadGLTF() returned error: %v", err)
+			}
+
+			if n := model.NumTriangles(); n != 4 {
+				t.Errorf("NumTriangles() = %v, want 4", n)
+			}
+			if n := len(model.Lights()); n != 2 {
+				t.Errorf("len(Lights()) = %v, want 2", n)
+			}
+
+			// The textured quad spans [-2, 2] at z = -5.
+			r := ray.New(&vec3.Vec3Impl{X: 1, Y: 1, Z: 10}, &vec3.Vec3Impl{Z: -1}, 0)
+			hr, mat, ok := model.Hitable().Hit(r, 0.001, 100, s)
+			if !ok {
+				t.Fatal("Hit() = false, want true")
+			}
+			if _, ok := mat.(*material.Lambertian); !ok {
+				t.Errorf("material = %T, want *material.Lambertian", mat)
+			}
+			if diff := cmp.Diff(&vec3.Vec3Impl{X: 1, Y: 1, Z: -5}, hr.P(), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("hit point mismatch (-want +got):\n%s", diff)
+			}
+			if u, v := hr.U(), hr.V(); math.Abs(u-0.75) > 1e-9 || math.Abs(v-0.75) > 1e-9 {
+				t.Errorf("texture coordinates = (%v, %v), want (0.75, 0.75)", u, v)
+			}
+			_, sr, _ := mat.Scatter(r, hr, s)
+			if diff := cmp.Diff(&vec3.Vec3Impl{X: 1}, sr.Attenuation()); diff != "" {
+				t.Errorf("albedo mismatch (-want +got):\n%s", diff)
+			}
+
+			_, mat, ok = model.Hitable().Hit(ray.New(&vec3.Vec3Impl{X: 10, Z: 10}, &vec3.Vec3Impl{Z: -1}, 0), 0.001, 100, s)
+			if !ok {
+				t.Fatal("Hit() of the emissive quad = false, want true")
+			}
+			if _, ok := mat.(*material.DiffuseLight); !ok {
+				t.Errorf("material = %T, want *material.DiffuseLight", mat)
+			}
+
+			cameras := model.Cameras(1)
+			if len(cameras) != 1 {
+				t.Fatalf("len(Cameras()) = %v, want 1", len(cameras))
+			}
+			cr := cameras[0].GetRay(0.5, 0.5, s)
+			if diff := cmp.Diff(&vec3.Vec3Impl{Z: 10}, cr.Origin(), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("camera origin mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(&vec3.Vec3Impl{Z: -1}, vec3.UnitVector(cr.Direction()), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("camera direction mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTransform(t *testing.T) {
+	// 90 degrees around the Y axis, then scaled by 2 and translated.
+	q := [4]float64{0, math.Sin(math.Pi / 4), 0, math.Cos(math.Pi / 4)}
+	m := trs([3]float64{1, 2, 3}, q, [3]float64{2, 2, 2})
+	if diff := cmp.Diff(&vec3.Vec3Impl{X: 1, Y: 2, Z: 1}, m.point(&vec3.Vec3Impl{X: 1}), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("point() mismatch (-want +got):\n%s", diff)
+	}
+
+	parent := trs([3]float64{0, 0, -5}, [4]float64{0, 0, 0, 1}, [3]float64{1, 1, 1})
+	if diff := cmp.Diff(&vec3.Vec3Impl{X: 1, Y: 2, Z: -4}, parent.mul(m).point(&vec3.Vec3Impl{X: 1}), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("mul() mismatch (-want +got):\n%s", diff)
+	}
+
+	// Non-uniform scaling keeps normals perpendicular to the surface and mirroring flips them.
+	shear := trs([3]float64{}, [4]float64{0, 0, 0, 1}, [3]float64{4, 1, 1})
+	if diff := cmp.Diff(vec3.UnitVector(&vec3.Vec3Impl{X: 1, Y: 4}), shear.normal(vec3.UnitVector(&vec3.Vec3Impl{X: 1, Y: 1})), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("normal() mismatch (-want +got):\n%s", diff)
+	}
+	mirror := trs([3]float64{}, [4]float64{0, 0, 0, 1}, [3]float64{-1, 1, 1})
+	if diff := cmp.Diff(&vec3.Vec3Impl{X: -1}, mirror.normal(&vec3.Vec3Impl{X: 1}), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("normal() of a mirror mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadGLTFErrors(t *testing.T) {
+	bin := makeGLTFBuffer()
+	valid := strings.NewReplacer(`BUFFER`, `"uri": "data:application/octet-stream;base64,`+base64.StdEncoding.EncodeToString(bin)+`", `, `IMAGE`, "missing.png").Replace(gltfScene)
+
+	testData := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{name: "Invalid JSON", data: []byte("{"), want: "unexpected end of JSON input"},
+		{name: "Unsupported version", data: []byte(`{"asset": {"version": "1.0"}}`), want: `unsupported glTF version "1.0"`},
+		{name: "Required extension", data: []byte(`{"asset": {"version": "2.0"}, "extensionsRequired": ["KHR_draco_mesh_compression"]}`), want: "KHR_draco_mesh_compression"},
+		{name: "No geometry", data: []byte(`{"asset": {"version": "2.0"}, "nodes": [{}]}`), want: "no geometry"},
+		{name: "Missing node", data: []byte(`{"asset": {"version": "2.0"}, "scenes": [{"nodes": [3]}]}`), want: "node 3 does not exist"},
+		{name: "Cycle", data: []byte(`{"asset": {"version": "2.0"}, "scenes": [{"nodes": [0]}], "nodes": [{"children": [1]}, {"children": [0]}]}`), want: "cycle"},
+		{name: "Missing texture", data: []byte(valid), want: "meshes[0].primitives[0]: materials[0]: images[0]"},
+		{name: "Accessor out of range", data: []byte(strings.Replace(strings.Replace(valid, `"material": 0`, `"material": 1`, 1), `"count": 6`, `"count": 7`, 1)), want: "accessors[3]: elements are outside of buffer view 2"},
+		{name: "Index out of range", data: []byte(strings.Replace(strings.Replace(valid, `"material": 0`, `"material": 1`, 1), `"byteOffset": 128`, `"byteOffset": 112`, 1)), want: "out of range"},
+		{name: "Truncated buffer", data: []byte(strings.Replace(valid, `"byteLength": 140`, `"byteLength": 160`, 1)), want: "buffers[0]: expected 160 bytes, got 140"},
+		{name: "GLB version", data: append([]byte("glTF\x01\x00\x00\x00"), make([]byte, 20)...), want: "unsupported GLB version 1"},
+		{name: "Truncated GLB", data: makeGLB(`{"asset": {"version": "2.0"}}`, bin)[:40], want: "truncated"},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ReadGLTF(fstest.MapFS{"a.gltf": {Data: test.data}}, "a.gltf")
+			if err == nil || !strings.HasPrefix(err.Error(), "a.gltf: ") || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("ReadGLTF() = %v, want an error containing %q", err, test.want)
+			}
+		})
+	}
+
+	// Mirrored nodes keep the triangles facing the same way.
+	rand.Seed(0)
+	mirrored := strings.Replace(valid, `"scale": [2, 2, 2]`, `"scale": [-2, 2, 2]`, 1)
+	mirrored = strings.Replace(mirrored, `"material": 0`, `"material": 1`, 1)
+	model, err := ReadGLTF(fstest.MapFS{"a.gltf": {Data: []byte(mirrored)}}, "a.gltf")
+	if err != nil {
+		t.Fatalf("ReadGLTF() returned error: %v", err)
+	}
+	hr, mat, ok := model.Hitable().Hit(ray.New(&vec3.Vec3Impl{X: 1, Y: 1, Z: 10}, &vec3.Vec3Impl{Z: -1}, 0), 0.001, 100, nil)
+	if !ok || reflect.TypeOf(mat) != reflect.TypeOf(&material.DiffuseLight{}) {
+		t.Fatalf("Hit() = %v, %T, want a hit on the emissive quad", ok, mat)
+	}
+	if diff := cmp.Diff(&vec3.Vec3Impl{Z: 1}, hr.Normal(), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("normal mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,99 @@
+package loader
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Lines longer than this are rejected.
+const maxLineLength = 16 * 1024 * 1024
+
+// lineReader splits a text file into statements. Comments are removed and lines ending
+// with a backslash are joined with the next one.
+type lineReader struct {
+	scanner *bufio.Scanner
+	file    string
+	// line is the line number where the current statement starts.
+	line   int
+	next   int
+	fields []string
+}
+
+func newLineReader(r io.Reader, file string) *lineReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineLength)
+	return &lineReader{
+		scanner: scanner,
+		file:    file,
+	}
+}
+
+// scan advances to the next non-empty statement.
+func (lr *lineReader) scan() bool {
+	statement := ""
+	for lr.scanner.Scan() {
+		lr.next++
+		if statement == "" {
+			lr.line = lr.next
+		}
+
+		text := lr.scanner.Text()
+		if strings.HasSuffix(text, "\\") {
+			statement += strings.TrimSuffix(text, "\\") + " "
+			continue
+		}
+
+		statement += text
+		if i := strings.IndexByte(statement, '#'); i >= 0 {
+			statement = statement[:i]
+		}
+		lr.fields = strings.Fields(statement)
+		if len(lr.fields) > 0 {
+			return true
+		}
+		statement = ""
+	}
+
+	// The last line can end with a backslash.
+	lr.fields = strings.Fields(statement)
+	return len(lr.fields) > 0
+}
+
+// err returns the error, if any, that stopped the scan.
+func (lr *lineReader) err() error {
+	if err := lr.scanner.Err(); err != nil {
+		return &ParseError{File: lr.file, Line: lr.next + 1, Err: err}
+	}
+
+	return nil
+}
+
+// errorf returns a ParseError for the current statement.
+func (lr *lineReader) errorf(format string, args ...interface{}) error {
+	return &ParseError{File: lr.file, Line: lr.line, Err: fmt.Errorf(format, args...)}
+}
+
+// floats parses the arguments of the current statement. Between min and max values are accepted.
+func (lr *lineReader) floats(min int, max int) ([]float64, error) {
+	args := lr.fields[1:]
+	if len(args) < min || len(args) > max {
+		if min == max {
+			return nil, lr.errorf("%v expects %v values, got %v", lr.fields[0], min, len(args))
+		}
+		return nil, lr.errorf("%v expects between %v and %v values, got %v", lr.fields[0], min, max, len(args))
+	}
+
+	values := make([]float64, len(args))
+	for i, arg := range args {
+		f, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return nil, lr.errorf("invalid number %q", arg)
+		}
+		values[i] = f
+	}
+
+	return values, nil
+}
@@ -0,0 +1,68 @@
+// Package loader implements readers for the mesh file formats produced by modelling tools.
+package loader
+
+import (
+	"fmt"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/camera"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitable"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// Model represents the geometry read from a file.
+type Model struct {
+	hitable      hitable.Hitable
+	lights       []hitable.Hitable
+	numTriangles int
+	cameras      []cameraView
+}
+
+// cameraView describes a camera read from a file. The aspect ratio is only known
+// once the size of the image is chosen.
+type cameraView struct {
+	lookFrom *vec3.Vec3Impl
+	lookAt   *vec3.Vec3Impl
+	vup      *vec3.Vec3Impl
+	vfov     float64
+}
+
+// Hitable returns the geometry of the model.
+func (m *Model) Hitable() hitable.Hitable {
+	return m.hitable
+}
+
+// Lights returns the meshes with emissive materials and the hitables that stand in for
+// light sources so that they can be used as importance sampling targets.
+func (m *Model) Lights() []hitable.Hitable {
+	return m.lights
+}
+
+// NumTriangles returns the number of triangles in the model.
+func (m *Model) NumTriangles() int {
+	return m.numTriangles
+}
+
+// Cameras returns the cameras defined in the file using the given aspect ratio.
+func (m *Model) Cameras(aspect float64) []*camera.Camera {
+	cameras := []*camera.Camera{}
+	for _, cv := range m.cameras {
+		cameras = append(cameras, camera.New(cv.lookFrom, cv.lookAt, cv.vup, cv.vfov, aspect, 0, 1, 0, 1))
+	}
+
+	return cameras
+}
+
+// ParseError describes a malformed line in a file.
+type ParseError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (pe *ParseError) Error() string {
+	return fmt.Sprintf("%v:%v: %v", pe.File, pe.Line, pe.Err)
+}
+
+func (pe *ParseError) Unwrap() error {
+	return pe.Err
+}
@@ -0,0 +1,220 @@
+package loader
+
+import (
+	"image"
+	// Register the image formats supported by texture maps.
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"math"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/texture"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// Refractive index used for transparent materials that do not set Ni.
+const defaultRefractiveIndex = 1.5
+
+// mtlMaterial holds the MTL properties that are mapped onto the available materials.
+type mtlMaterial struct {
+	name  string
+	kd    *vec3.Vec3Impl
+	ks    *vec3.Vec3Impl
+	ke    *vec3.Vec3Impl
+	ns    float64
+	ni    float64
+	d     float64
+	illum int
+	mapKd *texture.ImageTxt
+}
+
+// defaultDiffuse returns the diffuse colour used when none is given.
+func defaultDiffuse() *vec3.Vec3Impl {
+	return &vec3.Vec3Impl{X: 0.8, Y: 0.8, Z: 0.8}
+}
+
+func newMTLMaterial(name string) *mtlMaterial {
+	return &mtlMaterial{
+		name:  name,
+		kd:    defaultDiffuse(),
+		ks:    &vec3.Vec3Impl{},
+		ke:    &vec3.Vec3Impl{},
+		d:     1,
+		illum: -1,
+	}
+}
+
+// material maps the MTL properties onto a material:
+//   - Emissive materials (Ke) become diffuse lights.
+//   - Transparent materials (d or Tr, or illum 4, 6, 7 and 9) become dielectrics with Ni as their refractive index.
+//   - Mirrors (illum 3, or black Kd with a Ks colour) become metals whose fuzziness decreases as Ns increases.
+//   - Everything else becomes Lambertian using map_Kd if present and Kd otherwise.
+func (m *mtlMaterial) material() material.Material {
+	switch {
+	case !isBlack(m.ke):
+		return material.NewDiffuseLight(texture.NewConstant(m.ke))
+
+	case m.d < 1 || m.illum == 4 || m.illum == 6 || m.illum == 7 || m.illum == 9:
+		ni := m.ni
+		if ni <= 0 {
+			ni = defaultRefractiveIndex
+		}
+		return material.NewDielectric(ni)
+
+	case m.illum == 3 || (isBlack(m.kd) && m.mapKd == nil && !isBlack(m.ks)):
+		// Map the Phong exponent to a roughness value.
+		fuzz := math.Min(1, math.Sqrt(2/(math.Max(m.ns, 0)+2)))
+		return material.NewMetal(m.ks, fuzz)
+
+	case m.mapKd != nil:
+		return material.NewLambertian(m.mapKd)
+
+	default:
+		return material.NewLambertian(texture.NewConstant(m.kd))
+	}
+}
+
+func isBlack(v *vec3.Vec3Impl) bool {
+	return v.X == 0 && v.Y == 0 && v.Z == 0
+}
+
+// readMTL reads the materials defined in the named MTL file.
+func (p *objParser) readMTL(name string) error {
+	f, err := p.fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var current *mtlMaterial
+	finish := func() {
+		if current != nil {
+			p.materials[current.name] = current.material()
+		}
+	}
+
+	lr := newLineReader(f, name)
+	for lr.scan() {
+		if lr.fields[0] != "newmtl" && current == nil {
+			return lr.errorf("%v found before newmtl", lr.fields[0])
+		}
+
+		switch lr.fields[0] {
+		case "newmtl":
+			if len(lr.fields) != 2 {
+				return lr.errorf("newmtl expects 1 material name, got %v", len(lr.fields)-1)
+			}
+			finish()
+			current = newMTLMaterial(lr.fields[1])
+
+		case "Kd", "Ks", "Ke":
+			colour, err := mtlColour(lr)
+			if err != nil {
+				return err
+			}
+			switch lr.fields[0] {
+			case "Kd":
+				current.kd = colour
+			case "Ks":
+				current.ks = colour
+			case "Ke":
+				current.ke = colour
+			}
+
+		case "Ns", "Ni", "d", "Tr":
+			values, err := lr.floats(1, 1)
+			if err != nil {
+				return err
+			}
+			switch lr.fields[0] {
+			case "Ns":
+				current.ns = values[0]
+			case "Ni":
+				current.ni = values[0]
+			case "d":
+				current.d = values[0]
+			case "Tr":
+				current.d = 1 - values[0]
+			}
+
+		case "illum":
+			values, err := lr.floats(1, 1)
+			if err != nil {
+				return err
+			}
+			current.illum = int(values[0])
+
+		case "map_Kd":
+			if len(lr.fields) < 2 {
+				return lr.errorf("map_Kd expects a file name")
+			}
+			// Options such as -s or -o precede the file name and are ignored.
+			txt, err := p.loadTexture(p.resolve(lr.fields[len(lr.fields)-1]))
+			if err != nil {
+				return lr.errorf("cannot load texture; %w", err)
+			}
+			current.mapKd = txt
+
+		default:
+			// Ambient colours, other texture maps and PBR extensions are not supported and ignored.
+		}
+	}
+	if err := lr.err(); err != nil {
+		return err
+	}
+
+	finish()
+	return nil
+}
+
+// mtlColour parses the RGB colour of the current statement. A single value is used for all channels.
+func mtlColour(lr *lineReader) (*vec3.Vec3Impl, error) {
+	if len(lr.fields) > 1 && (lr.fields[1] == "spectral" || lr.fields[1] == "xyz") {
+		return nil, lr.errorf("%v colours are not supported", lr.fields[1])
+	}
+
+	values, err := lr.floats(1, 3)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 2 {
+		return nil, lr.errorf("%v expects 1 or 3 values, got 2", lr.fields[0])
+	}
+	if len(values) == 1 {
+		return &vec3.Vec3Impl{X: values[0], Y: values[0], Z: values[0]}, nil
+	}
+
+	return &vec3.Vec3Impl{X: values[0], Y: values[1], Z: values[2]}, nil
+}
+
+// loadTexture decodes the named image. Textures are cached so that materials sharing an image share the texture.
+func (p *objParser) loadTexture(name string) (*texture.ImageTxt, error) {
+	if txt, ok := p.textures[name]; ok {
+		return txt, nil
+	}
+
+	f, err := p.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	txt, err := decodeImage(f)
+	if err != nil {
+		return nil, err
+	}
+
+	p.textures[name] = txt
+	return txt, nil
+}
+
+// decodeImage decodes a PNG or JPEG image into a texture.
+func decodeImage(r io.Reader) (*texture.ImageTxt, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return texture.NewFromImage(img), nil
+}
@@ -0,0 +1,374 @@
+package loader
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitable"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/texture"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// The group faces belong to until a g or o statement is found.
+const defaultGroup = "default"
+
+// objCorner holds the zero based attribute indices of a face corner. Missing attributes are -1.
+type objCorner struct {
+	v  int
+	vt int
+	vn int
+}
+
+type objFace struct {
+	corners []objCorner
+	// smoothing is the smoothing group of the face. 0 means that the face is flat.
+	smoothing int
+}
+
+// objMesh collects the faces of a group that share a material.
+type objMesh struct {
+	group        string
+	materialName string
+	material     material.Material
+	faces        []objFace
+}
+
+type objMeshKey struct {
+	group        string
+	materialName string
+}
+
+type objParser struct {
+	fsys      fs.FS
+	dir       string
+	positions []*vec3.Vec3Impl
+	texCoords []*vec3.Vec3Impl
+	normals   []*vec3.Vec3Impl
+	materials map[string]material.Material
+	textures  map[string]*texture.ImageTxt
+	meshes    []*objMesh
+	meshIndex map[objMeshKey]*objMesh
+	// Current state.
+	group        string
+	materialName string
+	material     material.Material
+	smoothing    int
+}
+
+// LoadOBJ reads a Wavefront OBJ file. Material libraries and textures are resolved
+// relative to the directory that contains the file.
+func LoadOBJ(fileName string) (*Model, error) {
+	return ReadOBJ(os.DirFS(filepath.Dir(fileName)), filepath.Base(fileName))
+}
+
+// ReadOBJ reads the named Wavefront OBJ file from fsys. Polygons are triangulated and the
+// faces of every group are turned into one triangle mesh per material. Vertex normals are
+// computed for smoothing groups that do not provide them.
+func ReadOBJ(fsys fs.FS, name string) (*Model, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	p := &objParser{
+		fsys:      fsys,
+		dir:       path.Dir(name),
+		materials: make(map[string]material.Material),
+		textures:  make(map[string]*texture.ImageTxt),
+		meshIndex: make(map[objMeshKey]*objMesh),
+		group:     defaultGroup,
+		material:  material.NewLambertian(texture.NewConstant(defaultDiffuse())),
+	}
+
+	lr := newLineReader(f, name)
+	for lr.scan() {
+		if err := p.parseStatement(lr); err != nil {
+			return nil, err
+		}
+	}
+	if err := lr.err(); err != nil {
+		return nil, err
+	}
+
+	return p.build(name)
+}
+
+func (p *objParser) parseStatement(lr *lineReader) error {
+	switch lr.fields[0] {
+	case "v":
+		// The optional weight is ignored.
+		values, err := lr.floats(3, 4)
+		if err != nil {
+			return err
+		}
+		p.positions = append(p.positions, &vec3.Vec3Impl{X: values[0], Y: values[1], Z: values[2]})
+
+	case "vt":
+		values, err := lr.floats(1, 3)
+		if err != nil {
+			return err
+		}
+		uv := &vec3.Vec3Impl{X: values[0]}
+		if len(values) > 1 {
+			uv.Y = values[1]
+		}
+		p.texCoords = append(p.texCoords, uv)
+
+	case "vn":
+		values, err := lr.floats(3, 3)
+		if err != nil {
+			return err
+		}
+		p.normals = append(p.normals, &vec3.Vec3Impl{X: values[0], Y: values[1], Z: values[2]})
+
+	case "f":
+		return p.parseFace(lr)
+
+	case "g", "o":
+		p.group = defaultGroup
+		if len(lr.fields) > 1 {
+			p.group = strings.Join(lr.fields[1:], " ")
+		}
+
+	case "s":
+		if len(lr.fields) != 2 {
+			return lr.errorf("s expects 1 value, got %v", len(lr.fields)-1)
+		}
+		switch lr.fields[1] {
+		case "off":
+			p.smoothing = 0
+		case "on":
+			p.smoothing = 1
+		default:
+			s, err := strconv.Atoi(lr.fields[1])
+			if err != nil || s < 0 {
+				return lr.errorf("invalid smoothing group %q", lr.fields[1])
+			}
+			p.smoothing = s
+		}
+
+	case "mtllib":
+		if len(lr.fields) < 2 {
+			return lr.errorf("mtllib expects at least 1 file name")
+		}
+		for _, lib := range lr.fields[1:] {
+			if err := p.readMTL(p.resolve(lib)); err != nil {
+				return lr.errorf("cannot read material library; %w", err)
+			}
+		}
+
+	case "usemtl":
+		if len(lr.fields) != 2 {
+			return lr.errorf("usemtl expects 1 material name, got %v", len(lr.fields)-1)
+		}
+		mat, ok := p.materials[lr.fields[1]]
+		if !ok {
+			return lr.errorf("unknown material %q", lr.fields[1])
+		}
+		p.materialName = lr.fields[1]
+		p.material = mat
+
+	default:
+		// Free-form geometry, lines, points and display attributes are not supported and ignored.
+	}
+
+	return nil
+}
+
+func (p *objParser) parseFace(lr *lineReader) error {
+	if len(lr.fields) < 4 {
+		return lr.errorf("faces need at least 3 vertices, got %v", len(lr.fields)-1)
+	}
+
+	face := objFace{smoothing: p.smoothing}
+	for _, field := range lr.fields[1:] {
+		parts := strings.Split(field, "/")
+		if len(parts) > 3 {
+			return lr.errorf("invalid face vertex %q", field)
+		}
+
+		c := objCorner{vt: -1, vn: -1}
+		var err error
+		if c.v, err = objIndex(parts[0], len(p.positions)); err != nil {
+			return lr.errorf("invalid vertex index in %q; %v", field, err)
+		}
+		if len(parts) > 1 && parts[1] != "" {
+			if c.vt, err = objIndex(parts[1], len(p.texCoords)); err != nil {
+				return lr.errorf("invalid texture coordinate index in %q; %v", field, err)
+			}
+		}
+		if len(parts) > 2 && parts[2] != "" {
+			if c.vn, err = objIndex(parts[2], len(p.normals)); err != nil {
+				return lr.errorf("invalid normal index in %q; %v", field, err)
+			}
+		}
+		face.corners = append(face.corners, c)
+	}
+
+	key := objMeshKey{group: p.group, materialName: p.materialName}
+	mesh, ok := p.meshIndex[key]
+	if !ok {
+		mesh = &objMesh{group: p.group, materialName: p.materialName, material: p.material}
+		p.meshIndex[key] = mesh
+		p.meshes = append(p.meshes, mesh)
+	}
+	mesh.faces = append(mesh.faces, face)
+
+	return nil
+}
+
+// objIndex converts a one based OBJ index into a zero based one. Negative indices are
+// relative to the end of the n elements read so far.
+func objIndex(s string, n int) (int, error) {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not an integer", s)
+	}
+
+	switch {
+	case i > 0 && i <= n:
+		return i - 1, nil
+	case i < 0 && -i <= n:
+		return n + i, nil
+	default:
+		return 0, fmt.Errorf("index %v is out of range, %v elements defined", i, n)
+	}
+}
+
+// resolve returns the path of a file referenced from the file being read.
+func (p *objParser) resolve(name string) string {
+	return path.Join(p.dir, filepath.ToSlash(name))
+}
+
+type smoothingKey struct {
+	v         int
+	smoothing int
+}
+
+// smoothNormals returns the area weighted average of the normals of the faces that
+// share a vertex within a smoothing group.
+func (p *objParser) smoothNormals() map[smoothingKey]*vec3.Vec3Impl {
+	sums := make(map[smoothingKey]*vec3.Vec3Impl)
+	for _, mesh := range p.meshes {
+		for _, face := range mesh.faces {
+			if face.smoothing == 0 {
+				continue
+			}
+			normal := &vec3.Vec3Impl{}
+			v0 := p.positions[face.corners[0].v]
+			for i := 1; i < len(face.corners)-1; i++ {
+				e1 := vec3.Sub(p.positions[face.corners[i].v], v0)
+				e2 := vec3.Sub(p.positions[face.corners[i+1].v], v0)
+				normal = vec3.Add(normal, vec3.Cross(e1, e2))
+			}
+			for _, c := range face.corners {
+				key := smoothingKey{v: c.v, smoothing: face.smoothing}
+				if sum, ok := sums[key]; ok {
+					sums[key] = vec3.Add(sum, normal)
+				} else {
+					sums[key] = normal
+				}
+			}
+		}
+	}
+
+	normals := make(map[smoothingKey]*vec3.Vec3Impl)
+	for key, sum := range sums {
+		if sum.Length() > 0 {
+			normals[key] = vec3.UnitVector(sum)
+		}
+	}
+
+	return normals
+}
+
+type objVertexKey struct {
+	corner    objCorner
+	smoothing int
+}
+
+func (p *objParser) build(name string) (*Model, error) {
+	smooth := p.smoothNormals()
+	model := &Model{}
+	meshes := []hitable.Hitable{}
+	for _, mesh := range p.meshes {
+		vertices := []*vec3.Vec3Impl{}
+		normals := []*vec3.Vec3Impl{}
+		uvs := []*vec3.Vec3Impl{}
+		indices := []int{}
+		hasNormals, hasUVs := false, false
+		lookup := make(map[objVertexKey]int)
+
+		add := func(c objCorner, smoothing int) int {
+			key := objVertexKey{corner: c}
+			if c.vn < 0 {
+				key.smoothing = smoothing
+			}
+			if index, ok := lookup[key]; ok {
+				return index
+			}
+
+			// Missing attributes are left nil so that the triangles fall back to
+			// their geometric normal and default texture coordinates.
+			var normal, uv *vec3.Vec3Impl
+			if c.vn >= 0 {
+				normal = p.normals[c.vn]
+			} else if smoothing != 0 {
+				normal = smooth[smoothingKey{v: c.v, smoothing: smoothing}]
+			}
+			if c.vt >= 0 {
+				uv = p.texCoords[c.vt]
+			}
+			hasNormals = hasNormals || normal != nil
+			hasUVs = hasUVs || uv != nil
+
+			vertices = append(vertices, p.positions[c.v])
+			normals = append(normals, normal)
+			uvs = append(uvs, uv)
+			lookup[key] = len(vertices) - 1
+			return len(vertices) - 1
+		}
+
+		for _, face := range mesh.faces {
+			// Polygons are assumed to be convex and are split into a triangle fan.
+			for i := 1; i < len(face.corners)-1; i++ {
+				indices = append(indices,
+					add(face.corners[0], face.smoothing),
+					add(face.corners[i], face.smoothing),
+					add(face.corners[i+1], face.smoothing))
+			}
+		}
+
+		if !hasNormals {
+			normals = nil
+		}
+		if !hasUVs {
+			uvs = nil
+		}
+
+		tm, err := hitable.NewTriangleMesh(vertices, normals, uvs, indices, mesh.material)
+		if err != nil {
+			return nil, fmt.Errorf("%v: group %q: %v", name, mesh.group, err)
+		}
+
+		meshes = append(meshes, tm)
+		model.numTriangles += tm.NumTriangles()
+		if _, ok := mesh.material.(*material.DiffuseLight); ok {
+			model.lights = append(model.lights, tm)
+		}
+	}
+
+	if len(meshes) == 0 {
+		return nil, fmt.Errorf("%v: no faces found", name)
+	}
+
+	model.hitable = hitable.NewBVH(meshes, 0, 1)
+	return model, nil
+}
@@ -0,0 +1,281 @@
+package loader
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+const cubeOBJ = `# Unit cube made of quads.
+mtllib cube.mtl
+o cube
+v -1 -1 -1
+v 1 -1 -1
+v 1 1 -1
+v -1 1 -1
+v -1 -1 1
+v 1 -1 1
+v 1 1 1
+v -1 1 1
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+usemtl red
+f 5/1 6/2 7/3 8/4
+f 2 1 4 3
+f 1 5 8 4
+f 6 2 3 7
+usemtl light
+f 8 7 3 \
+  4
+f -8 -7 -3 -4
+`
+
+const cubeMTL = `newmtl red
+Kd 0.8 0.1 0.1
+
+newmtl light
+Ke 4 4 4
+`
+
+func TestReadOBJ(t *testing.T) {
+	rand.Seed(0)
+	fsys := fstest.MapFS{
+		"models/cube.obj": {Data: []byte(cubeOBJ)},
+		"models/cube.mtl": {Data: []byte(cubeMTL)},
+	}
+
+	model, err := ReadOBJ(fsys, "models/cube.obj")
+	if err != nil {
+		t.Fatalf("ReadOBJ() returned error: %v", err)
+	}
+
+	if n := model.NumTriangles(); n != 12 {
+		t.Errorf("NumTriangles() = %v, want 12", n)
+	}
+	if n := len(model.Lights()); n != 1 {
+		t.Errorf("len(Lights()) = %v, want 1", n)
+	}
+
+	testData := []struct {
+		name         string
+		r            ray.Ray
+		wantMaterial material.Material
+		wantNormal   *vec3.Vec3Impl
+		wantU        float64
+		wantV        float64
+	}{
+		{
+			name:         "Front face",
+			r:            ray.New(&vec3.Vec3Impl{X: 0.5, Y: -0.5, Z: 5}, &vec3.Vec3Impl{Z: -1}, 0),
+			wantMaterial: &material.Lambertian{},
+			wantNormal:   &vec3.Vec3Impl{Z: 1},
+			wantU:        0.75,
+			wantV:        0.25,
+		},
+		{
+			name:         "Top face",
+			r:            ray.New(&vec3.Vec3Impl{Y: 5}, &vec3.Vec3Impl{Y: -1}, 0),
+			wantMaterial: &material.DiffuseLight{},
+			wantNormal:   &vec3.Vec3Impl{Y: 1},
+		},
+		{
+			name:         "Bottom face",
+			r:            ray.New(&vec3.Vec3Impl{Y: -5}, &vec3.Vec3Impl{Y: 1}, 0),
+			wantMaterial: &material.DiffuseLight{},
+			wantNormal:   &vec3.Vec3Impl{Y: -1},
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			hr, mat, ok := model.Hitable().Hit(test.r, 0.001, 100, nil)
+			if !ok {
+				t.Fatal("Hit() = false, want true")
+			}
+			if reflect.TypeOf(mat) != reflect.TypeOf(test.wantMaterial) {
+				t.Errorf("material = %T, want %T", mat, test.wantMaterial)
+			}
+			if diff := cmp.Diff(test.wantNormal, hr.Normal(), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("normal mismatch (-want +got):\n%s", diff)
+			}
+			if test.wantU != 0 || test.wantV != 0 {
+				if u, v := hr.U(), hr.V(); u != test.wantU || v != test.wantV {
+					t.Errorf("texture coordinates = (%v, %v), want (%v, %v)", u, v, test.wantU, test.wantV)
+				}
+			}
+		})
+	}
+}
+
+func TestReadOBJNormals(t *testing.T) {
+	// Two triangles folded along the Y axis like a roof.
+	const roof = `v -1 0 0
+v 0 0 1
+v 0 1 1
+v 1 0 0
+vn 0 0 1
+%s
+f 1 2 3
+f 2 4 3
+`
+	testData := []struct {
+		name       string
+		statements string
+		wantNormal *vec3.Vec3Impl
+	}{
+		{
+			name:       "Flat",
+			statements: "s off",
+			wantNormal: vec3.UnitVector(&vec3.Vec3Impl{X: -1, Z: 1}),
+		},
+		{
+			name:       "Smoothing group",
+			statements: "s 1",
+			wantNormal: &vec3.Vec3Impl{Z: 1},
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			rand.Seed(0)
+			data := strings.Replace(roof, "%s", test.statements, 1)
+			model, err := ReadOBJ(fstest.MapFS{"roof.obj": {Data: []byte(data)}}, "roof.obj")
+			if err != nil {
+				t.Fatalf("ReadOBJ() returned error: %v", err)
+			}
+
+			// Hit the shared edge from the front.
+			hr, _, ok := model.Hitable().Hit(ray.New(&vec3.Vec3Impl{X: -0.001, Y: 0.5, Z: 5}, &vec3.Vec3Impl{Z: -1}, 0), 0.001, 100, nil)
+			if !ok {
+				t.Fatal("Hit() = false, want true")
+			}
+			if diff := cmp.Diff(test.wantNormal, hr.Normal(), cmpopts.EquateApprox(0, 1e-2)); diff != "" {
+				t.Errorf("normal mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// Explicit normals take precedence over smoothing groups.
+	model, err := ReadOBJ(fstest.MapFS{"n.obj": {Data: []byte("v 0 0 0\nv 1 0 0\nv 0 1 0\nvn 1 0 1\ns 1\nf 1//1 2//1 3//1\n")}}, "n.obj")
+	if err != nil {
+		t.Fatalf("ReadOBJ() returned error: %v", err)
+	}
+	hr, _, ok := model.Hitable().Hit(ray.New(&vec3.Vec3Impl{X: 0.2, Y: 0.2, Z: 1}, &vec3.Vec3Impl{Z: -1}, 0), 0.001, 100, nil)
+	if !ok {
+		t.Fatal("Hit() = false, want true")
+	}
+	if diff := cmp.Diff(vec3.UnitVector(&vec3.Vec3Impl{X: 1, Z: 1}), hr.Normal(), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("normal mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadMTL(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatalf("png.Encode() returned error: %v", err)
+	}
+
+	testData := []struct {
+		name string
+		mtl  string
+		want material.Material
+	}{
+		{name: "Diffuse", mtl: "Kd 0.5 0.5 0.5", want: &material.Lambertian{}},
+		{name: "Texture", mtl: "Kd 0 0 0\nmap_Kd -s 1 1 1 textures/checker.png", want: &material.Lambertian{}},
+		{name: "Mirror", mtl: "Ks 0.9 0.9 0.9\nNs 500\nillum 3", want: &material.Metal{}},
+		{name: "Specular only", mtl: "Kd 0 0 0\nKs 0.9 0.9 0.9", want: &material.Metal{}},
+		{name: "Glass", mtl: "Ni 1.5\nd 0.1", want: &material.Dielectric{}},
+		{name: "Glass illumination model", mtl: "illum 7", want: &material.Dielectric{}},
+		{name: "Transmission", mtl: "Tr 0.9", want: &material.Dielectric{}},
+		{name: "Emissive", mtl: "Kd 1 1 1\nKe 1", want: &material.DiffuseLight{}},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			rand.Seed(0)
+			fsys := fstest.MapFS{
+				"a.obj":                {Data: []byte("mtllib a.mtl\nv 0 0 0\nv 1 0 0\nv 0 1 0\nusemtl m\nf 1 2 3\n")},
+				"a.mtl":                {Data: []byte("newmtl m\n" + test.mtl + "\n")},
+				"textures/checker.png": {Data: buf.Bytes()},
+			}
+			model, err := ReadOBJ(fsys, "a.obj")
+			if err != nil {
+				t.Fatalf("ReadOBJ() returned error: %v", err)
+			}
+
+			_, mat, ok := model.Hitable().Hit(ray.New(&vec3.Vec3Impl{X: 0.2, Y: 0.2, Z: 1}, &vec3.Vec3Impl{Z: -1}, 0), 0.001, 100, nil)
+			if !ok {
+				t.Fatal("Hit() = false, want true")
+			}
+			if reflect.TypeOf(mat) != reflect.TypeOf(test.want) {
+				t.Errorf("material = %T, want %T", mat, test.want)
+			}
+		})
+	}
+}
+
+func TestReadOBJErrors(t *testing.T) {
+	testData := []struct {
+		name     string
+		obj      string
+		mtl      string
+		wantFile string
+		wantLine int
+	}{
+		{name: "Invalid number", obj: "v 0 0 0\nv 1 x 0\n", wantFile: "a.obj", wantLine: 2},
+		{name: "Missing coordinate", obj: "\n\nv 0 0\n", wantFile: "a.obj", wantLine: 3},
+		{name: "Index out of range", obj: "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 4\n", wantFile: "a.obj", wantLine: 4},
+		{name: "Negative index out of range", obj: "v 0 0 0\nv 1 0 0\nv 0 1 0\nf -1 -2 -4\n", wantFile: "a.obj", wantLine: 4},
+		{name: "Missing texture coordinate", obj: "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1/1 2/1 3/1\n", wantFile: "a.obj", wantLine: 4},
+		{name: "Too few vertices", obj: "v 0 0 0\nv 1 0 0\nf 1 2\n", wantFile: "a.obj", wantLine: 3},
+		{name: "Invalid vertex", obj: "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1/1/1/1 2 3\n", wantFile: "a.obj", wantLine: 4},
+		{name: "Invalid smoothing group", obj: "s maybe\n", wantFile: "a.obj", wantLine: 1},
+		{name: "Line continuation", obj: "v 0 \\\n 0 \\\n 0 0 0\n", wantFile: "a.obj", wantLine: 1},
+		{name: "Unknown material", obj: "# comment\nusemtl missing\n", wantFile: "a.obj", wantLine: 2},
+		{name: "Missing library", obj: "mtllib missing.mtl\n", wantFile: "a.obj", wantLine: 1},
+		{name: "Invalid colour", obj: "mtllib a.mtl\n", mtl: "newmtl m\nKd 1 1\n", wantFile: "a.mtl", wantLine: 2},
+		{name: "Spectral colour", obj: "mtllib a.mtl\n", mtl: "newmtl m\n\nKd spectral file.rfl\n", wantFile: "a.mtl", wantLine: 3},
+		{name: "Statement before newmtl", obj: "mtllib a.mtl\n", mtl: "Kd 1 1 1\n", wantFile: "a.mtl", wantLine: 1},
+		{name: "Missing texture", obj: "mtllib a.mtl\n", mtl: "newmtl m\nmap_Kd missing.png\n", wantFile: "a.mtl", wantLine: 2},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			fsys := fstest.MapFS{
+				"a.obj": {Data: []byte(test.obj)},
+				"a.mtl": {Data: []byte(test.mtl)},
+			}
+			_, err := ReadOBJ(fsys, "a.obj")
+			if err == nil {
+				t.Fatal("ReadOBJ() returned no error")
+			}
+
+			// Errors in material libraries are wrapped by the error of the mtllib statement.
+			var pe *ParseError
+			for errors.As(err, &pe) && pe.File != test.wantFile {
+				err = pe.Err
+			}
+			if pe == nil || pe.File != test.wantFile || pe.Line != test.wantLine {
+				t.Errorf("ReadOBJ() = %v, want an error in %v line %v", err, test.wantFile, test.wantLine)
+			}
+		})
+	}
+
+	if _, err := ReadOBJ(fstest.MapFS{"a.obj": {Data: []byte("v 0 0 0\n")}}, "a.obj"); err == nil {
+		t.Error("ReadOBJ() of a file without faces returned no error")
+	}
+}
@@ -0,0 +1,500 @@
+package loader
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitable"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/texture"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// PLY formats.
+const (
+	plyASCII = iota
+	plyBinaryLittleEndian
+	plyBinaryBigEndian
+)
+
+// PLY scalar types.
+const (
+	plyInt8 = iota
+	plyUint8
+	plyInt16
+	plyUint16
+	plyInt32
+	plyUint32
+	plyFloat32
+	plyFloat64
+)
+
+var plyTypes = map[string]int{
+	"char":    plyInt8,
+	"int8":    plyInt8,
+	"uchar":   plyUint8,
+	"uint8":   plyUint8,
+	"short":   plyInt16,
+	"int16":   plyInt16,
+	"ushort":  plyUint16,
+	"uint16":  plyUint16,
+	"int":     plyInt32,
+	"int32":   plyInt32,
+	"uint":    plyUint32,
+	"uint32":  plyUint32,
+	"float":   plyFloat32,
+	"float32": plyFloat32,
+	"double":  plyFloat64,
+	"float64": plyFloat64,
+}
+
+var plyTypeSizes = [...]int{1, 1, 2, 2, 4, 4, 4, 8}
+
+type plyProperty struct {
+	name string
+	// typ is the type of the property or of the list items.
+	typ    int
+	isList bool
+	// countType is the type of the list length.
+	countType int
+}
+
+type plyElement struct {
+	name       string
+	count      int
+	properties []plyProperty
+}
+
+// plyReader decodes the values of a PLY file straight from a buffered reader.
+type plyReader struct {
+	r      *bufio.Reader
+	name   string
+	format int
+	order  binary.ByteOrder
+	// line is the current line of ASCII files.
+	line  int
+	buf   [8]byte
+	token []byte
+}
+
+// LoadPLY reads a Stanford PLY file. See ReadPLY for details.
+func LoadPLY(fileName string, mat material.Material) (*Model, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadPLY(f, fileName, mat)
+}
+
+// ReadPLY reads an ASCII or binary PLY file. Positions, normals, texture coordinates and
+// vertex colors are read from the vertex element and polygons from the vertex indices of
+// the face element. The faces use the supplied material, or a grey Lambertian one if it is nil.
+// Vertex colors are interpolated by the mesh and read by texture.VertexColor, which the
+// default material uses when the vertices have colors.
+func ReadPLY(r io.Reader, name string, mat material.Material) (*Model, error) {
+	pr := &plyReader{
+		r:    bufio.NewReaderSize(r, 1024*1024),
+		name: name,
+	}
+
+	elements, err := pr.readHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	numVertices := 0
+	for _, el := range elements {
+		if el.name == "vertex" {
+			numVertices = el.count
+		}
+	}
+
+	var vertices, normals, uvs, colors []*vec3.Vec3Impl
+	var indices []int
+	for _, el := range elements {
+		switch el.name {
+		case "vertex":
+			vertices, normals, uvs, colors, err = pr.readVertices(el)
+		case "face":
+			indices, err = pr.readFaces(el, numVertices)
+		default:
+			err = pr.readElement(el, func(_ int, _ []float64, _ [][]float64) error { return nil })
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(indices) == 0 {
+		return nil, fmt.Errorf("%v: no faces found", name)
+	}
+
+	if mat == nil {
+		if colors != nil {
+			mat = material.NewLambertian(texture.NewVertexColor(defaultDiffuse()))
+		} else {
+			mat = material.NewLambertian(texture.NewConstant(defaultDiffuse()))
+		}
+	}
+
+	tm, err := hitable.NewColoredTriangleMesh(vertices, normals, uvs, colors, indices, mat)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", name, err)
+	}
+
+	model := &Model{numTriangles: len(indices) / 3, hitable: tm}
+	if _, ok := mat.(*material.DiffuseLight); ok {
+		model.lights = []hitable.Hitable{tm}
+	}
+
+	return model, nil
+}
+
+// readHeader parses the header up to and including the end_header line.
+func (pr *plyReader) readHeader() ([]*plyElement, error) {
+	elements := []*plyElement{}
+	formatFound := false
+	for {
+		text, err := pr.r.ReadString('\n')
+		if err != nil && (err != io.EOF || text == "") {
+			if err == io.EOF {
+				err = errors.New("unexpected end of file in header")
+			}
+			return nil, &ParseError{File: pr.name, Line: pr.line + 1, Err: err}
+		}
+		pr.line++
+
+		fields := strings.Fields(text)
+		if pr.line == 1 {
+			if len(fields) != 1 || fields[0] != "ply" {
+				return nil, pr.errorf("not a PLY file")
+			}
+			continue
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "format":
+			if len(fields) != 3 {
+				return nil, pr.errorf("format expects a format and a version")
+			}
+			switch fields[1] {
+			case "ascii":
+				pr.format = plyASCII
+			case "binary_little_endian":
+				pr.format = plyBinaryLittleEndian
+				pr.order = binary.LittleEndian
+			case "binary_big_endian":
+				pr.format = plyBinaryBigEndian
+				pr.order = binary.BigEndian
+			default:
+				return nil, pr.errorf("unsupported format %q", fields[1])
+			}
+			if fields[2] != "1.0" {
+				return nil, pr.errorf("unsupported version %q", fields[2])
+			}
+			formatFound = true
+
+		case "comment", "obj_info":
+
+		case "element":
+			if len(fields) != 3 {
+				return nil, pr.errorf("element expects a name and a count")
+			}
+			count, err := strconv.Atoi(fields[2])
+			if err != nil || count < 0 {
+				return nil, pr.errorf("invalid element count %q", fields[2])
+			}
+			elements = append(elements, &plyElement{name: fields[1], count: count})
+
+		case "property":
+			if len(elements) == 0 {
+				return nil, pr.errorf("property found before element")
+			}
+			prop, err := parsePLYProperty(fields[1:])
+			if err != nil {
+				return nil, pr.errorf("%v", err)
+			}
+			el := elements[len(elements)-1]
+			el.properties = append(el.properties, prop)
+
+		case "end_header":
+			if !formatFound {
+				return nil, pr.errorf("format not found")
+			}
+			// Data starts on the next line.
+			pr.line++
+			return elements, nil
+
+		default:
+			return nil, pr.errorf("unknown header keyword %q", fields[0])
+		}
+	}
+}
+
+func parsePLYProperty(fields []string) (plyProperty, error) {
+	if len(fields) == 4 && fields[0] == "list" {
+		countType, ok := plyTypes[fields[1]]
+		if !ok {
+			return plyProperty{}, fmt.Errorf("unknown type %q", fields[1])
+		}
+		if countType == plyFloat32 || countType == plyFloat64 {
+			return plyProperty{}, fmt.Errorf("list lengths must be integers, got %q", fields[1])
+		}
+		typ, ok := plyTypes[fields[2]]
+		if !ok {
+			return plyProperty{}, fmt.Errorf("unknown type %q", fields[2])
+		}
+		return plyProperty{name: fields[3], typ: typ, isList: true, countType: countType}, nil
+	}
+
+	if len(fields) != 2 {
+		return plyProperty{}, errors.New("property expects a type and a name")
+	}
+	typ, ok := plyTypes[fields[0]]
+	if !ok {
+		return plyProperty{}, fmt.Errorf("unknown type %q", fields[0])
+	}
+
+	return plyProperty{name: fields[1], typ: typ}, nil
+}
+
+// errorf returns a ParseError for the current line.
+func (pr *plyReader) errorf(format string, args ...interface{}) error {
+	return &ParseError{File: pr.name, Line: pr.line, Err: fmt.Errorf(format, args...)}
+}
+
+// elementError returns an error for the given instance of an element. Errors in ASCII
+// files are reported with their line number.
+func (pr *plyReader) elementError(el *plyElement, i int, err error) error {
+	if pr.format == plyASCII {
+		return &ParseError{File: pr.name, Line: pr.line, Err: fmt.Errorf("%v %v: %w", el.name, i, err)}
+	}
+
+	return fmt.Errorf("%v: %v %v: %w", pr.name, el.name, i, err)
+}
+
+// readElement reads every instance of the element and passes the values of its scalar
+// properties and lists to fn. The slices are reused between calls.
+func (pr *plyReader) readElement(el *plyElement, fn func(i int, values []float64, lists [][]float64) error) error {
+	values := make([]float64, len(el.properties))
+	lists := make([][]float64, len(el.properties))
+	for i := 0; i < el.count; i++ {
+		for j, prop := range el.properties {
+			if !prop.isList {
+				v, err := pr.readValue(prop.typ)
+				if err != nil {
+					return pr.elementError(el, i, err)
+				}
+				values[j] = v
+				continue
+			}
+
+			n, err := pr.readValue(prop.countType)
+			if err != nil {
+				return pr.elementError(el, i, err)
+			}
+			if n < 0 {
+				return pr.elementError(el, i, fmt.Errorf("invalid list length %v", n))
+			}
+			lists[j] = lists[j][:0]
+			for k := 0; k < int(n); k++ {
+				v, err := pr.readValue(prop.typ)
+				if err != nil {
+					return pr.elementError(el, i, err)
+				}
+				lists[j] = append(lists[j], v)
+			}
+		}
+
+		if err := fn(i, values, lists); err != nil {
+			return pr.elementError(el, i, err)
+		}
+	}
+
+	return nil
+}
+
+func (pr *plyReader) readVertices(el *plyElement) ([]*vec3.Vec3Impl, []*vec3.Vec3Impl, []*vec3.Vec3Impl, []*vec3.Vec3Impl, error) {
+	index := func(names ...string) int {
+		for j, prop := range el.properties {
+			for _, name := range names {
+				if prop.name == name && !prop.isList {
+					return j
+				}
+			}
+		}
+		return -1
+	}
+
+	x, y, z := index("x"), index("y"), index("z")
+	if x < 0 || y < 0 || z < 0 {
+		return nil, nil, nil, nil, fmt.Errorf("%v: vertex element without x, y and z properties", pr.name)
+	}
+	nx, ny, nz := index("nx"), index("ny"), index("nz")
+	u, v := index("u", "s", "texture_u", "texture_s"), index("v", "t", "texture_v", "texture_t")
+	red, green, blue := index("red", "diffuse_red"), index("green", "diffuse_green"), index("blue", "diffuse_blue")
+
+	vertices := make([]*vec3.Vec3Impl, 0, el.count)
+	var normals, uvs, colors []*vec3.Vec3Impl
+	if nx >= 0 && ny >= 0 && nz >= 0 {
+		normals = make([]*vec3.Vec3Impl, 0, el.count)
+	}
+	if u >= 0 && v >= 0 {
+		uvs = make([]*vec3.Vec3Impl, 0, el.count)
+	}
+	var rScale, gScale, bScale float64
+	if red >= 0 && green >= 0 && blue >= 0 {
+		colors = make([]*vec3.Vec3Impl, 0, el.count)
+		rScale = plyColorScale(el.properties[red].typ)
+		gScale = plyColorScale(el.properties[green].typ)
+		bScale = plyColorScale(el.properties[blue].typ)
+	}
+
+	err := pr.readElement(el, func(_ int, values []float64, _ [][]float64) error {
+		vertices = append(vertices, &vec3.Vec3Impl{X: values[x], Y: values[y], Z: values[z]})
+		if normals != nil {
+			normals = append(normals, &vec3.Vec3Impl{X: values[nx], Y: values[ny], Z: values[nz]})
+		}
+		if uvs != nil {
+			uvs = append(uvs, &vec3.Vec3Impl{X: values[u], Y: values[v]})
+		}
+		if colors != nil {
+			colors = append(colors, &vec3.Vec3Impl{X: values[red] * rScale, Y: values[green] * gScale, Z: values[blue] * bScale})
+		}
+		return nil
+	})
+
+	return vertices, normals, uvs, colors, err
+}
+
+// plyColorScale returns the factor that maps colors stored with the given type to [0, 1].
+func plyColorScale(typ int) float64 {
+	switch typ {
+	case plyFloat32, plyFloat64:
+		return 1
+	case plyUint16:
+		return 1.0 / 65535
+	default:
+		return 1.0 / 255
+	}
+}
+
+func (pr *plyReader) readFaces(el *plyElement, numVertices int) ([]int, error) {
+	list := -1
+	for j, prop := range el.properties {
+		if prop.isList && (prop.name == "vertex_indices" || prop.name == "vertex_index") {
+			list = j
+		}
+	}
+	if list < 0 {
+		return nil, fmt.Errorf("%v: face element without a vertex_indices list", pr.name)
+	}
+
+	indices := make([]int, 0, el.count*3)
+	err := pr.readElement(el, func(_ int, _ []float64, lists [][]float64) error {
+		face := lists[list]
+		if len(face) < 3 {
+			return fmt.Errorf("faces need at least 3 vertices, got %v", len(face))
+		}
+		for _, index := range face {
+			if index < 0 || int(index) >= numVertices || index != math.Trunc(index) {
+				return fmt.Errorf("vertex index %v is out of range, %v vertices defined", index, numVertices)
+			}
+		}
+		// Polygons are assumed to be convex and are split into a triangle fan.
+		for k := 1; k < len(face)-1; k++ {
+			indices = append(indices, int(face[0]), int(face[k]), int(face[k+1]))
+		}
+		return nil
+	})
+
+	return indices, err
+}
+
+// readValue reads one value of the given type.
+func (pr *plyReader) readValue(typ int) (float64, error) {
+	if pr.format == plyASCII {
+		token, err := pr.nextToken()
+		if err != nil {
+			return 0, err
+		}
+		v, err := strconv.ParseFloat(string(token), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q", token)
+		}
+		return v, nil
+	}
+
+	b := pr.buf[:plyTypeSizes[typ]]
+	if _, err := io.ReadFull(pr.r, b); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+
+	switch typ {
+	case plyInt8:
+		return float64(int8(b[0])), nil
+	case plyUint8:
+		return float64(b[0]), nil
+	case plyInt16:
+		return float64(int16(pr.order.Uint16(b))), nil
+	case plyUint16:
+		return float64(pr.order.Uint16(b)), nil
+	case plyInt32:
+		return float64(int32(pr.order.Uint32(b))), nil
+	case plyUint32:
+		return float64(pr.order.Uint32(b)), nil
+	case plyFloat32:
+		return float64(math.Float32frombits(pr.order.Uint32(b))), nil
+	default:
+		return math.Float64frombits(pr.order.Uint64(b)), nil
+	}
+}
+
+// nextToken returns the next whitespace separated token of an ASCII file.
+// The returned slice is only valid until the next call.
+func (pr *plyReader) nextToken() ([]byte, error) {
+	pr.token = pr.token[:0]
+	for {
+		b, err := pr.r.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				if len(pr.token) > 0 {
+					return pr.token, nil
+				}
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+
+		if b == ' ' || b == '\t' || b == '\r' || b == '\n' {
+			if len(pr.token) > 0 {
+				if b == '\n' {
+					// Count the new line when the next token is read so errors refer to this line.
+					pr.r.UnreadByte()
+				}
+				return pr.token, nil
+			}
+			if b == '\n' {
+				pr.line++
+			}
+			continue
+		}
+
+		pr.token = append(pr.token, b)
+	}
+}
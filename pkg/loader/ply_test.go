@@ -0,0 +1,222 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitable"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/texture"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+type plyTestVertex struct {
+	x, y, z          float32
+	red, green, blue uint8
+}
+
+// A unit square with a different color in every corner.
+var plySquare = []plyTestVertex{
+	{x: 0, y: 0, red: 255},
+	{x: 1, y: 0, green: 255},
+	{x: 1, y: 1, blue: 255},
+	{x: 0, y: 1, red: 255, green: 255, blue: 255},
+}
+
+// makePLY encodes the square as a single quad in the given format. An extra element
+// that the reader has to skip is stored between the vertices and the faces.
+func makePLY(format string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "ply\nformat %v 1.0\ncomment test square\n", format)
+	fmt.Fprintf(&buf, "element vertex 4\nproperty float x\nproperty float y\nproperty float z\n")
+	fmt.Fprintf(&buf, "property uchar red\nproperty uchar green\nproperty uchar blue\n")
+	fmt.Fprintf(&buf, "element extra 1\nproperty list uchar short values\nproperty double weight\n")
+	fmt.Fprintf(&buf, "element face 1\nproperty list uchar int vertex_indices\nend_header\n")
+
+	var order binary.ByteOrder
+	switch format {
+	case "ascii":
+		for _, v := range plySquare {
+			fmt.Fprintf(&buf, "%v %v %v %v %v %v\n", v.x, v.y, v.z, v.red, v.green, v.blue)
+		}
+		fmt.Fprintf(&buf, "2 -1 7 0.5\n4 0 1 2 3\n")
+		return buf.Bytes()
+	case "binary_little_endian":
+		order = binary.LittleEndian
+	default:
+		order = binary.BigEndian
+	}
+
+	for _, v := range plySquare {
+		binary.Write(&buf, order, []float32{v.x, v.y, v.z})
+		binary.Write(&buf, order, []uint8{v.red, v.green, v.blue})
+	}
+	binary.Write(&buf, order, uint8(2))
+	binary.Write(&buf, order, []int16{-1, 7})
+	binary.Write(&buf, order, 0.5)
+	binary.Write(&buf, order, uint8(4))
+	binary.Write(&buf, order, []int32{0, 1, 2, 3})
+	return buf.Bytes()
+}
+
+func TestReadPLY(t *testing.T) {
+	s, err := sampler.New(sampler.TypeRandom, 0, 1)
+	if err != nil {
+		t.Fatalf("sampler.New() returned error: %v", err)
+	}
+
+	for _, format := range []string{"ascii", "binary_little_endian", "binary_big_endian"} {
+		t.Run(format, func(t *testing.T) {
+			rand.Seed(0)
+			model, err := ReadPLY(bytes.NewReader(makePLY(format)), "square.ply", nil)
+			if err != nil {
+				t.Fatalf("ReadPLY() returned error: %v", err)
+			}
+			if n := model.NumTriangles(); n != 2 {
+				t.Errorf("NumTriangles() = %v, want 2", n)
+			}
+			if _, ok := model.Hitable().(*hitable.TriangleMesh); !ok {
+				t.Errorf("Hitable() = %T, want *hitable.TriangleMesh", model.Hitable())
+			}
+
+			// The point has barycentric coordinates (0.25, 0.5, 0.25) in the first triangle.
+			r := ray.New(&vec3.Vec3Impl{X: 0.75, Y: 0.25, Z: 1}, &vec3.Vec3Impl{Z: -1}, 0)
+			hr, mat, ok := model.Hitable().Hit(r, 0.001, 100, s)
+			if !ok {
+				t.Fatal("Hit() = false, want true")
+			}
+			_, sr, ok := mat.Scatter(r, hr, s)
+			if !ok {
+				t.Fatal("Scatter() = false, want true")
+			}
+			want := &vec3.Vec3Impl{X: 0.25, Y: 0.5, Z: 0.25}
+			if diff := cmp.Diff(want, sr.Attenuation(), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("color mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadPLYEmissiveColors(t *testing.T) {
+	light := material.NewDiffuseLight(texture.NewVertexColor(&vec3.Vec3Impl{X: 1, Y: 1, Z: 1}))
+	model, err := ReadPLY(bytes.NewReader(makePLY("ascii")), "square.ply", light)
+	if err != nil {
+		t.Fatalf("ReadPLY() returned error: %v", err)
+	}
+
+	if _, ok := model.Hitable().(*hitable.TriangleMesh); !ok {
+		t.Errorf("Hitable() = %T, want *hitable.TriangleMesh", model.Hitable())
+	}
+	if n := len(model.Lights()); n != 1 {
+		t.Fatalf("len(Lights()) = %v, want 1", n)
+	}
+
+	o := &vec3.Vec3Impl{X: 0.75, Y: 0.25, Z: 1}
+	if pdf := model.Lights()[0].PDFValue(o, &vec3.Vec3Impl{Z: -1}, 0); pdf <= 0 {
+		t.Errorf("PDFValue() = %v, want a positive value", pdf)
+	}
+
+	// The point has barycentric coordinates (0.25, 0.5, 0.25) in the first triangle.
+	r := ray.New(o, &vec3.Vec3Impl{Z: -1}, 0)
+	hr, mat, ok := model.Hitable().Hit(r, 0.001, 100, nil)
+	if !ok {
+		t.Fatal("Hit() = false, want true")
+	}
+	if mat != light {
+		t.Errorf("material = %v, want %v", mat, light)
+	}
+	want := &vec3.Vec3Impl{X: 0.25, Y: 0.5, Z: 0.25}
+	if diff := cmp.Diff(want, mat.Emitted(r, hr, hr.U(), hr.V(), hr.P()), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("emitted color mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadPLYMaterial(t *testing.T) {
+	rand.Seed(0)
+	const data = `ply
+format ascii 1.0
+element vertex 3
+property double x
+property double y
+property double z
+property double nx
+property double ny
+property double nz
+property float s
+property float t
+element face 1
+property list uint8 uint32 vertex_index
+end_header
+0 0 0 0 0 1 0 0
+1 0 0 0 0 1 1 0
+0 1 0 0 0 1 0 1
+3 0 1 2
+`
+	light := material.NewDiffuseLight(texture.NewConstant(&vec3.Vec3Impl{X: 1, Y: 1, Z: 1}))
+	model, err := ReadPLY(strings.NewReader(data), "triangle.ply", light)
+	if err != nil {
+		t.Fatalf("ReadPLY() returned error: %v", err)
+	}
+
+	if _, ok := model.Hitable().(*hitable.TriangleMesh); !ok {
+		t.Errorf("Hitable() = %T, want *hitable.TriangleMesh", model.Hitable())
+	}
+	if n := len(model.Lights()); n != 1 {
+		t.Errorf("len(Lights()) = %v, want 1", n)
+	}
+
+	hr, mat, ok := model.Hitable().Hit(ray.New(&vec3.Vec3Impl{X: 0.5, Y: 0.25, Z: 1}, &vec3.Vec3Impl{Z: -1}, 0), 0.001, 100, nil)
+	if !ok {
+		t.Fatal("Hit() = false, want true")
+	}
+	if mat != light {
+		t.Errorf("material = %v, want %v", mat, light)
+	}
+	if u, v := hr.U(), hr.V(); u != 0.5 || v != 0.25 {
+		t.Errorf("texture coordinates = (%v, %v), want (0.5, 0.25)", u, v)
+	}
+}
+
+func TestReadPLYErrors(t *testing.T) {
+	const header = "ply\nformat ascii 1.0\nelement vertex 3\nproperty float x\nproperty float y\nproperty float z\nelement face 1\nproperty list uchar int vertex_indices\nend_header\n"
+	testData := []struct {
+		name     string
+		data     string
+		wantLine int
+	}{
+		{name: "Not a PLY file", data: "obj\n", wantLine: 1},
+		{name: "Unknown format", data: "ply\nformat binary_middle_endian 1.0\n", wantLine: 2},
+		{name: "Unknown type", data: "ply\nformat ascii 1.0\nelement vertex 1\nproperty real x\n", wantLine: 4},
+		{name: "Property before element", data: "ply\nformat ascii 1.0\nproperty float x\n", wantLine: 3},
+		{name: "Missing end of header", data: "ply\nformat ascii 1.0\nelement vertex 1\n", wantLine: 4},
+		{name: "Invalid number", data: header + "0 0 0\n1 zero 0\n0 1 0\n3 0 1 2\n", wantLine: 11},
+		{name: "Index out of range", data: header + "0 0 0\n1 0 0\n0 1 0\n3 0 1 3\n", wantLine: 13},
+		{name: "Too few vertices", data: header + "0 0 0\n1 0 0\n0 1 0\n\n2 0 1\n", wantLine: 14},
+		{name: "Truncated", data: header + "0 0 0\n1 0 0\n", wantLine: 12},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ReadPLY(strings.NewReader(test.data), "a.ply", nil)
+			var pe *ParseError
+			if !errors.As(err, &pe) || pe.Line != test.wantLine {
+				t.Errorf("ReadPLY() = %v, want an error in line %v", err, test.wantLine)
+			}
+		})
+	}
+
+	data := makePLY("binary_little_endian")
+	_, err := ReadPLY(bytes.NewReader(data[:len(data)-2]), "a.ply", nil)
+	if err == nil || !strings.Contains(err.Error(), "face 0") {
+		t.Errorf("ReadPLY() of a truncated binary file = %v, want an error in face 0", err)
+	}
+}
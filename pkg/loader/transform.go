@@ -0,0 +1,80 @@
+package loader
+
+import (
+	"math"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// mat4 is a column major 4x4 affine transformation matrix.
+type mat4 [16]float64
+
+func identity() mat4 {
+	return mat4{
+		1, 0, 0, 0,
+		0, 1, 0, 0,
+		0, 0, 1, 0,
+		0, 0, 0, 1,
+	}
+}
+
+// trs returns the matrix that scales, then rotates by the unit quaternion q (x, y, z, w) and then translates.
+func trs(t [3]float64, q [4]float64, s [3]float64) mat4 {
+	x, y, z, w := q[0], q[1], q[2], q[3]
+	return mat4{
+		(1 - 2*(y*y+z*z)) * s[0], 2 * (x*y + z*w) * s[0], 2 * (x*z - y*w) * s[0], 0,
+		2 * (x*y - z*w) * s[1], (1 - 2*(x*x+z*z)) * s[1], 2 * (y*z + x*w) * s[1], 0,
+		2 * (x*z + y*w) * s[2], 2 * (y*z - x*w) * s[2], (1 - 2*(x*x+y*y)) * s[2], 0,
+		t[0], t[1], t[2], 1,
+	}
+}
+
+// mul returns the matrix that applies b and then m.
+func (m mat4) mul(b mat4) mat4 {
+	var res mat4
+	for c := 0; c < 4; c++ {
+		for r := 0; r < 4; r++ {
+			sum := 0.0
+			for k := 0; k < 4; k++ {
+				sum += m[k*4+r] * b[c*4+k]
+			}
+			res[c*4+r] = sum
+		}
+	}
+
+	return res
+}
+
+// column returns the first three elements of the given column.
+func (m mat4) column(c int) *vec3.Vec3Impl {
+	return &vec3.Vec3Impl{X: m[c*4], Y: m[c*4+1], Z: m[c*4+2]}
+}
+
+// point transforms a position.
+func (m mat4) point(p *vec3.Vec3Impl) *vec3.Vec3Impl {
+	return vec3.Add(m.direction(p), m.column(3))
+}
+
+// direction transforms a direction, ignoring the translation.
+func (m mat4) direction(d *vec3.Vec3Impl) *vec3.Vec3Impl {
+	return vec3.Add(vec3.ScalarMul(m.column(0), d.X), vec3.ScalarMul(m.column(1), d.Y), vec3.ScalarMul(m.column(2), d.Z))
+}
+
+// determinant returns the determinant of the upper 3x3 matrix. Negative values mean
+// that the transformation mirrors the geometry.
+func (m mat4) determinant() float64 {
+	return vec3.Dot(m.column(0), vec3.Cross(m.column(1), m.column(2)))
+}
+
+// normal transforms a normal with the inverse transpose of the upper 3x3 matrix.
+func (m mat4) normal(n *vec3.Vec3Impl) *vec3.Vec3Impl {
+	c0, c1, c2 := m.column(0), m.column(1), m.column(2)
+	res := vec3.Add(vec3.ScalarMul(vec3.Cross(c1, c2), n.X), vec3.ScalarMul(vec3.Cross(c2, c0), n.Y), vec3.ScalarMul(vec3.Cross(c0, c1), n.Z))
+	l := res.Length()
+	if l == 0 {
+		return n
+	}
+
+	// The cofactors are the inverse transpose scaled by the determinant.
+	return vec3.ScalarDiv(res, math.Copysign(l, m.determinant()))
+}
@@ -1,10 +1,9 @@
 package material
 
 import (
-	"math/rand"
-
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitrecord"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scatterrecord"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
 )
@@ -26,7 +25,7 @@ func NewDielectric(reIdx float64) *Dielectric {
 }
 
 // Scatter computes how the ray bounces off the surface of a dielectric material.
-func (d *Dielectric) Scatter(r ray.Ray, hr *hitrecord.HitRecord) (*ray.RayImpl, *scatterrecord.ScatterRecord, bool) {
+func (d *Dielectric) Scatter(r ray.Ray, hr *hitrecord.HitRecord, sampler sampler.Sampler) (*ray.RayImpl, *scatterrecord.ScatterRecord, bool) {
 	var niOverNt float64
 	var cosine float64
 	var reflectProb float64
@@ -54,7 +53,7 @@ func (d *Dielectric) Scatter(r ray.Ray, hr *hitrecord.HitRecord) (*ray.RayImpl,
 		reflectProb = 1.0
 	}
 
-	if rand.Float64() < reflectProb {
+	if sampler.Get1D() < reflectProb {
 		scattered = ray.New(hr.P(), reflected, r.Time())
 	} else {
 		scattered = ray.New(hr.P(), refracted, r.Time())
@@ -3,6 +3,7 @@ package material
 import (
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitrecord"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scatterrecord"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/texture"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
@@ -24,14 +25,14 @@ func NewDiffuseLight(emit texture.Texture) *DiffuseLight {
 }
 
 // Scatter returns false for diffuse light materials.
-func (dl *DiffuseLight) Scatter(_ ray.Ray, _ *hitrecord.HitRecord) (*ray.RayImpl, *scatterrecord.ScatterRecord, bool) {
+func (dl *DiffuseLight) Scatter(_ ray.Ray, _ *hitrecord.HitRecord, _ sampler.Sampler) (*ray.RayImpl, *scatterrecord.ScatterRecord, bool) {
 	return nil, nil, false
 }
 
 // Emitted returns the texture value at that point.
 func (dl *DiffuseLight) Emitted(rIn ray.Ray, rec *hitrecord.HitRecord, u float64, v float64, p *vec3.Vec3Impl) *vec3.Vec3Impl {
 	if vec3.Dot(rec.Normal(), rIn.Direction()) < 0.0 {
-		return dl.emit.Value(u, v, p)
+		return texture.ValueAt(dl.emit, rec)
 	}
 
 	return &vec3.Vec3Impl{}
@@ -1,9 +1,12 @@
 package material
 
 import (
+	"math"
+
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitrecord"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/pdf"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scatterrecord"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/texture"
 )
@@ -24,16 +27,17 @@ func NewIsotropic(albedo texture.Texture) *Isotropic {
 	}
 }
 
-// Scatter computes how the ray bounces off the surface of a diffuse material.
-func (i *Isotropic) Scatter(r ray.Ray, hr *hitrecord.HitRecord) (*ray.RayImpl, *scatterrecord.ScatterRecord, bool) {
-	scattered := ray.New(hr.P(), randomInUnitSphere(), r.Time())
-	attenuation := i.albedo.Value(hr.U(), hr.V(), hr.P())
-	pdf := pdf.NewCosine(hr.Normal())
+// Scatter computes how the ray is scattered in a random direction inside a participating medium.
+func (i *Isotropic) Scatter(r ray.Ray, hr *hitrecord.HitRecord, sampler sampler.Sampler) (*ray.RayImpl, *scatterrecord.ScatterRecord, bool) {
+	pdf := pdf.NewSphere()
+	scattered := ray.New(hr.P(), pdf.Generate(sampler), r.Time())
+	attenuation := texture.ValueAt(i.albedo, hr)
 	scatterRecord := scatterrecord.New(nil, false, attenuation, pdf)
 	return scattered, scatterRecord, true
 }
 
 // ScatteringPDF implements the probability distribution function for isotropic materials.
+// Every direction is equally likely.
 func (i *Isotropic) ScatteringPDF(r ray.Ray, hr *hitrecord.HitRecord, scattered ray.Ray) float64 {
-	return 0
+	return 1 / (4 * math.Pi)
 }
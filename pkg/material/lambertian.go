@@ -7,6 +7,7 @@ import (
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/onb"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/pdf"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scatterrecord"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/texture"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
@@ -29,12 +30,12 @@ func NewLambertian(albedo texture.Texture) *Lambertian {
 }
 
 // Scatter computes how the ray bounces off the surface of a diffuse material.
-func (l *Lambertian) Scatter(r ray.Ray, hr *hitrecord.HitRecord) (*ray.RayImpl, *scatterrecord.ScatterRecord, bool) {
+func (l *Lambertian) Scatter(r ray.Ray, hr *hitrecord.HitRecord, sampler sampler.Sampler) (*ray.RayImpl, *scatterrecord.ScatterRecord, bool) {
 	uvw := onb.New()
 	uvw.BuildFromW(hr.Normal())
-	direction := uvw.Local(vec3.RandomCosineDirection())
+	direction := uvw.Local(vec3.RandomCosineDirection(sampler))
 	scattered := ray.New(hr.P(), vec3.UnitVector(direction), r.Time())
-	albedo := l.albedo.Value(hr.U(), hr.V(), hr.P())
+	albedo := texture.ValueAt(l.albedo, hr)
 	pdf := pdf.NewCosine(hr.Normal())
 	scatterRecord := scatterrecord.New(nil, false, albedo, pdf)
 	return scattered, scatterRecord, true
@@ -2,19 +2,20 @@ package material
 
 import (
 	"math"
-	"math/rand"
 
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
 )
 
-func randomInUnitSphere() *vec3.Vec3Impl {
-	for {
-		p := vec3.Sub(vec3.ScalarMul(&vec3.Vec3Impl{X: rand.Float64(), Y: rand.Float64(), Z: rand.Float64()}, 2.0),
-			&vec3.Vec3Impl{X: 1.0, Y: 1.0, Z: 1.0})
-		if p.SquaredLength() < 1.0 {
-			return p
-		}
-	}
+// randomInUnitSphere maps a fixed number of sample values to a uniformly distributed point
+// inside the unit sphere so that every call consumes the same sample dimensions.
+func randomInUnitSphere(sampler sampler.Sampler) *vec3.Vec3Impl {
+	r1, r2 := sampler.Get2D()
+	z := 1 - 2*r1
+	r := math.Sqrt(math.Max(0, 1-z*z))
+	phi := 2 * math.Pi * r2
+	radius := math.Cbrt(sampler.Get1D())
+	return vec3.ScalarMul(&vec3.Vec3Impl{X: r * math.Cos(phi), Y: r * math.Sin(phi), Z: z}, radius)
 }
 
 func reflect(v *vec3.Vec3Impl, n *vec3.Vec3Impl) *vec3.Vec3Impl {
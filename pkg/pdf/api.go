@@ -1,12 +1,15 @@
 // Package pdf implements methods to work with probability density functions.
 package pdf
 
-import "github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+import (
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
 
 // PDF represents a probability density function.
 type PDF interface {
 	// Value computes the probability density function at a given point.
 	Value(direction *vec3.Vec3Impl) float64
-	// Generate generates a probability density function.
-	Generate() *vec3.Vec3Impl
+	// Generate returns a random direction distributed according to this probability density function.
+	Generate(sampler sampler.Sampler) *vec3.Vec3Impl
 }
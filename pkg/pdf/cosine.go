@@ -4,6 +4,7 @@ import (
 	"math"
 
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/onb"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
 )
 
@@ -32,6 +33,6 @@ func (c *Cosine) Value(direction *vec3.Vec3Impl) float64 {
 	return 0
 }
 
-func (c *Cosine) Generate() *vec3.Vec3Impl {
-	return c.uvw.Local(vec3.RandomCosineDirection())
+func (c *Cosine) Generate(sampler sampler.Sampler) *vec3.Vec3Impl {
+	return c.uvw.Local(vec3.RandomCosineDirection(sampler))
 }
@@ -0,0 +1,104 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Discrete represents a discrete probability distribution over the indices of a set of weights.
+// Sampling uses Vose's alias method and takes constant time.
+type Discrete struct {
+	probabilities []float64
+	threshold     []float64
+	alias         []int
+}
+
+// NewDiscrete returns a discrete distribution where the probability of every index is
+// proportional to its weight. Weights must be non-negative and add up to a positive value.
+func NewDiscrete(weights []float64) (*Discrete, error) {
+	if len(weights) == 0 {
+		return nil, errors.New("no weights supplied")
+	}
+
+	sum := 0.0
+	for i, w := range weights {
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return nil, fmt.Errorf("invalid weight %v at index %v", w, i)
+		}
+		sum += w
+	}
+	if sum <= 0 {
+		return nil, errors.New("the weights add up to zero")
+	}
+
+	n := len(weights)
+	d := &Discrete{
+		probabilities: make([]float64, n),
+		threshold:     make([]float64, n),
+		alias:         make([]int, n),
+	}
+
+	small := []int{}
+	large := []int{}
+	for i, w := range weights {
+		d.probabilities[i] = w / sum
+		// Scale so that the average bucket holds exactly 1.
+		d.threshold[i] = d.probabilities[i] * float64(n)
+		d.alias[i] = i
+		if d.threshold[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+
+	for len(small) > 0 && len(large) > 0 {
+		s := small[len(small)-1]
+		small = small[:len(small)-1]
+		l := large[len(large)-1]
+		large = large[:len(large)-1]
+
+		// The bucket of s is topped up with l.
+		d.alias[s] = l
+		d.threshold[l] -= 1 - d.threshold[s]
+		if d.threshold[l] < 1 {
+			small = append(small, l)
+		} else {
+			large = append(large, l)
+		}
+	}
+
+	// Whatever is left only differs from 1 because of rounding errors.
+	for _, i := range append(small, large...) {
+		d.threshold[i] = 1
+	}
+
+	return d, nil
+}
+
+// Len returns the number of indices in the distribution.
+func (d *Discrete) Len() int {
+	return len(d.probabilities)
+}
+
+// Probability returns the probability of selecting the given index.
+func (d *Discrete) Probability(i int) float64 {
+	return d.probabilities[i]
+}
+
+// Sample maps a value in the [0, 1) range to an index of the distribution.
+func (d *Discrete) Sample(u float64) int {
+	n := float64(len(d.threshold))
+	i := int(u * n)
+	if i >= len(d.threshold) {
+		i = len(d.threshold) - 1
+	}
+
+	// The fractional part decides between the bucket and its alias.
+	if u*n-float64(i) < d.threshold[i] {
+		return i
+	}
+
+	return d.alias[i]
+}
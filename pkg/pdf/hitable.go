@@ -2,6 +2,7 @@ package pdf
 
 import (
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitabletarget"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
 )
 
@@ -11,21 +12,23 @@ var _ PDF = (*Hitable)(nil)
 // Hitable represents a hitable PDF.
 type Hitable struct {
 	o       *vec3.Vec3Impl
+	time    float64
 	hitable hitabletarget.HitableTarget
 }
 
-// NewHitable returns an instance of a hitable PDF.
-func NewHitable(p hitabletarget.HitableTarget, origin *vec3.Vec3Impl) *Hitable {
+// NewHitable returns an instance of a hitable PDF for directions leaving origin at the given time.
+func NewHitable(p hitabletarget.HitableTarget, origin *vec3.Vec3Impl, time float64) *Hitable {
 	return &Hitable{
 		o:       origin,
+		time:    time,
 		hitable: p,
 	}
 }
 
 func (h *Hitable) Value(direction *vec3.Vec3Impl) float64 {
-	return h.hitable.PDFValue(h.o, direction)
+	return h.hitable.PDFValue(h.o, direction, h.time)
 }
 
-func (h *Hitable) Generate() *vec3.Vec3Impl {
-	return h.hitable.Random(h.o)
+func (h *Hitable) Generate(sampler sampler.Sampler) *vec3.Vec3Impl {
+	return h.hitable.Random(h.o, h.time, sampler)
 }
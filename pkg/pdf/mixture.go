@@ -1,34 +1,67 @@
 package pdf
 
 import (
-	"math/rand"
+	"fmt"
 
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
 )
 
 // Ensure interface compliance.
 var _ PDF = (*Mixture)(nil)
 
-// Mixture represents a mixture of two PDFs.
+// Mixture represents a weighted mixture of PDFs.
 type Mixture struct {
-	p [2]PDF
+	p            []PDF
+	distribution *Discrete
 }
 
-// NewMixture returns an instance of the mixture PDF.
-func NewMixture(p0 PDF, p1 PDF) *Mixture {
-	return &Mixture{
-		p: [2]PDF{p0, p1},
+// NewMixture returns an instance of the mixture PDF where every PDF has the same weight.
+// It panics if no PDF is supplied.
+func NewMixture(p ...PDF) *Mixture {
+	weights := make([]float64, len(p))
+	for i := range weights {
+		weights[i] = 1
+	}
+
+	m, err := NewWeightedMixture(p, weights)
+	if err != nil {
+		// Equal weights can only fail when no PDF is supplied.
+		panic(err)
 	}
+
+	return m
 }
 
-func (m *Mixture) Value(direction *vec3.Vec3Impl) float64 {
-	return 0.5*m.p[0].Value(direction) + 0.5*m.p[1].Value(direction)
+// NewWeightedMixture returns an instance of the mixture PDF where every PDF is selected with a
+// probability proportional to its weight. The weights are normalized and must be non-negative.
+func NewWeightedMixture(p []PDF, weights []float64) (*Mixture, error) {
+	if len(p) != len(weights) {
+		return nil, fmt.Errorf("got %v PDFs and %v weights", len(p), len(weights))
+	}
+
+	d, err := NewDiscrete(weights)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Mixture{
+		p:            p,
+		distribution: d,
+	}, nil
 }
 
-func (m *Mixture) Generate() *vec3.Vec3Impl {
-	if rand.Float64() < 0.5 {
-		return m.p[0].Generate()
+func (m *Mixture) Value(direction *vec3.Vec3Impl) float64 {
+	sum := 0.0
+	for i, p := range m.p {
+		if w := m.distribution.Probability(i); w > 0 {
+			sum += w * p.Value(direction)
+		}
 	}
 
-	return m.p[1].Generate()
+	return sum
+}
+
+func (m *Mixture) Generate(sampler sampler.Sampler) *vec3.Vec3Impl {
+	return m.p[m.distribution.Sample(sampler.Get1D())].Generate(sampler)
 }
@@ -0,0 +1,126 @@
+package pdf_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitable"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/pdf"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestDiscrete(t *testing.T) {
+	weights := []float64{1, 0, 3, 2, 0.5, 1.5}
+	d, err := pdf.NewDiscrete(weights)
+	if err != nil {
+		t.Fatalf("NewDiscrete() = %v", err)
+	}
+
+	const n = 80000
+	counts := make([]int, d.Len())
+	for i := 0; i < n; i++ {
+		counts[d.Sample((float64(i)+0.5)/n)]++
+	}
+
+	for i, w := range weights {
+		want := w / 8
+		if got := d.Probability(i); math.Abs(got-want) > 1e-12 {
+			t.Errorf("Probability(%v) = %v, want %v", i, got, want)
+		}
+		// Evenly spaced values must reproduce the probabilities almost exactly.
+		if got := float64(counts[i]) / n; math.Abs(got-want) > 1e-3 {
+			t.Errorf("index %v sampled with frequency %v, want %v", i, got, want)
+		}
+	}
+	if counts[1] != 0 {
+		t.Errorf("index with zero weight sampled %v times", counts[1])
+	}
+}
+
+func TestNewDiscreteErrors(t *testing.T) {
+	for _, weights := range [][]float64{nil, {0, 0}, {1, -1}, {math.NaN()}, {math.Inf(1)}} {
+		if _, err := pdf.NewDiscrete(weights); err == nil {
+			t.Errorf("NewDiscrete(%v) succeeded, want an error", weights)
+		}
+	}
+}
+
+func TestNewWeightedMixtureErrors(t *testing.T) {
+	if _, err := pdf.NewWeightedMixture([]pdf.PDF{pdf.NewSphere()}, []float64{1, 1}); err == nil {
+		t.Error("NewWeightedMixture() with mismatched weights succeeded, want an error")
+	}
+}
+
+func TestMixtureEqualWeights(t *testing.T) {
+	m := pdf.NewMixture(pdf.NewSphere(), pdf.NewCosine(&vec3.Vec3Impl{Z: 1}))
+	dir := &vec3.Vec3Impl{Z: 1}
+	want := 0.5/(4*math.Pi) + 0.5/math.Pi
+	if diff := cmp.Diff(want, m.Value(dir), cmpopts.EquateApprox(0, 1e-12)); diff != "" {
+		t.Errorf("Value() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// randomPDF returns one of the PDFs available to integrators with a random configuration.
+// None of them contain the origin.
+func randomPDF(rng *rand.Rand) pdf.PDF {
+	randomDirection := func() *vec3.Vec3Impl {
+		return vec3.UnitVector(&vec3.Vec3Impl{X: rng.NormFloat64(), Y: rng.NormFloat64(), Z: rng.NormFloat64()})
+	}
+
+	switch rng.Intn(4) {
+	case 0:
+		return pdf.NewCosine(randomDirection())
+	case 1:
+		return pdf.NewSphere()
+	case 2:
+		center := vec3.ScalarMul(randomDirection(), 2+rng.Float64()*3)
+		radius := 0.3 + rng.Float64()
+		return pdf.NewHitable(hitable.NewSphere(center, center, 0, 1, radius, nil), &vec3.Vec3Impl{}, 0)
+	default:
+		x0 := rng.Float64()*4 - 2
+		z0 := rng.Float64()*4 - 2
+		k := 1 + rng.Float64()*2
+		if rng.Intn(2) == 0 {
+			k = -k
+		}
+		rect := hitable.NewXZRect(x0, x0+0.5+rng.Float64()*2, z0, z0+0.5+rng.Float64()*2, k, nil)
+		return pdf.NewHitable(rect, &vec3.Vec3Impl{}, 0)
+	}
+}
+
+func TestMixtureIntegratesToOne(t *testing.T) {
+	const numSamples = 1 << 17
+	rng := rand.New(rand.NewSource(1))
+	for config := 0; config < 20; config++ {
+		numPDFs := 1 + rng.Intn(5)
+		pdfs := []pdf.PDF{}
+		weights := []float64{}
+		for i := 0; i < numPDFs; i++ {
+			pdfs = append(pdfs, randomPDF(rng))
+			weights = append(weights, float64(rng.Intn(4))*rng.Float64())
+		}
+		weights[0] += 0.1
+
+		m, err := pdf.NewWeightedMixture(pdfs, weights)
+		if err != nil {
+			t.Fatalf("NewWeightedMixture() = %v", err)
+		}
+
+		// Integrate over the sphere by averaging the PDF at uniformly distributed directions.
+		uniform := pdf.NewSphere()
+		smp := sampler.NewSobol(int64(config))
+		sum := 0.0
+		for i := 0; i < numSamples; i++ {
+			smp.StartPixelSample(0, 0, i)
+			sum += m.Value(uniform.Generate(smp))
+		}
+		got := sum / numSamples * 4 * math.Pi
+		if math.Abs(got-1) > 0.02 {
+			t.Errorf("configuration %v with %v PDFs and weights %v: integral = %v, want 1", config, numPDFs, weights, got)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package pdf
+
+import (
+	"math"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// Ensure interface compliance.
+var _ PDF = (*Sphere)(nil)
+
+// Sphere represents a uniform distribution of directions over the unit sphere.
+type Sphere struct{}
+
+// NewSphere returns an instance of a uniform sphere PDF.
+func NewSphere() *Sphere {
+	return &Sphere{}
+}
+
+func (s *Sphere) Value(direction *vec3.Vec3Impl) float64 {
+	return 1 / (4 * math.Pi)
+}
+
+func (s *Sphere) Generate(sampler sampler.Sampler) *vec3.Vec3Impl {
+	r1, r2 := sampler.Get2D()
+	z := 1 - 2*r1
+	r := math.Sqrt(math.Max(0, 1-z*z))
+	phi := 2 * math.Pi * r2
+	return &vec3.Vec3Impl{X: r * math.Cos(phi), Y: r * math.Sin(phi), Z: z}
+}
@@ -0,0 +1,33 @@
+package postprocess
+
+import "github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+
+// Ensure interface compliance.
+var _ Operator = (*ACES)(nil)
+
+// ACES implements Krzysztof Narkowicz's fit of the ACES filmic tone mapping curve.
+type ACES struct{}
+
+// NewACES returns an instance of the ACES operator.
+func NewACES() *ACES {
+	return &ACES{}
+}
+
+// Apply tone maps each colour channel.
+func (a *ACES) Apply(_ int, _ int, col *vec3.Vec3Impl) *vec3.Vec3Impl {
+	return &vec3.Vec3Impl{X: acesCurve(col.X), Y: acesCurve(col.Y), Z: acesCurve(col.Z)}
+}
+
+func acesCurve(x float64) float64 {
+	// (x * (a*x + b)) / (x * (c*x + d) + e)
+	v := (x * (2.51*x + 0.03)) / (x*(2.43*x+0.59) + 0.14)
+	if v < 0 {
+		return 0
+	}
+
+	if v > 1 {
+		return 1
+	}
+
+	return v
+}
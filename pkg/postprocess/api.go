@@ -0,0 +1,10 @@
+// Package postprocess implements the operators used to turn linear radiance into displayable images.
+package postprocess
+
+import "github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+
+// Operator defines the methods implemented by the post-processing stages.
+type Operator interface {
+	// Apply transforms the colour value of the pixel at the given position.
+	Apply(x int, y int, col *vec3.Vec3Impl) *vec3.Vec3Impl
+}
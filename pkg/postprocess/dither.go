@@ -0,0 +1,29 @@
+package postprocess
+
+import "github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+
+// Ensure interface compliance.
+var _ Operator = (*Dither)(nil)
+
+// 4x4 Bayer matrix.
+var bayer = [4][4]float64{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// Dither implements ordered dithering to hide banding when quantizing to 8 bits.
+// It should be the last operator in the pipeline.
+type Dither struct{}
+
+// NewDither returns an instance of the dither operator.
+func NewDither() *Dither {
+	return &Dither{}
+}
+
+// Apply adds the threshold offset for the given pixel position.
+func (d *Dither) Apply(x int, y int, col *vec3.Vec3Impl) *vec3.Vec3Impl {
+	offset := ((bayer[y&3][x&3]+0.5)/16.0 - 0.5) / 255.0
+	return &vec3.Vec3Impl{X: col.X + offset, Y: col.Y + offset, Z: col.Z + offset}
+}
@@ -0,0 +1,27 @@
+package postprocess
+
+import (
+	"math"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// Ensure interface compliance.
+var _ Operator = (*Exposure)(nil)
+
+// Exposure represents an exposure adjustment expressed in stops.
+type Exposure struct {
+	scale float64
+}
+
+// NewExposure returns an instance of the exposure operator.
+func NewExposure(stops float64) *Exposure {
+	return &Exposure{
+		scale: math.Pow(2, stops),
+	}
+}
+
+// Apply scales the colour by the exposure factor.
+func (e *Exposure) Apply(_ int, _ int, col *vec3.Vec3Impl) *vec3.Vec3Impl {
+	return vec3.ScalarMul(col, e.scale)
+}
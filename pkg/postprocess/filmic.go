@@ -0,0 +1,58 @@
+package postprocess
+
+import "github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+
+// Ensure interface compliance.
+var _ Operator = (*Filmic)(nil)
+
+const (
+	// Parameters of the curve as published by John Hable.
+	filmicShoulderStrength = 0.15
+	filmicLinearStrength   = 0.50
+	filmicLinearAngle      = 0.10
+	filmicToeStrength      = 0.20
+	filmicToeNumerator     = 0.02
+	filmicToeDenominator   = 0.30
+	filmicWhitePoint       = 11.2
+	filmicExposureBias     = 2.0
+)
+
+// Filmic implements John Hable's filmic tone mapping curve.
+type Filmic struct {
+	whiteScale float64
+}
+
+// NewFilmic returns an instance of the filmic operator.
+func NewFilmic() *Filmic {
+	return &Filmic{
+		whiteScale: 1.0 / hableCurve(filmicWhitePoint),
+	}
+}
+
+// Apply tone maps each colour channel. Values above the white point are mapped to 1.
+func (f *Filmic) Apply(_ int, _ int, col *vec3.Vec3Impl) *vec3.Vec3Impl {
+	return &vec3.Vec3Impl{X: f.curve(col.X), Y: f.curve(col.Y), Z: f.curve(col.Z)}
+}
+
+func (f *Filmic) curve(c float64) float64 {
+	v := hableCurve(c*filmicExposureBias) * f.whiteScale
+	if v < 0 {
+		return 0
+	}
+
+	if v > 1 {
+		return 1
+	}
+
+	return v
+}
+
+func hableCurve(x float64) float64 {
+	a := filmicShoulderStrength
+	b := filmicLinearStrength
+	c := filmicLinearAngle
+	d := filmicToeStrength
+	e := filmicToeNumerator
+	f := filmicToeDenominator
+	return ((x*(a*x+c*b) + d*e) / (x*(a*x+b) + d*f)) - e/f
+}
@@ -0,0 +1,31 @@
+package postprocess
+
+import (
+	"math"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// Ensure interface compliance.
+var _ Operator = (*Gamma)(nil)
+
+// Gamma implements a simple power law transfer function.
+type Gamma struct {
+	invGamma float64
+}
+
+// NewGamma returns an instance of the gamma operator.
+func NewGamma(gamma float64) *Gamma {
+	return &Gamma{
+		invGamma: 1.0 / gamma,
+	}
+}
+
+// Apply encodes each colour channel.
+func (g *Gamma) Apply(_ int, _ int, col *vec3.Vec3Impl) *vec3.Vec3Impl {
+	return &vec3.Vec3Impl{
+		X: math.Pow(math.Max(col.X, 0), g.invGamma),
+		Y: math.Pow(math.Max(col.Y, 0), g.invGamma),
+		Z: math.Pow(math.Max(col.Z, 0), g.invGamma),
+	}
+}
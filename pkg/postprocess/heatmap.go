@@ -0,0 +1,51 @@
+package postprocess
+
+import (
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/canvas"
+)
+
+// Heatmap returns an 8-bit RGB canvas showing the number of samples taken by every pixel
+// of the film. Pixels go from black for the fewest samples through blue and red to yellow
+// for the most.
+func Heatmap(f *canvas.Film) (*canvas.CanvasImpl, error) {
+	c, err := canvas.New(f.SizeX, f.SizeY, canvas.PixelFormatRGB, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	minSamples, maxSamples := 0, 0
+	for i, n := range f.Samples {
+		if i == 0 || n < minSamples {
+			minSamples = n
+		}
+		if n > maxSamples {
+			maxSamples = n
+		}
+	}
+
+	for i, n := range f.Samples {
+		t := 0.0
+		if maxSamples > minSamples {
+			t = float64(n-minSamples) / float64(maxSamples-minSamples)
+		}
+		r, g, b := heat(t)
+		c.Buffer[i*c.PixelSize] = clamp(r)
+		c.Buffer[i*c.PixelSize+1] = clamp(g)
+		c.Buffer[i*c.PixelSize+2] = clamp(b)
+	}
+
+	return c, nil
+}
+
+// heat maps t in [0, 1] to a black, blue, red, yellow colour ramp.
+func heat(t float64) (float64, float64, float64) {
+	switch {
+	case t < 1.0/3.0:
+		return 0, 0, 3 * t
+	case t < 2.0/3.0:
+		s := 3*t - 1
+		return s, 0, 1 - s
+	default:
+		return 1, 3*t - 2, 0
+	}
+}
@@ -0,0 +1,61 @@
+package postprocess
+
+import (
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/canvas"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// Pipeline represents a chain of post-processing operators.
+type Pipeline struct {
+	operators []Operator
+}
+
+// New returns a new pipeline that applies the supplied operators in order.
+func New(operators ...Operator) *Pipeline {
+	return &Pipeline{
+		operators: operators,
+	}
+}
+
+// Process applies the operators to every pixel of the film and quantizes the result
+// into an 8-bit RGB canvas.
+func (p *Pipeline) Process(f *canvas.Film) (*canvas.CanvasImpl, error) {
+	c, err := canvas.New(f.SizeX, f.SizeY, canvas.PixelFormatRGB, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for y := 0; y < f.SizeY; y++ {
+		for x := 0; x < f.SizeX; x++ {
+			col := p.Apply(x, y, f.Pixel(x, y))
+			i := (y*f.SizeX + x) * c.PixelSize
+			c.Buffer[i] = clamp(col.X)
+			c.Buffer[i+1] = clamp(col.Y)
+			c.Buffer[i+2] = clamp(col.Z)
+		}
+	}
+
+	return c, nil
+}
+
+// Apply runs all the operators on a single colour value.
+func (p *Pipeline) Apply(x int, y int, col *vec3.Vec3Impl) *vec3.Vec3Impl {
+	for _, op := range p.operators {
+		col = op.Apply(x, y, col)
+	}
+
+	return col
+}
+
+func clamp(f float64) uint8 {
+	i := int(255.99 * f)
+	if i < 0 {
+		return 0
+	}
+
+	if i < 256 {
+		return uint8(i)
+	}
+
+	return 255
+}
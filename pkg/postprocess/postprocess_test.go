@@ -0,0 +1,199 @@
+package postprocess
+
+import (
+	"testing"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/canvas"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestOperators(t *testing.T) {
+	testData := []struct {
+		name string
+		op   Operator
+		in   float64
+		want float64
+	}{
+		{name: "Exposure +1", op: NewExposure(1), in: 0.25, want: 0.5},
+		{name: "Exposure -2", op: NewExposure(-2), in: 1, want: 0.25},
+		{name: "Gamma 2", op: NewGamma(2), in: 0.25, want: 0.5},
+		{name: "Gamma 2 of a negative value", op: NewGamma(2), in: -1, want: 0},
+		{name: "sRGB of 0", op: NewSRGB(), in: 0, want: 0},
+		{name: "sRGB at the linear segment end", op: NewSRGB(), in: 0.0031308, want: 0.040449936},
+		{name: "sRGB of 0.5", op: NewSRGB(), in: 0.5, want: 0.7353569830524495},
+		{name: "sRGB of 1", op: NewSRGB(), in: 1, want: 1},
+		{name: "sRGB of a negative value", op: NewSRGB(), in: -1, want: 0},
+		{name: "Reinhard of 0", op: NewReinhard(0), in: 0, want: 0},
+		{name: "Reinhard of 1", op: NewReinhard(0), in: 1, want: 0.5},
+		{name: "Reinhard of 3", op: NewReinhard(0), in: 3, want: 0.75},
+		{name: "Reinhard of a negative value", op: NewReinhard(0), in: -4, want: 0},
+		{name: "Extended Reinhard of 1", op: NewReinhard(4), in: 1, want: 0.53125},
+		{name: "Extended Reinhard at the white point", op: NewReinhard(4), in: 4, want: 1},
+		{name: "Extended Reinhard above the white point", op: NewReinhard(4), in: 100, want: 1},
+		{name: "ACES of 0", op: NewACES(), in: 0, want: 0},
+		{name: "ACES of 1", op: NewACES(), in: 1, want: 0.8037974683544303},
+		{name: "ACES of a large value", op: NewACES(), in: 1e6, want: 1},
+		{name: "Filmic of 0", op: NewFilmic(), in: 0, want: 0},
+		{name: "Filmic of 1", op: NewFilmic(), in: 1, want: 0.49291854599116447},
+		{name: "Filmic at the white point", op: NewFilmic(), in: filmicWhitePoint / filmicExposureBias, want: 1},
+		{name: "Filmic of a large value", op: NewFilmic(), in: 100, want: 1},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			want := &vec3.Vec3Impl{X: test.want, Y: test.want, Z: test.want}
+			got := test.op.Apply(0, 0, &vec3.Vec3Impl{X: test.in, Y: test.in, Z: test.in})
+			if diff := cmp.Diff(want, got, cmpopts.EquateApprox(0, 1e-12)); diff != "" {
+				t.Errorf("Apply() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOperatorsMonotonic(t *testing.T) {
+	testData := []struct {
+		name string
+		op   Operator
+		// max is the largest input tested. Tone mapping operators must keep any input in [0, 1].
+		max float64
+	}{
+		{name: "Gamma 2", op: NewGamma(2), max: 1},
+		{name: "sRGB", op: NewSRGB(), max: 1},
+		{name: "Reinhard", op: NewReinhard(0), max: 1000},
+		{name: "Extended Reinhard", op: NewReinhard(4), max: 1000},
+		{name: "ACES", op: NewACES(), max: 1000},
+		{name: "Filmic", op: NewFilmic(), max: 1000},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			last := 0.0
+			for in := 0.0; in <= test.max; in += test.max / 10000 {
+				got := test.op.Apply(0, 0, &vec3.Vec3Impl{X: in, Y: in, Z: in}).X
+				if got < last {
+					t.Fatalf("Apply(%v) = %v, want at least %v", in, got, last)
+				}
+				if got < 0 || got > 1 {
+					t.Fatalf("Apply(%v) = %v, want a value in [0, 1]", in, got)
+				}
+				last = got
+			}
+		})
+	}
+}
+
+func TestDither(t *testing.T) {
+	d := NewDither()
+	sum := 0.0
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			for _, in := range []float64{0, 0.5 / 255, 1.0 / 255, 0.5, 254.0 / 255, 1} {
+				got := d.Apply(x, y, &vec3.Vec3Impl{X: in, Y: in, Z: in})
+				// The offset is less than half a quantization step.
+				if offset := got.X - in; offset <= -0.5/255 || offset >= 0.5/255 {
+					t.Errorf("Apply(%v, %v, %v) moved the value by %v", x, y, in, offset)
+				}
+				// Black and white stay black and white once quantized.
+				if q := clamp(got.X); (in == 0 && q != 0) || (in == 1 && q != 255) {
+					t.Errorf("Apply(%v, %v, %v) quantized to %v", x, y, in, q)
+				}
+			}
+			sum += d.Apply(x, y, &vec3.Vec3Impl{}).X
+		}
+	}
+
+	if sum < -1e-12 || sum > 1e-12 {
+		t.Errorf("the offsets of a 4x4 block add up to %v, want 0", sum)
+	}
+}
+
+func TestPipelineProcess(t *testing.T) {
+	// The film holds the sums of the samples: the second pixel averages two samples,
+	// the third has none and the last is out of range in both directions.
+	film := canvas.NewFilm(2, 2)
+	copy(film.Buffer, []float64{
+		0.25, 1, 0,
+		2, 0.5, 0,
+		0, 0, 0,
+		4, -1, 0.0625,
+	})
+	copy(film.Samples, []int{1, 2, 0, 1})
+
+	testData := []struct {
+		name     string
+		pipeline *Pipeline
+		want     []byte
+	}{
+		{
+			name:     "No operators",
+			pipeline: New(),
+			want:     []byte{63, 255, 0, 255, 63, 0, 0, 0, 0, 255, 0, 15},
+		},
+		{
+			name:     "Gamma 2",
+			pipeline: New(NewGamma(2)),
+			want:     []byte{127, 255, 0, 255, 127, 0, 0, 0, 0, 255, 0, 63},
+		},
+		{
+			name:     "Exposure before Reinhard",
+			pipeline: New(NewExposure(2), NewReinhard(0)),
+			want:     []byte{127, 204, 0, 204, 127, 0, 0, 0, 0, 240, 0, 51},
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.pipeline.Process(film)
+			if err != nil {
+				t.Fatalf("Process() = %v", err)
+			}
+			if got.SizeX != 2 || got.SizeY != 2 || got.PixelFormat != canvas.PixelFormatRGB {
+				t.Errorf("Process() returned a %vx%v canvas with pixel format %v, want a 2x2 RGB canvas", got.SizeX, got.SizeY, got.PixelFormat)
+			}
+			if diff := cmp.Diff(test.want, got.Buffer); diff != "" {
+				t.Errorf("Process() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHeatmap(t *testing.T) {
+	testData := []struct {
+		name    string
+		samples []int
+		want    []byte
+	}{
+		{
+			// 4 is the minimum, 8 a third of the way, 10 half way and 16 the maximum.
+			name:    "Ramp",
+			samples: []int{4, 8, 10, 16},
+			want:    []byte{0, 0, 0, 0, 0, 255, 127, 0, 127, 255, 255, 0},
+		},
+		{
+			name:    "Two thirds",
+			samples: []int{0, 2, 3, 3},
+			want:    []byte{0, 0, 0, 255, 0, 0, 255, 255, 0, 255, 255, 0},
+		},
+		{
+			name:    "Same number of samples",
+			samples: []int{16, 16, 16, 16},
+			want:    []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			film := canvas.NewFilm(2, 2)
+			copy(film.Samples, test.samples)
+			got, err := Heatmap(film)
+			if err != nil {
+				t.Fatalf("Heatmap() = %v", err)
+			}
+			if diff := cmp.Diff(test.want, got.Buffer); diff != "" {
+				t.Errorf("Heatmap() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package postprocess
+
+import "github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+
+// Ensure interface compliance.
+var _ Operator = (*Reinhard)(nil)
+
+// Reinhard implements the extended Reinhard tone mapping operator.
+type Reinhard struct {
+	whitePoint float64
+}
+
+// NewReinhard returns an instance of the Reinhard operator. Values equal to or above
+// the white point are mapped to 1. A white point of 0 selects the simple c/(1+c) curve.
+func NewReinhard(whitePoint float64) *Reinhard {
+	return &Reinhard{
+		whitePoint: whitePoint,
+	}
+}
+
+// Apply tone maps each colour channel.
+func (r *Reinhard) Apply(_ int, _ int, col *vec3.Vec3Impl) *vec3.Vec3Impl {
+	return &vec3.Vec3Impl{X: r.curve(col.X), Y: r.curve(col.Y), Z: r.curve(col.Z)}
+}
+
+func (r *Reinhard) curve(c float64) float64 {
+	if c <= 0 {
+		return 0
+	}
+
+	if r.whitePoint <= 0 {
+		return c / (1 + c)
+	}
+
+	if c >= r.whitePoint {
+		return 1
+	}
+
+	// c * (1 + c/w^2) / (1 + c)
+	return c * (1 + c/(r.whitePoint*r.whitePoint)) / (1 + c)
+}
@@ -0,0 +1,31 @@
+package postprocess
+
+import (
+	"math"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// Ensure interface compliance.
+var _ Operator = (*SRGB)(nil)
+
+// SRGB implements the sRGB opto-electronic transfer function.
+type SRGB struct{}
+
+// NewSRGB returns an instance of the sRGB operator.
+func NewSRGB() *SRGB {
+	return &SRGB{}
+}
+
+// Apply encodes each colour channel.
+func (s *SRGB) Apply(_ int, _ int, col *vec3.Vec3Impl) *vec3.Vec3Impl {
+	return &vec3.Vec3Impl{X: srgbOETF(col.X), Y: srgbOETF(col.Y), Z: srgbOETF(col.Z)}
+}
+
+func srgbOETF(c float64) float64 {
+	if c <= 0.0031308 {
+		return 12.92 * math.Max(c, 0)
+	}
+
+	return 1.055*math.Pow(c, 1.0/2.4) - 0.055
+}
@@ -0,0 +1,88 @@
+package render
+
+import (
+	"math"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/canvas"
+)
+
+const (
+	defaultMinSamples = 16
+	defaultThreshold  = 0.01
+)
+
+// renderAdaptive gives every pixel MinSamples samples and then keeps adding PassSamples
+// samples to the pixels whose estimated error is above the threshold until they converge
+// or reach NumSamples.
+func (r *renderer) renderAdaptive() error {
+	maxSamples := r.opts.NumSamples
+	minSamples := r.opts.MinSamples
+	if minSamples < 1 {
+		minSamples = defaultMinSamples
+	}
+	if minSamples > maxSamples {
+		minSamples = maxSamples
+	}
+	passSamples := r.opts.PassSamples
+	if passSamples < 1 {
+		passSamples = minSamples
+	}
+	threshold := r.opts.Threshold
+	if threshold <= 0 {
+		threshold = defaultThreshold
+	}
+
+	target := minSamples
+	var active []bool
+	for {
+		if err := r.renderPass(target, active); err != nil {
+			return err
+		}
+		r.snapshot()
+		if target >= maxSamples {
+			return nil
+		}
+
+		target += passSamples
+		if target > maxSamples {
+			target = maxSamples
+		}
+
+		var numActive int
+		active, numActive = unconverged(r.film, r.region, target, threshold)
+		if numActive == 0 {
+			return nil
+		}
+	}
+}
+
+// unconverged returns a mask of the pixels of the region that have less than numSamples
+// samples and an error above the threshold, together with the number of such pixels.
+// The error of pixels with less than two samples cannot be estimated so they are never converged.
+func unconverged(film *canvas.Film, region Tile, numSamples int, threshold float64) ([]bool, int) {
+	active := make([]bool, film.SizeX*film.SizeY)
+	n := 0
+	for y := region.Y0; y < region.Y1; y++ {
+		for x := region.X0; x < region.X1; x++ {
+			i := y*film.SizeX + x
+			if film.Samples[i] < numSamples && (film.Samples[i] < 2 || pixelError(film, x, y) > threshold) {
+				active[i] = true
+				n++
+			}
+		}
+	}
+
+	return active, n
+}
+
+// pixelError estimates the error of a pixel as it will be displayed. With a gamma 2
+// transfer the displayed value is sqrt(L), so a standard error e in the luminance L
+// becomes roughly e / (2 * sqrt(L)).
+func pixelError(film *canvas.Film, x int, y int) float64 {
+	stdErr := math.Sqrt(film.Variance(x, y))
+	if stdErr == 0 {
+		return 0
+	}
+
+	return stdErr / (2 * math.Sqrt(math.Max(film.Luminance(x, y), 1e-6)))
+}
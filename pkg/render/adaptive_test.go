@@ -0,0 +1,74 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/canvas"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scenes"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRenderAdaptiveUniform(t *testing.T) {
+	// Every sample of the emissive sphere has the same value so all the pixels
+	// converge after the first pass.
+	film := canvas.NewFilm(13, 7)
+	if err := Render(makeScene(13.0/7.0), film, &Options{
+		NumSamples: 64,
+		NumWorkers: 3,
+		Adaptive:   true,
+		MinSamples: 4,
+		TileSize:   4,
+	}); err != nil {
+		t.Fatalf("Render() = %v", err)
+	}
+
+	for i, n := range film.Samples {
+		if n != 4 {
+			t.Fatalf("pixel %v has %v samples, want 4", i, n)
+		}
+	}
+}
+
+func TestRenderAdaptive(t *testing.T) {
+	render := func(numWorkers int) *canvas.Film {
+		film := canvas.NewFilm(24, 16)
+		if err := Render(scenes.CornellBox(24.0/16.0), film, &Options{
+			NumSamples:  32,
+			NumWorkers:  numWorkers,
+			Seed:        1,
+			TileSize:    5,
+			Adaptive:    true,
+			MinSamples:  8,
+			PassSamples: 8,
+			Threshold:   0.05,
+		}); err != nil {
+			t.Fatalf("Render() = %v", err)
+		}
+		return film
+	}
+
+	want := render(1)
+	minSamples, maxSamples := want.Samples[0], want.Samples[0]
+	for _, n := range want.Samples {
+		if n < 8 || n > 32 {
+			t.Fatalf("pixel has %v samples, want between 8 and 32", n)
+		}
+		if n < minSamples {
+			minSamples = n
+		}
+		if n > maxSamples {
+			maxSamples = n
+		}
+	}
+	if minSamples == maxSamples {
+		t.Errorf("all pixels have %v samples, want an adaptive distribution", minSamples)
+	}
+
+	got := render(4)
+	if diff := cmp.Diff(want.Samples, got.Samples); diff != "" {
+		t.Errorf("Render() sample counts mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(want.Buffer, got.Buffer); diff != "" {
+		t.Errorf("Render() mismatch (-want +got):\n%s", diff)
+	}
+}
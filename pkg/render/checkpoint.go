@@ -0,0 +1,103 @@
+package render
+
+import (
+	"bufio"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/canvas"
+)
+
+// checkpointMagic identifies checkpoint files and their version.
+const checkpointMagic = "RTCHECKPOINT1\n"
+
+// Checkpoint holds the state needed to resume a render.
+type Checkpoint struct {
+	// Film contains the sums of the samples and the number of samples of every pixel.
+	Film *canvas.Film
+	// Seed and Sampler select the sample values. Samplers are deterministic for every pixel
+	// sample so they capture the state of the random number generators together with the
+	// number of samples of each pixel.
+	Seed    int64
+	Sampler int
+	// Settings holds application defined render settings, for example command line flags.
+	Settings map[string]string
+}
+
+// SaveCheckpoint writes the checkpoint to a temporary file that then replaces fileName,
+// so that a crash while saving never destroys the previous checkpoint.
+func SaveCheckpoint(fileName string, c *Checkpoint) error {
+	tmp := fileName + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if err := WriteCheckpoint(f, c); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, fileName)
+}
+
+// LoadCheckpoint reads the checkpoint stored in fileName.
+func LoadCheckpoint(fileName string) (*Checkpoint, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	c, err := ReadCheckpoint(f)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", fileName, err)
+	}
+
+	return c, nil
+}
+
+// WriteCheckpoint encodes the checkpoint.
+func WriteCheckpoint(w io.Writer, c *Checkpoint) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(checkpointMagic); err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(bw).Encode(c); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// ReadCheckpoint decodes a checkpoint and checks that its film is consistent.
+func ReadCheckpoint(r io.Reader) (*Checkpoint, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(checkpointMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != checkpointMagic {
+		return nil, errors.New("not a checkpoint file")
+	}
+
+	c := &Checkpoint{}
+	if err := gob.NewDecoder(br).Decode(c); err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoint; %w", err)
+	}
+
+	f := c.Film
+	if f == nil || f.SizeX < 1 || f.SizeY < 1 {
+		return nil, errors.New("the checkpoint does not contain a film")
+	}
+	n := f.SizeX * f.SizeY
+	if len(f.Buffer) != n*3 || len(f.SumSquares) != n || len(f.Samples) != n {
+		return nil, fmt.Errorf("the film buffers do not match its size of %vx%v", f.SizeX, f.SizeY)
+	}
+
+	return c, nil
+}
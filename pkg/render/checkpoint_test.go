@@ -0,0 +1,113 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/canvas"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scenes"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRenderResume(t *testing.T) {
+	testData := []struct {
+		name string
+		opts Options
+		// stopAfter is the number of completed tiles after which the render is stopped.
+		stopAfter int
+	}{
+		{
+			name:      "Tiles",
+			opts:      Options{NumSamples: 6, Seed: 3, TileSize: 7},
+			stopAfter: 6,
+		},
+		{
+			name:      "Progressive",
+			opts:      Options{NumSamples: 6, Seed: 3, TileSize: 7, Progressive: true, Sampler: sampler.TypeSobol},
+			stopAfter: 30,
+		},
+		{
+			name:      "Adaptive",
+			opts:      Options{NumSamples: 24, Seed: 3, TileSize: 5, Adaptive: true, MinSamples: 4, PassSamples: 4, Threshold: 0.05},
+			stopAfter: 30,
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			opts := test.opts
+			opts.NumWorkers = 3
+			want := canvas.NewFilm(24, 16)
+			if err := Render(scenes.CornellBox(24.0/16.0), want, &opts); err != nil {
+				t.Fatalf("Render() = %v", err)
+			}
+
+			// Stop the render half way through and keep every checkpoint.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			checkpoints := []*canvas.Film{}
+			interrupted := opts
+			interrupted.CheckpointInterval = 1
+			interrupted.Checkpoint = func(film *canvas.Film) {
+				checkpoints = append(checkpoints, film.Clone())
+			}
+			tiles := 0
+			interrupted.Progress = func(p Progress) {
+				if tiles++; tiles == test.stopAfter {
+					cancel()
+				}
+			}
+			if err := RenderContext(ctx, scenes.CornellBox(24.0/16.0), canvas.NewFilm(24, 16), &interrupted); err == nil {
+				t.Fatal("RenderContext() = nil, want the render to stop early")
+			}
+			if len(checkpoints) < 3 {
+				t.Fatalf("got %v checkpoints, want at least 3", len(checkpoints))
+			}
+
+			// Both periodic checkpoints and the one taken when the render stops can be resumed.
+			for _, i := range []int{len(checkpoints) / 2, len(checkpoints) - 1} {
+				got := checkpoints[i]
+				opts.NumWorkers = 2
+				if err := Render(scenes.CornellBox(24.0/16.0), got, &opts); err != nil {
+					t.Fatalf("Render() = %v", err)
+				}
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Errorf("Render() from checkpoint %v mismatch (-want +got):\n%s", i, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	film := canvas.NewFilm(3, 2)
+	film.Encoding = canvas.EncodingPFM
+	film.Buffer[4] = 0.25
+	film.SumSquares[1] = 1e-3
+	film.Samples[1] = 7
+	want := &Checkpoint{
+		Film:     film,
+		Seed:     -5,
+		Sampler:  sampler.TypeHalton,
+		Settings: map[string]string{"scene": "cornell-box", "samples": "100"},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := WriteCheckpoint(buf, want); err != nil {
+		t.Fatalf("WriteCheckpoint() = %v", err)
+	}
+	got, err := ReadCheckpoint(buf)
+	if err != nil {
+		t.Fatalf("ReadCheckpoint() = %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ReadCheckpoint() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := ReadCheckpoint(strings.NewReader("P3\n")); err == nil {
+		t.Error("ReadCheckpoint() of a PPM file = nil, want an error")
+	}
+}
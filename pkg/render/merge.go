@@ -0,0 +1,49 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/canvas"
+)
+
+// Merge combines independent renders of the same image into a new film. The samples of
+// every pixel are pooled, so each render is weighted by the number of samples it took.
+// The renders must have the same size and sampler and use different seeds, as renders
+// with the same seed take the same samples. Settings are application defined and it is
+// up to the caller to check that the renders are of the same scene.
+func Merge(partials ...*Checkpoint) (*canvas.Film, error) {
+	if len(partials) == 0 {
+		return nil, errors.New("no renders to merge")
+	}
+
+	first := partials[0]
+	seeds := make(map[int64]int)
+	for i, p := range partials {
+		if p.Film.SizeX != first.Film.SizeX || p.Film.SizeY != first.Film.SizeY {
+			return nil, fmt.Errorf("render %v is %vx%v, want %vx%v", i, p.Film.SizeX, p.Film.SizeY, first.Film.SizeX, first.Film.SizeY)
+		}
+		if p.Sampler != first.Sampler {
+			return nil, fmt.Errorf("render %v uses a different sampler", i)
+		}
+		if j, ok := seeds[p.Seed]; ok {
+			return nil, fmt.Errorf("renders %v and %v use the same seed %v", j, i, p.Seed)
+		}
+		seeds[p.Seed] = i
+	}
+
+	film := canvas.NewFilm(first.Film.SizeX, first.Film.SizeY)
+	for _, p := range partials {
+		for i, v := range p.Film.Buffer {
+			film.Buffer[i] += v
+		}
+		for i, v := range p.Film.SumSquares {
+			film.SumSquares[i] += v
+		}
+		for i, n := range p.Film.Samples {
+			film.Samples[i] += n
+		}
+	}
+
+	return film, nil
+}
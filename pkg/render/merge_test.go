@@ -0,0 +1,80 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/canvas"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scenes"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestMerge(t *testing.T) {
+	settings := map[string]string{"scene": "cornell-box"}
+	partials := []*Checkpoint{}
+	for seed, numSamples := range []int{2, 3, 5} {
+		film := canvas.NewFilm(24, 16)
+		if err := Render(scenes.CornellBox(24.0/16.0), film, &Options{NumSamples: numSamples, NumWorkers: 2, Seed: int64(seed)}); err != nil {
+			t.Fatalf("Render() = %v", err)
+		}
+		partials = append(partials, &Checkpoint{Film: film, Seed: int64(seed), Settings: settings})
+	}
+
+	got, err := Merge(partials...)
+	if err != nil {
+		t.Fatalf("Merge() = %v", err)
+	}
+
+	want := canvas.NewFilm(24, 16)
+	for i := range want.Samples {
+		want.Samples[i] = 10
+		want.SumSquares[i] = partials[0].Film.SumSquares[i] + partials[1].Film.SumSquares[i] + partials[2].Film.SumSquares[i]
+		for c := 0; c < 3; c++ {
+			want.Buffer[i*3+c] = partials[0].Film.Buffer[i*3+c] + partials[1].Film.Buffer[i*3+c] + partials[2].Film.Buffer[i*3+c]
+		}
+	}
+	// Each render contributes in proportion to its number of samples.
+	if diff := cmp.Diff(want, got, cmpopts.EquateApprox(1e-12, 0)); diff != "" {
+		t.Errorf("Merge() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	partial := func(sizeX int, seed int64, smp int) *Checkpoint {
+		return &Checkpoint{
+			Film:    canvas.NewFilm(sizeX, 2),
+			Seed:    seed,
+			Sampler: smp,
+		}
+	}
+
+	testData := []struct {
+		name     string
+		partials []*Checkpoint
+	}{
+		{
+			name: "No renders",
+		},
+		{
+			name:     "Different sizes",
+			partials: []*Checkpoint{partial(2, 0, sampler.TypeRandom), partial(3, 1, sampler.TypeRandom)},
+		},
+		{
+			name:     "Different samplers",
+			partials: []*Checkpoint{partial(2, 0, sampler.TypeRandom), partial(2, 1, sampler.TypeSobol)},
+		},
+		{
+			name:     "Same seed",
+			partials: []*Checkpoint{partial(2, 0, sampler.TypeRandom), partial(2, 1, sampler.TypeRandom), partial(2, 0, sampler.TypeRandom)},
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Merge(test.partials...); err == nil {
+				t.Error("Merge() = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package render
+
+import (
+	"time"
+)
+
+// renderProgressive adds one sample to every pixel per pass until all of them have NumSamples samples.
+func (r *renderer) renderProgressive() error {
+	for target := 1; target <= r.opts.NumSamples; target++ {
+		if err := r.renderPass(target, nil); err != nil {
+			return err
+		}
+		r.snapshot()
+	}
+
+	return nil
+}
+
+// snapshot invokes the snapshot callback if the number of passes or the time since
+// the last snapshot require it.
+func (r *renderer) snapshot() {
+	if r.opts.Snapshot == nil {
+		return
+	}
+
+	passes := r.opts.SnapshotPasses
+	interval := r.opts.SnapshotInterval
+	pass := r.progress.Pass
+	due := passes <= 0 && interval <= 0
+	if passes > 0 && pass%passes == 0 {
+		due = true
+	}
+	if interval > 0 && time.Since(r.lastSnapshot) >= interval {
+		due = true
+	}
+	if !due {
+		return
+	}
+
+	r.opts.Snapshot(r.film, pass)
+	r.lastSnapshot = time.Now()
+}
@@ -0,0 +1,46 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/canvas"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scenes"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRenderProgressive(t *testing.T) {
+	opts := &Options{
+		NumSamples: 6,
+		NumWorkers: 3,
+		Seed:       5,
+		TileSize:   7,
+	}
+	want := canvas.NewFilm(24, 16)
+	if err := Render(scenes.CornellBox(24.0/16.0), want, opts); err != nil {
+		t.Fatalf("Render() = %v", err)
+	}
+
+	snapshots := []int{}
+	opts.Progressive = true
+	opts.SnapshotPasses = 2
+	opts.Snapshot = func(film *canvas.Film, pass int) {
+		for i, n := range film.Samples {
+			if n != pass {
+				t.Fatalf("snapshot of pass %v: pixel %v has %v samples", pass, i, n)
+			}
+		}
+		snapshots = append(snapshots, pass)
+	}
+	got := canvas.NewFilm(24, 16)
+	if err := Render(scenes.CornellBox(24.0/16.0), got, opts); err != nil {
+		t.Fatalf("Render() = %v", err)
+	}
+
+	// Every pixel sample uses the same sample values regardless of the pass it is taken in.
+	if diff := cmp.Diff(want.Buffer, got.Buffer); diff != "" {
+		t.Errorf("Render() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]int{2, 4, 6}, snapshots); diff != "" {
+		t.Errorf("snapshot passes mismatch (-want +got):\n%s", diff)
+	}
+}
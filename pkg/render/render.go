@@ -2,135 +2,322 @@
 package render
 
 import (
-	"image"
-	"image/color"
-	"math"
-	"math/rand"
+	"context"
 	"sync"
+	"time"
 
-	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/camera"
-	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitable"
-	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/pdf"
-	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/ray"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/canvas"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/integrator"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scene"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
 )
 
+// Options contains the settings used by the renderer.
+type Options struct {
+	// NumSamples is the number of samples per pixel.
+	NumSamples int
+	// NumWorkers is the number of worker goroutines.
+	NumWorkers int
+	// Integrator is the light transport algorithm. When nil a path tracer limited
+	// by MaxDepth and RussianRouletteDepth is used.
+	Integrator integrator.Integrator
+	// MaxDepth is the maximum number of bounces of the default integrator.
+	MaxDepth int
+	// RussianRouletteDepth is the number of bounces after which the default integrator
+	// starts terminating paths at random. Russian roulette is disabled when 0.
+	RussianRouletteDepth int
+	// Seed determines the sample values. Renders with the same seed are identical
+	// regardless of the number of workers.
+	Seed int64
+	// TileSize is the width and height of the tiles the image is split into. Defaults to 32.
+	TileSize int
+	// TileOrder is the order in which tiles are rendered.
+	TileOrder int
+	// Region restricts the render to the pixels inside the tile. The rest of the film is
+	// left untouched. The whole image is rendered when nil.
+	Region *Tile
+	// Sampler is the type of sampler used to generate the sample values.
+	Sampler int
+	// Adaptive enables adaptive sampling. Pixels are rendered in passes and stop receiving
+	// samples once their estimated error drops below Threshold. NumSamples is then the
+	// maximum number of samples per pixel.
+	Adaptive bool
+	// MinSamples is the number of samples every pixel receives in adaptive mode. Defaults to 16.
+	MinSamples int
+	// PassSamples is the number of samples added to unconverged pixels on every adaptive pass.
+	// Defaults to MinSamples.
+	PassSamples int
+	// Threshold is the error below which a pixel is considered converged. It is measured as
+	// the standard error of the pixel luminance after a gamma 2 transfer. Defaults to 0.01.
+	Threshold float64
+	// Progressive renders the whole image one sample per pixel at a time until every pixel has
+	// NumSamples samples, so that a usable image is available early on.
+	Progressive bool
+	// Snapshot is called with the film after a progressive or adaptive pass is completed and
+	// no worker is running. It must not modify the film nor retain it after returning.
+	Snapshot func(film *canvas.Film, pass int)
+	// SnapshotPasses is the number of passes between snapshots.
+	SnapshotPasses int
+	// SnapshotInterval is the minimum time between snapshots. Every pass produces
+	// a snapshot when neither SnapshotPasses nor SnapshotInterval are set.
+	SnapshotInterval time.Duration
+	// Progress is called every time a tile is completed. It is never called concurrently.
+	Progress func(p Progress)
+	// Checkpoint is called with a copy of the film that only contains fully sampled pixels
+	// when a tile is completed and at least CheckpointInterval has passed since the last call,
+	// and once more with the film if the render stops early. Rendering the returned film again
+	// with the same options gives the same result as an uninterrupted render.
+	// It must not modify the film nor retain it after returning.
+	Checkpoint func(film *canvas.Film)
+	// CheckpointInterval is the minimum time between periodic checkpoints. Checkpoints are
+	// only taken when the render stops early if it is not set.
+	CheckpointInterval time.Duration
+}
+
+// Progress contains information about the state of a render.
+type Progress struct {
+	// Pass is the current pass, starting at 1.
+	Pass int
+	// TilesCompleted is the number of tiles of the current pass that have been fully rendered.
+	TilesCompleted int
+	// TilesTotal is the number of tiles rendered by the current pass.
+	TilesTotal int
+	// Samples is the number of samples taken so far.
+	Samples int64
+	// SamplesPerSecond is the average sampling rate since the render started.
+	SamplesPerSecond float64
+	// Elapsed is the time since the render started.
+	Elapsed time.Duration
+	// ETA is the estimated time left until the current pass completes.
+	ETA time.Duration
+}
+
 type workUnit struct {
-	cam        *camera.Camera
-	world      *hitable.HitableSlice
-	canvas     *image.NRGBA
+	scene      *scene.Scene
+	integrator integrator.Integrator
+	film       *canvas.Film
 	numSamples int
-	x0         int
-	x1         int
-	y0         int
-	y1         int
+	active     []bool
+	tile       Tile
 }
 
-func colour(r ray.Ray, world *hitable.HitableSlice, lightShape hitable.Hitable, depth int) *vec3.Vec3Impl {
-	if rec, mat, ok := world.Hit(r, 0.001, math.MaxFloat64); ok {
-		_, srec, ok := mat.Scatter(r, rec)
-		emitted := mat.Emitted(r, rec, rec.U(), rec.V(), rec.P())
-		if depth < 50 && ok {
-			if srec.IsSpecular() {
-				// srec.Attenuation() * colour(...)
-				return vec3.Mul(srec.Attenuation(), colour(srec.SpecularRay(), world, lightShape, depth+1))
-			} else {
-				pLight := pdf.NewHitable(lightShape, rec.P())
-				p := pdf.NewMixture(pLight, srec.PDF())
-				scattered := ray.New(rec.P(), p.Generate(), r.Time())
-				pdfVal := p.Value(scattered.Direction())
-				// emitted + (albedo * scatteringPDF())*colour() / pdf
-				v1 := vec3.ScalarMul(colour(scattered, world, lightShape, depth+1), mat.ScatteringPDF(r, rec, scattered))
-				v2 := vec3.Mul(srec.Attenuation(), v1)
-				v3 := vec3.ScalarDiv(v2, pdfVal)
-				res := vec3.Add(emitted, v3)
-				return res
+// renderRect renders the work unit and returns the number of samples taken
+// or false if the context was cancelled before the work unit was completed.
+// Every active pixel receives samples until it has numSamples of them, so the
+// sample indices carry on from whatever the film already contains.
+func renderRect(ctx context.Context, w workUnit, sampler sampler.Sampler) (int, bool) {
+	nx := w.film.SizeX
+	ny := w.film.SizeY
+	total := 0
+	for y := w.tile.Y0; y < w.tile.Y1; y++ {
+		for x := w.tile.X0; x < w.tile.X1; x++ {
+			if ctx.Err() != nil {
+				return 0, false
+			}
+			i := y*nx + x
+			if w.active != nil && !w.active[i] {
+				continue
+			}
+			for s := w.film.Samples[i]; s < w.numSamples; s++ {
+				sampler.StartPixelSample(x, y, s)
+				// Film rows go from top to bottom while v goes from bottom to top.
+				du, dv := sampler.Get2D()
+				u := (float64(x) + du) / float64(nx)
+				v := (float64(ny-1-y) + dv) / float64(ny)
+				r := w.scene.Camera().GetRay(u, v, sampler)
+				w.film.AddSample(x, y, vec3.DeNAN(w.integrator.Radiance(r, w.scene, sampler)))
+				total++
 			}
-		} else {
-			return emitted
 		}
 	}
-	return &vec3.Vec3Impl{}
-}
-
-func clamp(f float64) uint8 {
-	i := int(255.99 * f)
-	if i < 256 {
-		return uint8(i)
-	}
 
-	return 255
+	return total, true
 }
-func renderRect(w workUnit) {
-	nx := w.canvas.Bounds().Max.X
-	ny := w.canvas.Bounds().Max.Y
-	for y := w.y0; y <= w.y1; y++ {
-		for x := w.x0; x <= w.x1; x++ {
-			col := &vec3.Vec3Impl{}
-			for s := 0; s < w.numSamples; s++ {
-				u := (float64(x) + rand.Float64()) / float64(nx)
-				v := (float64(y) + rand.Float64()) / float64(ny)
-				r := w.cam.GetRay(u, v)
-				lightShape := hitable.NewXZRect(213, 343, 227, 332, 554, nil)
-				glassSphere := hitable.NewSphere(&vec3.Vec3Impl{X: 190, Y: 90, Z: 190}, &vec3.Vec3Impl{X: 190, Y: 90, Z: 190}, 0, 1, 90, nil)
-				hList := hitable.NewSlice([]hitable.Hitable{lightShape, glassSphere})
-				col = vec3.Add(col, vec3.DeNAN(colour(r, w.world, hList, 0)))
-			}
 
-			col = vec3.ScalarDiv(col, float64(w.numSamples))
-			// gamma 2
-			col = &vec3.Vec3Impl{X: math.Sqrt(col.X), Y: math.Sqrt(col.Y), Z: math.Sqrt(col.Z)}
-			ir := clamp(col.X)
-			ig := clamp(col.Y)
-			ib := clamp(col.Z)
-			w.canvas.SetNRGBA(x, y, color.NRGBA{R: ir, G: ig, B: ib, A: 255})
-		}
-	}
+// tileResult is sent by the workers when they complete a work unit.
+type tileResult struct {
+	tile    Tile
+	samples int
 }
 
-func worker(input chan workUnit, quit chan struct{}, wg sync.WaitGroup) {
-	wg.Add(1)
+func worker(ctx context.Context, sampler sampler.Sampler, input <-chan workUnit, done chan<- tileResult, wg *sync.WaitGroup) {
 	defer wg.Done()
-	for {
-		select {
-		case w := <-input:
-			renderRect(w)
-		case <-quit:
+	for w := range input {
+		samples, ok := renderRect(ctx, w, sampler)
+		if !ok {
 			return
 		}
+		done <- tileResult{tile: w.tile, samples: samples}
 	}
-
 }
 
 // Render performs the rendering task spread across 1 or more worker goroutines.
-func Render(cam *camera.Camera, world *hitable.HitableSlice, canvas *image.NRGBA, numSamples int, numWorkers int) {
-	nx := canvas.Bounds().Max.X
-	ny := canvas.Bounds().Max.Y
+// The linear radiance samples are accumulated in the supplied film.
+// An error is returned if the options are invalid.
+func Render(s *scene.Scene, film *canvas.Film, opts *Options) error {
+	return RenderContext(context.Background(), s, film, opts)
+}
 
-	queue := make(chan workUnit)
-	quit := make(chan struct{})
-	wg := sync.WaitGroup{}
+// RenderContext is like Render but stops the workers as soon as the context is done.
+// The film then contains a partial image and the context error is returned.
+// RenderContext only returns once all the workers have exited.
+func RenderContext(ctx context.Context, s *scene.Scene, film *canvas.Film, opts *Options) error {
+	in := opts.Integrator
+	if in == nil {
+		in = integrator.NewPathTracer(opts.MaxDepth, opts.RussianRouletteDepth)
+	}
+
+	numWorkers := opts.NumWorkers
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
 
+	// Every worker owns its sampler so there is no contention between them.
+	samplers := []sampler.Sampler{}
 	for i := 0; i < numWorkers; i++ {
-		go worker(queue, quit, wg)
+		smp, err := sampler.New(opts.Sampler, opts.Seed, opts.NumSamples)
+		if err != nil {
+			return err
+		}
+		samplers = append(samplers, smp)
+	}
+
+	r := &renderer{
+		ctx:        ctx,
+		scene:      s,
+		integrator: in,
+		film:       film,
+		samplers:   samplers,
+		opts:       opts,
+		start:      time.Now(),
+	}
+	r.region = Tile{X1: film.SizeX, Y1: film.SizeY}
+	if opts.Region != nil {
+		r.region = *opts.Region
+	}
+	r.lastSnapshot = r.start
+	r.lastCheckpoint = r.start
+	if opts.Checkpoint != nil && opts.CheckpointInterval > 0 {
+		r.committed = film.Clone()
 	}
 
-	for y := 0; y <= (ny - 10); y += 10 {
-		queue <- workUnit{
-			cam:        cam,
-			world:      world,
-			canvas:     canvas,
+	var err error
+	switch {
+	case opts.Adaptive:
+		err = r.renderAdaptive()
+	case opts.Progressive:
+		err = r.renderProgressive()
+	default:
+		err = r.renderPass(opts.NumSamples, nil)
+	}
+
+	// The workers finish every pixel they start so the film can be resumed.
+	if err != nil && opts.Checkpoint != nil {
+		opts.Checkpoint(film)
+	}
+
+	return err
+}
+
+// renderer holds the state shared by the passes of a render.
+type renderer struct {
+	ctx        context.Context
+	scene      *scene.Scene
+	integrator integrator.Integrator
+	film       *canvas.Film
+	samplers   []sampler.Sampler
+	opts       *Options
+	start      time.Time
+	progress   Progress
+	// region is the part of the film that is rendered.
+	region Tile
+	// lastSnapshot is the time the last snapshot was taken.
+	lastSnapshot time.Time
+	// committed holds the pixels of the completed tiles for periodic checkpoints.
+	committed *canvas.Film
+	// lastCheckpoint is the time the last checkpoint was taken.
+	lastCheckpoint time.Time
+}
+
+// renderPass renders every tile containing active pixels until those pixels have
+// numSamples samples. A nil mask means that all the pixels are active.
+func (r *renderer) renderPass(numSamples int, active []bool) error {
+	units := []workUnit{}
+	for _, t := range Tiles(r.film.SizeX, r.film.SizeY, r.opts.TileSize, r.opts.TileOrder) {
+		t, ok := t.intersect(r.region)
+		if !ok || !t.hasActive(r.film.SizeX, active) {
+			continue
+		}
+		units = append(units, workUnit{
+			scene:      r.scene,
+			integrator: r.integrator,
+			film:       r.film,
 			numSamples: numSamples,
-			x0:         0,
-			x1:         nx,
-			y0:         y,
-			y1:         y + (10 - 1),
+			active:     active,
+			tile:       t,
+		})
+	}
+
+	queue := make(chan workUnit, len(units))
+	for _, w := range units {
+		queue <- w
+	}
+	close(queue)
+
+	done := make(chan tileResult)
+	wg := sync.WaitGroup{}
+	for _, smp := range r.samplers {
+		wg.Add(1)
+		go worker(r.ctx, smp, queue, done, &wg)
+	}
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	p := &r.progress
+	p.Pass++
+	p.TilesCompleted = 0
+	p.TilesTotal = len(units)
+	passStart := time.Now()
+	for res := range done {
+		p.TilesCompleted++
+		p.Samples += int64(res.samples)
+		r.checkpoint(res.tile)
+		if r.opts.Progress == nil {
+			continue
 		}
+
+		p.Elapsed = time.Since(r.start)
+		p.SamplesPerSecond = float64(p.Samples) / p.Elapsed.Seconds()
+		passElapsed := time.Since(passStart)
+		p.ETA = time.Duration(float64(passElapsed) * float64(p.TilesTotal-p.TilesCompleted) / float64(p.TilesCompleted))
+		r.opts.Progress(*p)
 	}
 
-	for i := 0; i < numWorkers; i++ {
-		quit <- struct{}{}
+	return r.ctx.Err()
+}
+
+// checkpoint records the pixels of a completed tile and invokes the checkpoint callback
+// if enough time has passed since the last checkpoint. The other tiles may still be
+// rendering so the callback receives a copy of the film.
+func (r *renderer) checkpoint(t Tile) {
+	if r.committed == nil {
+		return
 	}
 
-	wg.Wait()
+	for y := t.Y0; y < t.Y1; y++ {
+		i0, i1 := y*r.film.SizeX+t.X0, y*r.film.SizeX+t.X1
+		copy(r.committed.Buffer[i0*3:i1*3], r.film.Buffer[i0*3:i1*3])
+		copy(r.committed.SumSquares[i0:i1], r.film.SumSquares[i0:i1])
+		copy(r.committed.Samples[i0:i1], r.film.Samples[i0:i1])
+	}
+
+	if time.Since(r.lastCheckpoint) < r.opts.CheckpointInterval {
+		return
+	}
+	r.opts.Checkpoint(r.committed)
+	r.lastCheckpoint = time.Now()
 }
@@ -0,0 +1,267 @@
+package render
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/camera"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/canvas"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitable"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scene"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scenes"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/texture"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRender(t *testing.T) {
+	testData := []struct {
+		name       string
+		sizeX      int
+		sizeY      int
+		numWorkers int
+		tileSize   int
+		tileOrder  int
+	}{
+		{name: "Odd resolution", sizeX: 37, sizeY: 23, numWorkers: 4, tileSize: 8, tileOrder: TileOrderScanline},
+		{name: "Odd resolution spiral", sizeX: 31, sizeY: 29, numWorkers: 3, tileSize: 7, tileOrder: TileOrderSpiral},
+		{name: "Odd resolution hilbert", sizeX: 19, sizeY: 41, numWorkers: 2, tileSize: 5, tileOrder: TileOrderHilbert},
+		{name: "Single worker", sizeX: 10, sizeY: 10, numWorkers: 1, tileSize: 32, tileOrder: TileOrderScanline},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			film := canvas.NewFilm(test.sizeX, test.sizeY)
+			var last Progress
+			err := RenderContext(context.Background(), makeScene(float64(test.sizeX)/float64(test.sizeY)), film, &Options{
+				NumSamples: 2,
+				NumWorkers: test.numWorkers,
+				TileSize:   test.tileSize,
+				TileOrder:  test.tileOrder,
+				Progress: func(p Progress) {
+					last = p
+				},
+			})
+			if err != nil {
+				t.Fatalf("RenderContext() returned error: %v", err)
+			}
+
+			for y := 0; y < test.sizeY; y++ {
+				for x := 0; x < test.sizeX; x++ {
+					if n := film.Samples[y*test.sizeX+x]; n != 2 {
+						t.Fatalf("pixel (%v, %v) has %v samples, want 2", x, y, n)
+					}
+					if p := film.Pixel(x, y); p.X != 1 || p.Y != 1 || p.Z != 1 {
+						t.Fatalf("pixel (%v, %v) = %v, want 1, 1, 1", x, y, p)
+					}
+				}
+			}
+
+			if last.TilesCompleted != last.TilesTotal || last.Samples != int64(test.sizeX*test.sizeY*2) {
+				t.Errorf("final progress = %+v, want all tiles and samples completed", last)
+			}
+		})
+	}
+}
+
+func TestRenderContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	film := canvas.NewFilm(16, 16)
+	if err := RenderContext(ctx, makeScene(1.0), film, &Options{NumSamples: 1, NumWorkers: 2}); err != context.Canceled {
+		t.Errorf("RenderContext() = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestRenderContextUnknownSampler(t *testing.T) {
+	film := canvas.NewFilm(16, 16)
+	if err := RenderContext(context.Background(), makeScene(1.0), film, &Options{NumSamples: 1, NumWorkers: 2, Sampler: -1}); err == nil {
+		t.Error("RenderContext() = nil, want an error for the unknown sampler")
+	}
+	if err := Render(makeScene(1.0), film, &Options{NumSamples: 1, Sampler: 42}); err == nil {
+		t.Error("Render() = nil, want an error for the unknown sampler")
+	}
+	for i, n := range film.Samples {
+		if n != 0 {
+			t.Fatalf("pixel %v has %v samples, want 0", i, n)
+		}
+	}
+}
+
+func TestRenderContextCancelledMidway(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	film := canvas.NewFilm(48, 32)
+	var cancelled time.Time
+	tiles := 0
+	err := RenderContext(ctx, scenes.CornellBox(48.0/32.0), film, &Options{
+		NumSamples: 8,
+		NumWorkers: 3,
+		TileSize:   4,
+		Progress: func(p Progress) {
+			if tiles++; tiles == 10 {
+				cancelled = time.Now()
+				cancel()
+			}
+		},
+	})
+	if err != context.Canceled {
+		t.Fatalf("RenderContext() = %v, want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(cancelled); elapsed > time.Second {
+		t.Errorf("RenderContext() returned %v after the context was cancelled", elapsed)
+	}
+
+	// Workers finish the pixel they are sampling so every pixel is either complete or empty.
+	complete := 0
+	for i, n := range film.Samples {
+		switch n {
+		case 0:
+		case 8:
+			complete++
+		default:
+			t.Fatalf("pixel %v has %v samples, want 0 or 8", i, n)
+		}
+	}
+	if complete < 10*4*4 || complete == len(film.Samples) {
+		t.Errorf("%v of %v pixels were rendered, want a partial image with at least the 10 completed tiles", complete, len(film.Samples))
+	}
+}
+
+func TestRenderProgress(t *testing.T) {
+	testData := []struct {
+		name string
+		opts Options
+	}{
+		{name: "Tiles", opts: Options{NumSamples: 2, TileSize: 4}},
+		{name: "Progressive", opts: Options{NumSamples: 3, TileSize: 4, Progressive: true}},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			var running int32
+			calls := []Progress{}
+			opts := test.opts
+			opts.NumWorkers = 4
+			opts.Progress = func(p Progress) {
+				if !atomic.CompareAndSwapInt32(&running, 0, 1) {
+					t.Error("Progress was called concurrently")
+				}
+				// Give other calls a chance to overlap.
+				time.Sleep(100 * time.Microsecond)
+				calls = append(calls, p)
+				atomic.StoreInt32(&running, 0)
+			}
+			if err := Render(makeScene(18.0/10.0), canvas.NewFilm(18, 10), &opts); err != nil {
+				t.Fatalf("Render() = %v", err)
+			}
+
+			// 5x3 tiles per pass.
+			numPasses := 1
+			if opts.Progressive {
+				numPasses = opts.NumSamples
+			}
+			if len(calls) != numPasses*15 {
+				t.Fatalf("Progress was called %v times, want %v", len(calls), numPasses*15)
+			}
+			for i, p := range calls {
+				want := Progress{Pass: i/15 + 1, TilesCompleted: i%15 + 1, TilesTotal: 15}
+				if p.Pass != want.Pass || p.TilesCompleted != want.TilesCompleted || p.TilesTotal != want.TilesTotal {
+					t.Errorf("call %v = %+v, want pass %v with %v of %v tiles", i, p, want.Pass, want.TilesCompleted, want.TilesTotal)
+				}
+				if i > 0 && (p.Samples <= calls[i-1].Samples || p.Elapsed < calls[i-1].Elapsed) {
+					t.Errorf("call %v = %+v went backwards from %+v", i, p, calls[i-1])
+				}
+			}
+			if last := calls[len(calls)-1]; last.Samples != int64(18*10*opts.NumSamples) {
+				t.Errorf("final progress = %+v, want %v samples", last, 18*10*opts.NumSamples)
+			}
+		})
+	}
+}
+
+func TestRenderReproducible(t *testing.T) {
+	for _, samplerType := range []int{sampler.TypeRandom, sampler.TypeStratified, sampler.TypeHalton, sampler.TypeSobol} {
+		render := func(seed int64, numWorkers int) *canvas.Film {
+			film := canvas.NewFilm(24, 16)
+			if err := Render(scenes.CornellBox(24.0/16.0), film, &Options{
+				NumSamples: 4,
+				NumWorkers: numWorkers,
+				Seed:       seed,
+				TileSize:   5,
+				Sampler:    samplerType,
+			}); err != nil {
+				t.Fatalf("Render() = %v", err)
+			}
+			return film
+		}
+
+		want := render(42, 1)
+		for _, numWorkers := range []int{2, 7} {
+			if diff := cmp.Diff(want.Buffer, render(42, numWorkers).Buffer); diff != "" {
+				t.Errorf("Render() with sampler %v and %v workers mismatch (-want +got):\n%s", samplerType, numWorkers, diff)
+			}
+		}
+
+		if diff := cmp.Diff(want.Buffer, render(43, 1).Buffer); diff == "" {
+			t.Errorf("Render() with sampler %v and different seeds produced the same image", samplerType)
+		}
+	}
+}
+
+func TestRenderRegion(t *testing.T) {
+	testData := []struct {
+		name string
+		opts Options
+	}{
+		{name: "Tiles", opts: Options{NumSamples: 3, Seed: 5, TileSize: 4}},
+		{name: "Progressive", opts: Options{NumSamples: 3, Seed: 5, TileSize: 4, Progressive: true}},
+		{name: "Adaptive", opts: Options{NumSamples: 16, Seed: 5, TileSize: 4, Adaptive: true, MinSamples: 4, Threshold: 0.05}},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			opts := test.opts
+			opts.NumWorkers = 2
+			full := canvas.NewFilm(24, 16)
+			if err := Render(scenes.CornellBox(24.0/16.0), full, &opts); err != nil {
+				t.Fatalf("Render() = %v", err)
+			}
+
+			region := Tile{X0: 5, Y0: 3, X1: 18, Y1: 10}
+			opts.Region = &region
+			got := canvas.NewFilm(24, 16)
+			if err := Render(scenes.CornellBox(24.0/16.0), got, &opts); err != nil {
+				t.Fatalf("Render() = %v", err)
+			}
+
+			// Pixels inside the region match the full render and the others are untouched.
+			want := canvas.NewFilm(24, 16)
+			for y := region.Y0; y < region.Y1; y++ {
+				i0, i1 := y*24+region.X0, y*24+region.X1
+				copy(want.Buffer[i0*3:i1*3], full.Buffer[i0*3:i1*3])
+				copy(want.SumSquares[i0:i1], full.SumSquares[i0:i1])
+				copy(want.Samples[i0:i1], full.Samples[i0:i1])
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Render() of region mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// makeScene returns a scene where the camera sits inside an emissive sphere.
+func makeScene(aspect float64) *scene.Scene {
+	light := material.NewDiffuseLight(texture.NewConstant(&vec3.Vec3Impl{X: 1, Y: 1, Z: 1}))
+	world := hitable.NewSlice([]hitable.Hitable{
+		hitable.NewFlipNormals(hitable.NewSphere(&vec3.Vec3Impl{}, &vec3.Vec3Impl{}, 0, 1, 100, light)),
+	})
+	cam := camera.New(&vec3.Vec3Impl{}, &vec3.Vec3Impl{Z: -1}, &vec3.Vec3Impl{Y: 1}, 40, aspect, 0, 1, 0, 1)
+	return scene.New(world, nil, cam)
+}
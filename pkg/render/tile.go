@@ -0,0 +1,177 @@
+package render
+
+import "sort"
+
+const (
+	// TileOrderScanline renders tiles from left to right and top to bottom.
+	TileOrderScanline int = iota
+	// TileOrderSpiral renders tiles spiralling out from the centre of the image.
+	TileOrderSpiral
+	// TileOrderHilbert renders tiles following a Hilbert curve.
+	TileOrderHilbert
+)
+
+const (
+	defaultTileSize = 32
+)
+
+// Tile represents a rectangular region of the image. X1 and Y1 are exclusive.
+type Tile struct {
+	X0 int
+	Y0 int
+	X1 int
+	Y1 int
+}
+
+// Tiles splits an image into tiles of at most tileSize x tileSize pixels
+// and returns them in the requested order. Tiles on the right and bottom
+// edges are clipped to the image size.
+func Tiles(sizeX int, sizeY int, tileSize int, order int) []Tile {
+	if tileSize <= 0 {
+		tileSize = defaultTileSize
+	}
+
+	cols := (sizeX + tileSize - 1) / tileSize
+	rows := (sizeY + tileSize - 1) / tileSize
+
+	var cells [][2]int
+	switch order {
+	case TileOrderSpiral:
+		cells = spiralOrder(cols, rows)
+	case TileOrderHilbert:
+		cells = hilbertOrder(cols, rows)
+	default:
+		cells = scanlineOrder(cols, rows)
+	}
+
+	tiles := make([]Tile, 0, len(cells))
+	for _, c := range cells {
+		t := Tile{
+			X0: c[0] * tileSize,
+			Y0: c[1] * tileSize,
+			X1: (c[0] + 1) * tileSize,
+			Y1: (c[1] + 1) * tileSize,
+		}
+		if t.X1 > sizeX {
+			t.X1 = sizeX
+		}
+		if t.Y1 > sizeY {
+			t.Y1 = sizeY
+		}
+		tiles = append(tiles, t)
+	}
+
+	return tiles
+}
+
+func scanlineOrder(cols int, rows int) [][2]int {
+	cells := make([][2]int, 0, cols*rows)
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			cells = append(cells, [2]int{x, y})
+		}
+	}
+
+	return cells
+}
+
+// spiralOrder walks the grid in a square spiral starting from the centre cell.
+func spiralOrder(cols int, rows int) [][2]int {
+	total := cols * rows
+	cells := make([][2]int, 0, total)
+	x := (cols - 1) / 2
+	y := (rows - 1) / 2
+	// Right, down, left, up.
+	dirs := [4][2]int{{1, 0}, {0, 1}, {-1, 0}, {0, -1}}
+	dir := 0
+	for steps := 1; len(cells) < total; steps++ {
+		// Each run length is used twice before growing.
+		for run := 0; run < 2 && len(cells) < total; run++ {
+			for i := 0; i < steps; i++ {
+				if x >= 0 && x < cols && y >= 0 && y < rows {
+					cells = append(cells, [2]int{x, y})
+				}
+				x += dirs[dir][0]
+				y += dirs[dir][1]
+			}
+			dir = (dir + 1) % 4
+		}
+	}
+
+	return cells
+}
+
+// hilbertOrder sorts the cells by their distance along a Hilbert curve covering the grid.
+func hilbertOrder(cols int, rows int) [][2]int {
+	n := 1
+	for n < cols || n < rows {
+		n *= 2
+	}
+
+	cells := scanlineOrder(cols, rows)
+	sort.SliceStable(cells, func(i, j int) bool {
+		return hilbertIndex(n, cells[i][0], cells[i][1]) < hilbertIndex(n, cells[j][0], cells[j][1])
+	})
+
+	return cells
+}
+
+// hilbertIndex returns the distance of (x, y) along the Hilbert curve filling an n x n grid.
+func hilbertIndex(n int, x int, y int) int {
+	d := 0
+	for s := n / 2; s > 0; s /= 2 {
+		rx := 0
+		if x&s > 0 {
+			rx = 1
+		}
+		ry := 0
+		if y&s > 0 {
+			ry = 1
+		}
+		d += s * s * ((3 * rx) ^ ry)
+		// Rotate the quadrant.
+		if ry == 0 {
+			if rx == 1 {
+				x = n - 1 - x
+				y = n - 1 - y
+			}
+			x, y = y, x
+		}
+	}
+
+	return d
+}
+
+// intersect returns the part of the tile inside the other tile and whether they overlap.
+func (t Tile) intersect(o Tile) (Tile, bool) {
+	if o.X0 > t.X0 {
+		t.X0 = o.X0
+	}
+	if o.Y0 > t.Y0 {
+		t.Y0 = o.Y0
+	}
+	if o.X1 < t.X1 {
+		t.X1 = o.X1
+	}
+	if o.Y1 < t.Y1 {
+		t.Y1 = o.Y1
+	}
+
+	return t, t.X0 < t.X1 && t.Y0 < t.Y1
+}
+
+// hasActive returns whether the tile contains any active pixel. A nil mask means that all the pixels are active.
+func (t Tile) hasActive(sizeX int, active []bool) bool {
+	if active == nil {
+		return true
+	}
+	for y := t.Y0; y < t.Y1; y++ {
+		for x := t.X0; x < t.X1; x++ {
+			if active[y*sizeX+x] {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,89 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTiles(t *testing.T) {
+	testData := []struct {
+		name     string
+		sizeX    int
+		sizeY    int
+		tileSize int
+	}{
+		{name: "Exact multiple", sizeX: 64, sizeY: 32, tileSize: 16},
+		{name: "Odd resolution", sizeX: 37, sizeY: 23, tileSize: 8},
+		{name: "Full HD", sizeX: 1920, sizeY: 1080, tileSize: 32},
+		{name: "Tile bigger than image", sizeX: 5, sizeY: 3, tileSize: 32},
+		{name: "Single column", sizeX: 1, sizeY: 17, tileSize: 4},
+		{name: "Default tile size", sizeX: 33, sizeY: 65, tileSize: 0},
+	}
+
+	for _, test := range testData {
+		for _, order := range []int{TileOrderScanline, TileOrderSpiral, TileOrderHilbert} {
+			t.Run(test.name, func(t *testing.T) {
+				covered := make([]int, test.sizeX*test.sizeY)
+				for _, tile := range Tiles(test.sizeX, test.sizeY, test.tileSize, order) {
+					if tile.X0 < 0 || tile.Y0 < 0 || tile.X1 > test.sizeX || tile.Y1 > test.sizeY || tile.X0 >= tile.X1 || tile.Y0 >= tile.Y1 {
+						t.Fatalf("Tiles() returned invalid tile %+v for order %v", tile, order)
+					}
+					for y := tile.Y0; y < tile.Y1; y++ {
+						for x := tile.X0; x < tile.X1; x++ {
+							covered[y*test.sizeX+x]++
+						}
+					}
+				}
+
+				for i, c := range covered {
+					if c != 1 {
+						t.Fatalf("pixel (%v, %v) covered %v times for order %v", i%test.sizeX, i/test.sizeX, c, order)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestTilesOrder(t *testing.T) {
+	testData := []struct {
+		name  string
+		order int
+		want  []Tile
+	}{
+		{
+			name:  "Scanline",
+			order: TileOrderScanline,
+			want: []Tile{
+				{X0: 0, Y0: 0, X1: 2, Y1: 2}, {X0: 2, Y0: 0, X1: 3, Y1: 2},
+				{X0: 0, Y0: 2, X1: 2, Y1: 3}, {X0: 2, Y0: 2, X1: 3, Y1: 3},
+			},
+		},
+		{
+			name:  "Spiral",
+			order: TileOrderSpiral,
+			want: []Tile{
+				{X0: 0, Y0: 0, X1: 2, Y1: 2}, {X0: 2, Y0: 0, X1: 3, Y1: 2},
+				{X0: 2, Y0: 2, X1: 3, Y1: 3}, {X0: 0, Y0: 2, X1: 2, Y1: 3},
+			},
+		},
+		{
+			name:  "Hilbert",
+			order: TileOrderHilbert,
+			want: []Tile{
+				{X0: 0, Y0: 0, X1: 2, Y1: 2}, {X0: 0, Y0: 2, X1: 2, Y1: 3},
+				{X0: 2, Y0: 2, X1: 3, Y1: 3}, {X0: 2, Y0: 0, X1: 3, Y1: 2},
+			},
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			got := Tiles(3, 3, 2, test.order)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Tiles() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+// Package sampler implements the sources of sample values used while rendering.
+package sampler
+
+import "fmt"
+
+const (
+	// TypeRandom selects independent uniform random samples.
+	TypeRandom int = iota
+	// TypeStratified selects jittered stratified samples.
+	TypeStratified
+	// TypeHalton selects samples from a scrambled Halton sequence.
+	TypeHalton
+	// TypeSobol selects samples from an Owen-scrambled Sobol sequence.
+	TypeSobol
+)
+
+// Sampler defines the methods used to draw sample values.
+// Samplers are not safe for concurrent use and each worker must own its own instance.
+type Sampler interface {
+	// StartPixelSample prepares the sampler to generate the values used by the given pixel sample.
+	StartPixelSample(x int, y int, index int)
+	// Get1D returns the next sample value in the [0, 1) range.
+	Get1D() float64
+	// Get2D returns the next pair of sample values in the [0, 1) range.
+	Get2D() (float64, float64)
+}
+
+// New returns a new sampler of the given type. The number of samples per pixel
+// is used by samplers that need to know the size of the sample set in advance.
+func New(samplerType int, seed int64, samplesPerPixel int) (Sampler, error) {
+	switch samplerType {
+	case TypeRandom:
+		return NewRandom(seed), nil
+	case TypeStratified:
+		return NewStratified(seed, samplesPerPixel), nil
+	case TypeHalton:
+		return NewHalton(seed), nil
+	case TypeSobol:
+		return NewSobol(seed), nil
+	default:
+		return nil, fmt.Errorf("unsupported sampler type %v", samplerType)
+	}
+}
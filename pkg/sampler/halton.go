@@ -0,0 +1,90 @@
+package sampler
+
+import "math"
+
+// Ensure interface compliance.
+var _ Sampler = (*Halton)(nil)
+
+const (
+	// Dimensions beyond this point fall back to uniform random values.
+	maxHaltonDimension = 128
+)
+
+var primes = firstPrimes(maxHaltonDimension)
+
+// Halton implements a sampler based on the Halton sequence with random digit scrambling.
+// Each pixel uses a different scramble so that neighbouring pixels are decorrelated.
+type Halton struct {
+	seed      uint64
+	pixelSeed uint64
+	index     uint64
+	dimension int
+}
+
+// NewHalton returns a new instance of the Halton sampler.
+func NewHalton(seed int64) *Halton {
+	return &Halton{
+		seed: uint64(seed),
+	}
+}
+
+// StartPixelSample selects the point of the sequence that will be returned.
+func (h *Halton) StartPixelSample(x int, y int, index int) {
+	h.pixelSeed = hash(h.seed, uint64(x), uint64(y))
+	h.index = uint64(index)
+	h.dimension = 0
+}
+
+// Get1D returns the next dimension of the current point.
+func (h *Halton) Get1D() float64 {
+	d := h.dimension
+	h.dimension++
+	if d >= maxHaltonDimension {
+		return toFloat64(hash(h.pixelSeed, uint64(d), h.index))
+	}
+
+	return scrambledRadicalInverse(h.index, primes[d], hash(h.pixelSeed, uint64(d)))
+}
+
+// Get2D returns the next two dimensions of the current point.
+func (h *Halton) Get2D() (float64, float64) {
+	return h.Get1D(), h.Get1D()
+}
+
+// scrambledRadicalInverse mirrors the digits of index in the given base around the
+// decimal point, permuting each digit with a permutation that depends on its position.
+func scrambledRadicalInverse(index uint64, base uint64, seed uint64) float64 {
+	invBase := 1.0 / float64(base)
+	weight := 1.0
+	v := 0.0
+	// Trailing zero digits are permuted too, so keep going until they no longer matter.
+	for k := uint64(0); weight > 1e-17; k++ {
+		digit := index % base
+		index /= base
+		weight *= invBase
+		v += float64(permute(uint32(digit), uint32(base), uint32(hash(seed, k)))) * weight
+	}
+
+	return math.Min(v, oneMinusEpsilon)
+}
+
+func firstPrimes(n int) []uint64 {
+	p := []uint64{}
+	for c := uint64(2); len(p) < n; c++ {
+		isPrime := true
+		for _, q := range p {
+			if q*q > c {
+				break
+			}
+			if c%q == 0 {
+				isPrime = false
+				break
+			}
+		}
+		if isPrime {
+			p = append(p, c)
+		}
+	}
+
+	return p
+}
@@ -0,0 +1,64 @@
+package sampler
+
+const (
+	// oneMinusEpsilon is the largest float64 below 1.
+	oneMinusEpsilon = 0x1.fffffffffffffp-1
+)
+
+// mix64 is the finalizer of the SplitMix64 generator.
+func mix64(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// hash combines the supplied values into a well distributed 64-bit value.
+func hash(values ...uint64) uint64 {
+	h := uint64(0x9e3779b97f4a7c15)
+	for _, v := range values {
+		h = mix64(h ^ mix64(v+0x9e3779b97f4a7c15))
+	}
+
+	return h
+}
+
+// toFloat64 maps the upper 53 bits of v to the [0, 1) range.
+func toFloat64(v uint64) float64 {
+	return float64(v>>11) * (1.0 / (1 << 53))
+}
+
+// permute returns the element at position i of a pseudo-random permutation of [0, l)
+// selected by p. See Kensler, "Correlated Multi-Jittered Sampling".
+func permute(i uint32, l uint32, p uint32) uint32 {
+	w := l - 1
+	w |= w >> 1
+	w |= w >> 2
+	w |= w >> 4
+	w |= w >> 8
+	w |= w >> 16
+	for {
+		i ^= p
+		i *= 0xe170893d
+		i ^= p >> 16
+		i ^= (i & w) >> 4
+		i ^= p >> 8
+		i *= 0x0929eb3f
+		i ^= p >> 23
+		i ^= (i & w) >> 1
+		i *= 1 | p>>27
+		i *= 0x6935fa69
+		i ^= (i & w) >> 11
+		i *= 0x74dcb303
+		i ^= (i & w) >> 2
+		i *= 0x9e501cc3
+		i ^= (i & w) >> 2
+		i *= 0xc860a3df
+		i &= w
+		i ^= i >> 5
+		if i < l {
+			break
+		}
+	}
+
+	return (i + p) % l
+}
@@ -0,0 +1,36 @@
+package sampler
+
+// Ensure interface compliance.
+var _ Sampler = (*Random)(nil)
+
+// Random implements a sampler returning independent uniform values.
+// The sequence of each pixel sample only depends on the seed and the pixel sample coordinates.
+type Random struct {
+	seed  uint64
+	state uint64
+}
+
+// NewRandom returns a new instance of the random sampler.
+func NewRandom(seed int64) *Random {
+	r := &Random{
+		seed: uint64(seed),
+	}
+	r.StartPixelSample(0, 0, 0)
+	return r
+}
+
+// StartPixelSample reseeds the generator for the given pixel sample.
+func (r *Random) StartPixelSample(x int, y int, index int) {
+	r.state = hash(r.seed, uint64(x), uint64(y), uint64(index))
+}
+
+// Get1D returns the next value of a SplitMix64 generator.
+func (r *Random) Get1D() float64 {
+	r.state += 0x9e3779b97f4a7c15
+	return toFloat64(mix64(r.state))
+}
+
+// Get2D returns the next two values of a SplitMix64 generator.
+func (r *Random) Get2D() (float64, float64) {
+	return r.Get1D(), r.Get1D()
+}
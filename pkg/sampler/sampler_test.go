@@ -0,0 +1,104 @@
+package sampler
+
+import (
+	"testing"
+)
+
+func TestSamplersRange(t *testing.T) {
+	for _, samplerType := range []int{TypeRandom, TypeStratified, TypeHalton, TypeSobol} {
+		s, err := New(samplerType, 42, 16)
+		if err != nil {
+			t.Fatalf("New(%v): %v", samplerType, err)
+		}
+		for i := 0; i < 64; i++ {
+			s.StartPixelSample(3, 5, i)
+			// Go well past the dimensions backed by the Halton primes.
+			for d := 0; d < 200; d++ {
+				u := s.Get1D()
+				v, w := s.Get2D()
+				for _, x := range []float64{u, v, w} {
+					if x < 0 || x >= 1 {
+						t.Fatalf("sampler %v: sample %v dimension %v out of range: %v", samplerType, i, d, x)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSamplersDeterministic(t *testing.T) {
+	for _, samplerType := range []int{TypeRandom, TypeStratified, TypeHalton, TypeSobol} {
+		a, _ := New(samplerType, 7, 16)
+		b, _ := New(samplerType, 7, 16)
+		// Advance a on a different pixel first; the state must be fully reset.
+		a.StartPixelSample(9, 9, 3)
+		a.Get2D()
+		a.StartPixelSample(1, 2, 3)
+		b.StartPixelSample(1, 2, 3)
+		for d := 0; d < 10; d++ {
+			if x, y := a.Get1D(), b.Get1D(); x != y {
+				t.Fatalf("sampler %v: dimension %v differs: %v != %v", samplerType, d, x, y)
+			}
+		}
+	}
+}
+
+func TestNewUnsupported(t *testing.T) {
+	if _, err := New(-1, 0, 1); err == nil {
+		t.Error("expected an error for an unsupported sampler type")
+	}
+}
+
+func checkStratified(t *testing.T, s Sampler, dim int, n int) {
+	t.Helper()
+	seen := make([]bool, n)
+	for i := 0; i < n; i++ {
+		s.StartPixelSample(0, 0, i)
+		for d := 0; d < dim; d++ {
+			s.Get1D()
+		}
+		interval := int(s.Get1D() * float64(n))
+		if seen[interval] {
+			t.Fatalf("dimension %v: interval %v used twice", dim, interval)
+		}
+		seen[interval] = true
+	}
+}
+
+// TestStratification checks that the first n samples of each dimension fall into
+// n different intervals of size 1/n.
+func TestStratification(t *testing.T) {
+	for _, samplerType := range []int{TypeStratified, TypeSobol} {
+		s, _ := New(samplerType, 1234, 16)
+		for dim := 0; dim < 4; dim++ {
+			checkStratified(t, s, dim, 16)
+		}
+	}
+
+	// Every Halton dimension is stratified for powers of its base.
+	s := NewHalton(1234)
+	for dim, n := range []int{16, 27, 25, 49} {
+		checkStratified(t, s, dim, n)
+	}
+}
+
+// TestStratification2D checks that the first n 2D samples cover every cell of a
+// sqrt(n) x sqrt(n) grid exactly once.
+func TestStratification2D(t *testing.T) {
+	const n = 16
+	const cells = 4
+	for _, samplerType := range []int{TypeStratified, TypeSobol} {
+		s, _ := New(samplerType, 99, n)
+		seen := make([]bool, n)
+		for i := 0; i < n; i++ {
+			s.StartPixelSample(2, 1, i)
+			s.Get2D()
+			x, y := s.Get2D()
+			cell := int(y*cells)*cells + int(x*cells)
+			if seen[cell] {
+				t.Fatalf("sampler %v: cell %v used twice", samplerType, cell)
+			}
+			seen[cell] = true
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package sampler
+
+import "math/bits"
+
+// Ensure interface compliance.
+var _ Sampler = (*Sobol)(nil)
+
+// Sobol implements a sampler based on the first two dimensions of the Sobol sequence
+// with hash based Owen scrambling. Further dimensions are obtained by padding: every
+// pair of dimensions uses an independently shuffled and scrambled copy of the sequence.
+// See Burley, "Practical Hash-based Owen Scrambling".
+type Sobol struct {
+	seed      uint64
+	pixelSeed uint64
+	index     uint32
+	dimension int
+}
+
+// NewSobol returns a new instance of the Sobol sampler.
+func NewSobol(seed int64) *Sobol {
+	return &Sobol{
+		seed: uint64(seed),
+	}
+}
+
+// StartPixelSample selects the point of the sequence that will be returned.
+func (s *Sobol) StartPixelSample(x int, y int, index int) {
+	s.pixelSeed = hash(s.seed, uint64(x), uint64(y))
+	s.index = uint32(index)
+	s.dimension = 0
+}
+
+// Get1D returns the next dimension of the current point.
+func (s *Sobol) Get1D() float64 {
+	seed := s.nextSeed()
+	i := nestedUniformScramble(s.index, uint32(seed))
+	return toUnit(nestedUniformScramble(sobol0(i), uint32(seed>>32)))
+}
+
+// Get2D returns the next pair of dimensions of the current point.
+func (s *Sobol) Get2D() (float64, float64) {
+	seed := s.nextSeed()
+	i := nestedUniformScramble(s.index, uint32(seed))
+	x := nestedUniformScramble(sobol0(i), uint32(seed>>32))
+	y := nestedUniformScramble(sobol1(i), uint32(mix64(seed)))
+	return toUnit(x), toUnit(y)
+}
+
+func (s *Sobol) nextSeed() uint64 {
+	d := s.dimension
+	s.dimension++
+	return hash(s.pixelSeed, uint64(d))
+}
+
+// sobol0 returns the first dimension of the Sobol sequence, i.e. the van der Corput sequence.
+func sobol0(index uint32) uint32 {
+	return bits.Reverse32(index)
+}
+
+// sobol1 returns the second dimension of the Sobol sequence.
+func sobol1(index uint32) uint32 {
+	result := uint32(0)
+	v := uint32(1) << 31
+	for ; index != 0; index >>= 1 {
+		if index&1 != 0 {
+			result ^= v
+		}
+		v ^= v >> 1
+	}
+
+	return result
+}
+
+func laineKarrasPermutation(x uint32, seed uint32) uint32 {
+	x += seed
+	x ^= x * 0x6c50b47c
+	x ^= x * 0xb82f1e52
+	x ^= x * 0xc7afe638
+	x ^= x * 0x8d22f6e6
+	return x
+}
+
+// nestedUniformScramble performs an Owen scramble of x where every bit is flipped
+// depending on the value of the bits above it.
+func nestedUniformScramble(x uint32, seed uint32) uint32 {
+	x = bits.Reverse32(x)
+	x = laineKarrasPermutation(x, seed)
+	return bits.Reverse32(x)
+}
+
+func toUnit(x uint32) float64 {
+	return float64(x) / (1 << 32)
+}
@@ -0,0 +1,77 @@
+package sampler
+
+import "math"
+
+// Ensure interface compliance.
+var _ Sampler = (*Stratified)(nil)
+
+// Stratified implements a jittered stratified sampler. Every dimension is split into
+// as many strata as samples per pixel and each pixel sample is assigned a different
+// stratum through a per-dimension random permutation.
+type Stratified struct {
+	seed            uint64
+	samplesPerPixel int
+	xStrata         int
+	yStrata         int
+	pixelSeed       uint64
+	index           int
+	dimension       int
+}
+
+// NewStratified returns a new instance of the stratified sampler.
+func NewStratified(seed int64, samplesPerPixel int) *Stratified {
+	if samplesPerPixel < 1 {
+		samplesPerPixel = 1
+	}
+	xStrata := int(math.Ceil(math.Sqrt(float64(samplesPerPixel))))
+	yStrata := (samplesPerPixel + xStrata - 1) / xStrata
+
+	return &Stratified{
+		seed:            uint64(seed),
+		samplesPerPixel: samplesPerPixel,
+		xStrata:         xStrata,
+		yStrata:         yStrata,
+	}
+}
+
+// StartPixelSample selects the pixel sample whose strata will be returned.
+func (s *Stratified) StartPixelSample(x int, y int, index int) {
+	s.pixelSeed = hash(s.seed, uint64(x), uint64(y))
+	s.index = index
+	s.dimension = 0
+}
+
+// Get1D returns a jittered value from the stratum assigned to this pixel sample.
+func (s *Stratified) Get1D() float64 {
+	d := s.nextDimension()
+	jitter := toFloat64(hash(s.pixelSeed, d, uint64(s.index)))
+	if s.index >= s.samplesPerPixel {
+		return jitter
+	}
+
+	stratum := permute(uint32(s.index), uint32(s.samplesPerPixel), uint32(hash(s.pixelSeed, d)))
+	return math.Min((float64(stratum)+jitter)/float64(s.samplesPerPixel), oneMinusEpsilon)
+}
+
+// Get2D returns a jittered point from the 2D stratum assigned to this pixel sample.
+func (s *Stratified) Get2D() (float64, float64) {
+	d := s.nextDimension()
+	h := hash(s.pixelSeed, d, uint64(s.index))
+	jx := toFloat64(h)
+	jy := toFloat64(mix64(h))
+	numStrata := s.xStrata * s.yStrata
+	if s.index >= numStrata {
+		return jx, jy
+	}
+
+	stratum := int(permute(uint32(s.index), uint32(numStrata), uint32(hash(s.pixelSeed, d))))
+	x := (float64(stratum%s.xStrata) + jx) / float64(s.xStrata)
+	y := (float64(stratum/s.xStrata) + jy) / float64(s.yStrata)
+	return math.Min(x, oneMinusEpsilon), math.Min(y, oneMinusEpsilon)
+}
+
+func (s *Stratified) nextDimension() uint64 {
+	d := s.dimension
+	s.dimension++
+	return uint64(d)
+}
@@ -0,0 +1,43 @@
+// Package scene implements the scene type consumed by the renderer.
+package scene
+
+import (
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/camera"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitable"
+)
+
+// Scene bundles the world geometry, the camera and the geometry used as importance sampling targets.
+type Scene struct {
+	world  *hitable.HitableSlice
+	lights *hitable.HitableSlice
+	camera *camera.Camera
+}
+
+// New returns a new scene. The lights slice contains the hitables that will be used as
+// importance sampling targets and can be empty.
+func New(world *hitable.HitableSlice, lights *hitable.HitableSlice, cam *camera.Camera) *Scene {
+	if lights == nil {
+		lights = hitable.NewSlice(nil)
+	}
+
+	return &Scene{
+		world:  world,
+		lights: lights,
+		camera: cam,
+	}
+}
+
+// World returns the geometry of this scene.
+func (s *Scene) World() *hitable.HitableSlice {
+	return s.world
+}
+
+// Lights returns the hitables used as importance sampling targets.
+func (s *Scene) Lights() *hitable.HitableSlice {
+	return s.lights
+}
+
+// Camera returns the camera associated with this scene.
+func (s *Scene) Camera() *camera.Camera {
+	return s.camera
+}
@@ -0,0 +1,297 @@
+package scenefile
+
+import (
+	"fmt"
+	"image"
+	// Register the image formats supported by image textures.
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/camera"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitable"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/loader"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scene"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/texture"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// builder creates the objects described by a document. Textures and materials are only
+// created once so that the hitables that reference them share the same instance.
+type builder struct {
+	doc       *Document
+	textures  map[string]texture.Texture
+	materials map[string]material.Material
+}
+
+// Scene builds the scene described by the document using the given aspect ratio.
+// The emissive parts of the models in the world, including the ones nested in transforms
+// and BVHs, are added to the importance sampling targets.
+func (d *Document) Scene(aspect float64) (*scene.Scene, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+
+	b := &builder{
+		doc:       d,
+		textures:  make(map[string]texture.Texture),
+		materials: make(map[string]material.Material),
+	}
+
+	world := []hitable.Hitable{}
+	lights := []hitable.Hitable{}
+	for i, h := range d.World {
+		hh, modelLights, err := b.hitable(h, fmt.Sprintf("world[%v]", i))
+		if err != nil {
+			return nil, err
+		}
+		world = append(world, hh)
+		lights = append(lights, modelLights...)
+	}
+
+	for i, h := range d.Lights {
+		hh, _, err := b.hitable(h, fmt.Sprintf("lights[%v]", i))
+		if err != nil {
+			return nil, err
+		}
+		lights = append(lights, hh)
+	}
+
+	c := d.Camera
+	time0, time1 := interval(c.Time)
+	cam := camera.New(c.LookFrom.vec(), c.LookAt.vec(), c.VUp.vec(), c.VFov, aspect, c.Aperture, c.FocusDist, time0, time1)
+
+	return scene.New(hitable.NewSlice(world), hitable.NewSlice(lights), cam), nil
+}
+
+// resolve returns the path of a file referenced by the document.
+func (b *builder) resolve(fileName string) string {
+	if filepath.IsAbs(fileName) || b.doc.dir == "" {
+		return fileName
+	}
+
+	return filepath.Join(b.doc.dir, fileName)
+}
+
+func (b *builder) texture(name string) (texture.Texture, error) {
+	if t, ok := b.textures[name]; ok {
+		return t, nil
+	}
+
+	desc := b.doc.Textures[name]
+	var t texture.Texture
+	switch desc.Type {
+	case "constant":
+		t = texture.NewConstant(desc.Color.vec())
+	case "checker":
+		odd, err := b.texture(desc.Odd)
+		if err != nil {
+			return nil, err
+		}
+		even, err := b.texture(desc.Even)
+		if err != nil {
+			return nil, err
+		}
+		t = texture.NewChecker(odd, even)
+	case "noise":
+		t = texture.NewNoise(desc.Scale)
+	case "image":
+		img, err := loadImage(b.resolve(desc.File))
+		if err != nil {
+			return nil, &FieldError{Path: "textures." + name + ".file", Err: err}
+		}
+		t = img
+	}
+
+	b.textures[name] = t
+	return t, nil
+}
+
+func loadImage(fileName string) (*texture.ImageTxt, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return texture.NewFromImage(img), nil
+}
+
+// albedo returns a constant texture for the color or the named texture.
+func (b *builder) albedo(color *Vec3, name string) (texture.Texture, error) {
+	if color != nil {
+		return texture.NewConstant(color.vec()), nil
+	}
+
+	return b.texture(name)
+}
+
+// material returns the named material or nil if the name is empty.
+func (b *builder) material(name string) (material.Material, error) {
+	if name == "" {
+		return nil, nil
+	}
+	if m, ok := b.materials[name]; ok {
+		return m, nil
+	}
+
+	desc := b.doc.Materials[name]
+	var m material.Material
+	switch desc.Type {
+	case "lambertian", "diffuse_light", "isotropic":
+		albedo, err := b.albedo(desc.Color, desc.Texture)
+		if err != nil {
+			return nil, err
+		}
+		switch desc.Type {
+		case "lambertian":
+			m = material.NewLambertian(albedo)
+		case "diffuse_light":
+			m = material.NewDiffuseLight(albedo)
+		case "isotropic":
+			m = material.NewIsotropic(albedo)
+		}
+	case "metal":
+		m = material.NewMetal(desc.Color.vec(), desc.Fuzz)
+	case "dielectric":
+		m = material.NewDielectric(desc.RefIdx)
+	}
+
+	b.materials[name] = m
+	return m, nil
+}
+
+func (b *builder) model(h *Hitable, path string) (*loader.Model, error) {
+	fileName := b.resolve(h.File)
+	format, _ := modelFormat(fileName)
+
+	var model *loader.Model
+	var err error
+	switch format {
+	case formatOBJ:
+		model, err = loader.LoadOBJ(fileName)
+	case formatPLY:
+		var mat material.Material
+		if mat, err = b.material(h.Material); err == nil {
+			model, err = loader.LoadPLY(fileName, mat)
+		}
+	case formatGLTF:
+		model, err = loader.LoadGLTF(fileName)
+	}
+	if err != nil {
+		return nil, &FieldError{Path: path + ".file", Err: err}
+	}
+
+	return model, nil
+}
+
+// hitable returns the hitable described by h and the emissive parts of the models it contains.
+// The emissive parts go through the same transforms as the models so that they can be used as
+// importance sampling targets.
+func (b *builder) hitable(h *Hitable, path string) (hitable.Hitable, []hitable.Hitable, error) {
+	mat, err := b.material(h.Material)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch h.Type {
+	case "sphere":
+		center1 := h.Center
+		if h.Center1 != nil {
+			center1 = h.Center1
+		}
+		time0, time1 := interval(h.Time)
+		return hitable.NewSphere(h.Center.vec(), center1.vec(), time0, time1, h.Radius, mat), nil, nil
+
+	case "xy_rect":
+		return hitable.NewXYRect(h.X0, h.X1, h.Y0, h.Y1, h.K, mat), nil, nil
+
+	case "xz_rect":
+		return hitable.NewXZRect(h.X0, h.X1, h.Z0, h.Z1, h.K, mat), nil, nil
+
+	case "yz_rect":
+		return hitable.NewYZRect(h.Y0, h.Y1, h.Z0, h.Z1, h.K, mat), nil, nil
+
+	case "box":
+		return hitable.NewBox(h.Min.vec(), h.Max.vec(), mat), nil, nil
+
+	case "triangle":
+		return hitable.NewTriangle(h.Vertices[0].vec(), h.Vertices[1].vec(), h.Vertices[2].vec(), mat), nil, nil
+
+	case "mesh":
+		vertices := make([]*vec3.Vec3Impl, len(h.Vertices))
+		for i, v := range h.Vertices {
+			vertices[i] = v.vec()
+		}
+		var normals, uvs []*vec3.Vec3Impl
+		for _, n := range h.Normals {
+			normals = append(normals, n.vec())
+		}
+		for _, uv := range h.UVs {
+			uvs = append(uvs, &vec3.Vec3Impl{X: uv[0], Y: uv[1]})
+		}
+		tm, err := hitable.NewTriangleMesh(vertices, normals, uvs, h.Indices, mat)
+		if err != nil {
+			return nil, nil, &FieldError{Path: path, Err: err}
+		}
+		return tm, nil, nil
+
+	case "model":
+		model, err := b.model(h, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return model.Hitable(), model.Lights(), nil
+
+	case "bvh":
+		children := make([]hitable.Hitable, len(h.Hitables))
+		lights := []hitable.Hitable{}
+		for i, child := range h.Hitables {
+			var childLights []hitable.Hitable
+			if children[i], childLights, err = b.hitable(child, fmt.Sprintf("%v.hitables[%v]", path, i)); err != nil {
+				return nil, nil, err
+			}
+			lights = append(lights, childLights...)
+		}
+		time0, time1 := interval(h.Time)
+		return hitable.NewBVH(children, time0, time1), lights, nil
+	}
+
+	// The remaining types wrap another hitable.
+	child, lights, err := b.hitable(h.Hitable, path+".hitable")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var wrap func(hitable.Hitable) hitable.Hitable
+	switch h.Type {
+	case "flip_normals":
+		wrap = func(hh hitable.Hitable) hitable.Hitable { return hitable.NewFlipNormals(hh) }
+	case "translate":
+		wrap = func(hh hitable.Hitable) hitable.Hitable { return hitable.NewTranslate(hh, h.Offset.vec()) }
+	case "rotate_y":
+		wrap = func(hh hitable.Hitable) hitable.Hitable { return hitable.NewRotateY(hh, h.Angle) }
+	case "constant_medium":
+		albedo, err := b.albedo(h.Color, h.Texture)
+		if err != nil {
+			return nil, nil, err
+		}
+		// The boundary of a medium does not emit light.
+		return hitable.NewConstantMedium(child, h.Density, albedo), nil, nil
+	default:
+		return nil, nil, fieldErrorf(path+".type", "unknown hitable type %q", h.Type)
+	}
+
+	for i, light := range lights {
+		lights[i] = wrap(light)
+	}
+
+	return wrap(child), lights, nil
+}
@@ -0,0 +1,170 @@
+// Package scenefile implements a JSON scene description format.
+//
+// A document describes the camera, optional render settings, named textures and
+// materials and the hitables that make up the world and the importance sampling
+// targets. Vectors and colors are arrays of three numbers:
+//
+//	{
+//	  "camera": {"lookFrom": [278, 278, -800], "lookAt": [278, 278, 0], "vup": [0, 1, 0], "vfov": 40, "focusDist": 10},
+//	  "render": {"width": 500, "height": 500, "samples": 100, "integrator": "mis", "sampler": "sobol"},
+//	  "textures": {"earth": {"type": "image", "file": "earth.png"}},
+//	  "materials": {
+//	    "white": {"type": "lambertian", "color": [0.73, 0.73, 0.73]},
+//	    "earth": {"type": "lambertian", "texture": "earth"},
+//	    "light": {"type": "diffuse_light", "color": [15, 15, 15]}
+//	  },
+//	  "world": [
+//	    {"type": "flip_normals", "hitable": {"type": "xz_rect", "x0": 213, "x1": 343, "z0": 227, "z1": 332, "k": 554, "material": "light"}},
+//	    {"type": "sphere", "center": [190, 90, 190], "radius": 90, "material": "earth"},
+//	    {"type": "translate", "offset": [265, 0, 295], "hitable": {"type": "rotate_y", "angle": 15,
+//	      "hitable": {"type": "box", "min": [0, 0, 0], "max": [165, 330, 165], "material": "white"}}}
+//	  ],
+//	  "lights": [{"type": "xz_rect", "x0": 213, "x1": 343, "z0": 227, "z1": 332, "k": 554}]
+//	}
+//
+// Texture types are constant (color), checker (odd and even texture names), noise (scale)
+// and image (file). Material types are lambertian, diffuse_light and isotropic (color or
+// texture), metal (color and fuzz) and dielectric (refIdx).
+//
+// Hitable types are sphere (center, radius and, for moving spheres, center1), xy_rect,
+// xz_rect and yz_rect (two of the x0/x1, y0/y1 and z0/z1 ranges and k), box (min and max),
+// triangle (vertices), mesh (vertices, indices and optional normals and uvs), model (an OBJ,
+// PLY or glTF file), flip_normals, translate (offset), rotate_y (angle), constant_medium
+// (density and color or texture) wrapping another hitable and bvh (hitables).
+// Primitives in the world must reference a material while importance sampling targets
+// and the boundaries of media do not need one. The emissive parts of the models in the
+// world, including the ones nested in flip_normals, translate, rotate_y and bvh, are added
+// to the importance sampling targets with the same transforms. The time interval of cameras,
+// moving spheres and BVHs defaults to [0, 1].
+//
+// File references are relative to the directory of the document.
+package scenefile
+
+import "github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+
+// Vec3 is a vector or a color.
+type Vec3 [3]float64
+
+// Interval is a time interval.
+type Interval [2]float64
+
+// Document represents a scene description.
+type Document struct {
+	Camera    Camera               `json:"camera"`
+	Render    *Render              `json:"render,omitempty"`
+	Textures  map[string]*Texture  `json:"textures,omitempty"`
+	Materials map[string]*Material `json:"materials,omitempty"`
+	World     []*Hitable           `json:"world"`
+	Lights    []*Hitable           `json:"lights,omitempty"`
+	// dir is the directory file references are relative to.
+	dir string
+}
+
+// Camera describes the camera parameters.
+type Camera struct {
+	LookFrom  Vec3      `json:"lookFrom"`
+	LookAt    Vec3      `json:"lookAt"`
+	VUp       Vec3      `json:"vup"`
+	VFov      float64   `json:"vfov"`
+	Aperture  float64   `json:"aperture,omitempty"`
+	FocusDist float64   `json:"focusDist"`
+	Time      *Interval `json:"time,omitempty"`
+}
+
+// Render holds the render settings stored with the scene. Zero values mean that the
+// renderer defaults are used.
+type Render struct {
+	Width      int    `json:"width,omitempty"`
+	Height     int    `json:"height,omitempty"`
+	Samples    int    `json:"samples,omitempty"`
+	MaxDepth   int    `json:"maxDepth,omitempty"`
+	Integrator string `json:"integrator,omitempty"`
+	Sampler    string `json:"sampler,omitempty"`
+	Seed       int64  `json:"seed,omitempty"`
+}
+
+// Texture describes a texture.
+type Texture struct {
+	Type  string  `json:"type"`
+	Color *Vec3   `json:"color,omitempty"`
+	Odd   string  `json:"odd,omitempty"`
+	Even  string  `json:"even,omitempty"`
+	Scale float64 `json:"scale,omitempty"`
+	File  string  `json:"file,omitempty"`
+}
+
+// Material describes a material.
+type Material struct {
+	Type    string  `json:"type"`
+	Color   *Vec3   `json:"color,omitempty"`
+	Texture string  `json:"texture,omitempty"`
+	Fuzz    float64 `json:"fuzz,omitempty"`
+	RefIdx  float64 `json:"refIdx,omitempty"`
+}
+
+// Hitable describes a hitable. Only the fields used by its type are set.
+type Hitable struct {
+	Type     string `json:"type"`
+	Material string `json:"material,omitempty"`
+	// Spheres.
+	Center  *Vec3     `json:"center,omitempty"`
+	Center1 *Vec3     `json:"center1,omitempty"`
+	Radius  float64   `json:"radius,omitempty"`
+	Time    *Interval `json:"time,omitempty"`
+	// Rectangles.
+	X0 float64 `json:"x0,omitempty"`
+	X1 float64 `json:"x1,omitempty"`
+	Y0 float64 `json:"y0,omitempty"`
+	Y1 float64 `json:"y1,omitempty"`
+	Z0 float64 `json:"z0,omitempty"`
+	Z1 float64 `json:"z1,omitempty"`
+	K  float64 `json:"k,omitempty"`
+	// Boxes.
+	Min *Vec3 `json:"min,omitempty"`
+	Max *Vec3 `json:"max,omitempty"`
+	// Triangles and meshes.
+	Vertices []Vec3       `json:"vertices,omitempty"`
+	Normals  []Vec3       `json:"normals,omitempty"`
+	UVs      [][2]float64 `json:"uvs,omitempty"`
+	Indices  []int        `json:"indices,omitempty"`
+	// Models.
+	File string `json:"file,omitempty"`
+	// Transformations and media.
+	Hitable *Hitable `json:"hitable,omitempty"`
+	Offset  *Vec3    `json:"offset,omitempty"`
+	Angle   float64  `json:"angle,omitempty"`
+	Density float64  `json:"density,omitempty"`
+	Color   *Vec3    `json:"color,omitempty"`
+	Texture string   `json:"texture,omitempty"`
+	// BVHs.
+	Hitables []*Hitable `json:"hitables,omitempty"`
+}
+
+func (v Vec3) vec() *vec3.Vec3Impl {
+	return &vec3.Vec3Impl{X: v[0], Y: v[1], Z: v[2]}
+}
+
+// interval returns the time interval or [0, 1] if it is not set.
+func interval(i *Interval) (float64, float64) {
+	if i == nil {
+		return 0, 1
+	}
+
+	return i[0], i[1]
+}
+
+// TextureTypes returns the texture types that documents can use.
+func TextureTypes() []string {
+	return []string{"constant", "checker", "noise", "image"}
+}
+
+// MaterialTypes returns the material types that documents can use.
+func MaterialTypes() []string {
+	return []string{"lambertian", "metal", "dielectric", "diffuse_light", "isotropic"}
+}
+
+// HitableTypes returns the hitable types that documents can use.
+func HitableTypes() []string {
+	return []string{"sphere", "xy_rect", "xz_rect", "yz_rect", "box", "triangle", "mesh", "model",
+		"flip_normals", "translate", "rotate_y", "constant_medium", "bvh"}
+}
@@ -0,0 +1,104 @@
+package scenefile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Load reads the scene description stored in fileName. File references are resolved
+// relative to the directory that contains it.
+func Load(fileName string) (*Document, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	d, err := Read(f, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	d.dir = filepath.Dir(fileName)
+	return d, nil
+}
+
+// Read decodes and validates a scene description. The name is used in error messages and
+// file references are resolved relative to the working directory.
+func Read(r io.Reader, name string) (*Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	d := &Document{}
+	if err := dec.Decode(d); err != nil {
+		offset := dec.InputOffset()
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			// The offset is just past the offending character.
+			offset = syntaxErr.Offset - 1
+		case errors.As(err, &typeErr):
+			offset = typeErr.Offset
+		case strings.HasPrefix(err.Error(), unknownField):
+			// The decoder does not report where unknown fields are so point at the first use of the name.
+			offset = fieldOffset(data, strings.TrimPrefix(err.Error(), unknownField), offset)
+		}
+		return nil, fmt.Errorf("%v:%v: %v", name, position(data, offset), err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("%v:%v: unexpected data after the scene description", name, position(data, dec.InputOffset()))
+	}
+
+	if err := d.Validate(); err != nil {
+		return nil, fmt.Errorf("%v: %w", name, err)
+	}
+
+	return d, nil
+}
+
+// unknownField is the prefix of the errors returned for fields that are not part of the format.
+const unknownField = "json: unknown field "
+
+// fieldOffset returns the offset of the first object key with the given quoted name.
+func fieldOffset(data []byte, quoted string, fallback int64) int64 {
+	re, err := regexp.Compile(regexp.QuoteMeta(quoted) + `\s*:`)
+	if err != nil {
+		return fallback
+	}
+	if loc := re.FindIndex(data); loc != nil {
+		return int64(loc[0])
+	}
+
+	return fallback
+}
+
+// position returns the line and column of the given byte offset.
+func position(data []byte, offset int64) string {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+
+	line, column := 1, 1
+	for _, b := range data[:offset] {
+		if b == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+
+	return fmt.Sprintf("%v:%v", line, column)
+}
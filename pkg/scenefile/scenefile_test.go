@@ -0,0 +1,243 @@
+package scenefile_test
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/canvas"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/render"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scenefile"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scenes"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestRoundTrip(t *testing.T) {
+	rand.Seed(0)
+	testData := []struct {
+		name string
+		doc  *scenefile.Document
+	}{
+		{name: "random scene", doc: scenes.RandomSceneDocument()},
+		{name: "two spheres", doc: scenes.TwoSpheresDocument()},
+		{name: "two Perlin spheres", doc: scenes.TwoPerlinSpheresDocument()},
+		{name: "texture mapped sphere", doc: scenes.TextureMappedSphereDocument()},
+		{name: "simple light", doc: scenes.SimpleLightDocument()},
+		{name: "Cornell box", doc: scenes.CornellBoxDocument()},
+		{name: "final", doc: scenes.FinalDocument()},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := scenefile.Write(buf, test.doc); err != nil {
+				t.Fatalf("Write() = %v", err)
+			}
+			got, err := scenefile.Read(buf, test.name)
+			if err != nil {
+				t.Fatalf("Read() = %v", err)
+			}
+			if diff := cmp.Diff(test.doc, got, cmpopts.IgnoreUnexported(scenefile.Document{})); diff != "" {
+				t.Errorf("Read() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadCornellBox(t *testing.T) {
+	d, err := scenefile.Load("testdata/cornell_box.json")
+	if err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+	if diff := cmp.Diff(scenes.CornellBoxDocument(), d, cmpopts.IgnoreUnexported(scenefile.Document{}), cmpopts.IgnoreFields(scenefile.Document{}, "Render")); diff != "" {
+		t.Errorf("Load() mismatch (-want +got):\n%s", diff)
+	}
+
+	r := d.Render
+	aspect := float64(r.Width) / float64(r.Height)
+	s, err := d.Scene(aspect)
+	if err != nil {
+		t.Fatalf("Scene() = %v", err)
+	}
+	opts := &render.Options{NumSamples: r.Samples, NumWorkers: 2, Seed: r.Seed}
+	got := canvas.NewFilm(r.Width, r.Height)
+	if err := render.Render(s, got, opts); err != nil {
+		t.Fatalf("Render() = %v", err)
+	}
+	want := canvas.NewFilm(r.Width, r.Height)
+	if err := render.Render(scenes.CornellBox(aspect), want, opts); err != nil {
+		t.Fatalf("Render() = %v", err)
+	}
+
+	if diff := cmp.Diff(want.Buffer, got.Buffer); diff != "" {
+		t.Errorf("Render() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestModel(t *testing.T) {
+	dir := t.TempDir()
+	obj := `mtllib lamp.mtl
+v 0 0 0
+v 1 0 0
+v 0 1 0
+usemtl lamp
+f 1 2 3
+`
+	mtl := `newmtl lamp
+Ke 4 4 4
+`
+	scene := `{
+  "camera": {"lookFrom": [0, 0, 5], "lookAt": [0, 0, 0], "vup": [0, 1, 0], "vfov": 40, "focusDist": 10},
+  "world": [{"type": "model", "file": "lamp.obj"}]
+}`
+	for name, data := range map[string]string{"lamp.obj": obj, "lamp.mtl": mtl, "scene.json": scene} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d, err := scenefile.Load(filepath.Join(dir, "scene.json"))
+	if err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+	s, err := d.Scene(1)
+	if err != nil {
+		t.Fatalf("Scene() = %v", err)
+	}
+	if got := s.Lights().Len(); got != 1 {
+		t.Errorf("Scene() has %v lights, want 1", got)
+	}
+
+	// The emissive parts of nested models go through the same transforms as the models.
+	translated := &scenefile.Hitable{Type: "translate", Offset: &scenefile.Vec3{5, 0, 0}, Hitable: d.World[0]}
+	d.World = []*scenefile.Hitable{{Type: "bvh", Hitables: []*scenefile.Hitable{translated}}}
+	s, err = d.Scene(1)
+	if err != nil {
+		t.Fatalf("Scene() = %v", err)
+	}
+	if got := s.Lights().Len(); got != 1 {
+		t.Fatalf("Scene() has %v lights, want 1", got)
+	}
+	o := &vec3.Vec3Impl{X: 0.25, Y: 0.25, Z: 5}
+	if pdf := s.Lights().PDFValue(o, &vec3.Vec3Impl{X: 5, Z: -5}, 0); pdf <= 0 {
+		t.Errorf("PDFValue() towards the translated lamp = %v, want a positive value", pdf)
+	}
+	if pdf := s.Lights().PDFValue(o, &vec3.Vec3Impl{Z: -5}, 0); pdf != 0 {
+		t.Errorf("PDFValue() towards the original lamp = %v, want 0", pdf)
+	}
+	d.World = []*scenefile.Hitable{translated.Hitable}
+
+	// Files are resolved relative to the document.
+	d.World[0].File = "missing.obj"
+	_, err = d.Scene(1)
+	var fe *scenefile.FieldError
+	if !errors.As(err, &fe) || fe.Path != "world[0].file" {
+		t.Errorf("Scene() = %v, want an error for world[0].file", err)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	const camera = `"camera": {"lookFrom": [0, 0, 5], "lookAt": [0, 0, 0], "vup": [0, 1, 0], "vfov": 40, "focusDist": 10}`
+	const sphere = `{"type": "sphere", "center": [0, 0, 0], "radius": 1, "material": "white"}`
+	const white = `"materials": {"white": {"type": "lambertian", "color": [1, 1, 1]}}`
+
+	testData := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "syntax error",
+			input: "{\n  " + camera + ",\n  \"world\": [}\n}",
+			want:  "test.json:3:13: invalid character '}' looking for beginning of value",
+		},
+		{
+			name:  "unknown field",
+			input: "{\n  " + camera + ",\n  " + white + ",\n  \"world\": [{\"type\": \"sphere\", \"centre\": [0, 0, 0]}]\n}",
+			want:  `test.json:4:32: json: unknown field "centre"`,
+		},
+		{
+			name:  "wrong type",
+			input: "{\n  " + camera + ",\n  \"world\": [{\"type\": \"sphere\", \"radius\": \"1\"}]\n}",
+			want:  "test.json:3:45: json: cannot unmarshal string into Go struct field",
+		},
+		{
+			name:  "trailing data",
+			input: "{\n  " + camera + ",\n  " + white + ",\n  \"world\": [" + sphere + "]\n}\n{}",
+			want:  "test.json:6:1: unexpected data after the scene description",
+		},
+		{
+			name:  "empty world",
+			input: "{" + camera + ", \"world\": []}",
+			want:  "test.json: world: the world is empty",
+		},
+		{
+			name:  "camera",
+			input: `{"camera": {"lookFrom": [0, 0, 5], "vup": [0, 1, 0], "vfov": 180, "focusDist": 10}, "world": [` + sphere + `]}`,
+			want:  "test.json: camera.vfov: must be between 0 and 180 degrees, got 180",
+		},
+		{
+			name:  "render settings",
+			input: "{" + camera + `, "render": {"sampler": "uniform"}, ` + white + `, "world": [` + sphere + `]}`,
+			want:  `test.json: render.sampler: unknown sampler "uniform"`,
+		},
+		{
+			name:  "texture cycle",
+			input: "{" + camera + `, "textures": {"a": {"type": "checker", "odd": "a", "even": "a"}}, ` + white + `, "world": [` + sphere + `]}`,
+			want:  `test.json: textures.a.odd: texture "a" references itself`,
+		},
+		{
+			name:  "unknown material",
+			input: "{" + camera + `, "world": [` + sphere + `]}`,
+			want:  `test.json: world[0].material: unknown material "white"`,
+		},
+		{
+			name:  "missing material",
+			input: "{" + camera + `, "world": [{"type": "bvh", "hitables": [{"type": "translate", "offset": [1, 0, 0], "hitable": {"type": "box", "min": [0, 0, 0], "max": [1, 1, 1]}}]}]}`,
+			want:  "test.json: world[0].hitables[0].hitable.material: missing material",
+		},
+		{
+			name:  "rectangle",
+			input: "{" + camera + ", " + white + `, "world": [{"type": "flip_normals", "hitable": {"type": "xz_rect", "x1": 1, "z0": 1, "material": "white"}}]}`,
+			want:  "test.json: world[0].hitable.z1: must be greater than z0, got 0",
+		},
+		{
+			name:  "mesh",
+			input: "{" + camera + ", " + white + `, "world": [{"type": "mesh", "vertices": [[0, 0, 0], [1, 0, 0], [0, 1, 0]], "indices": [0, 1, 3], "material": "white"}]}`,
+			want:  "test.json: world[0].indices[2]: index 3 is out of range",
+		},
+		{
+			name:  "model",
+			input: "{" + camera + `, "world": [{"type": "model", "file": "teapot.stl"}]}`,
+			want:  "test.json: world[0].file: unsupported model format, expected .obj, .ply, .gltf or .glb",
+		},
+		{
+			name:  "medium",
+			input: "{" + camera + `, "world": [{"type": "constant_medium", "density": 0.1, "hitable": {"type": "sphere", "center": [0, 0, 0], "radius": 1}}]}`,
+			want:  "test.json: world[0]: missing color or texture",
+		},
+		{
+			name:  "light",
+			input: "{" + camera + ", " + white + `, "world": [` + sphere + `], "lights": [{"type": "disc"}]}`,
+			want:  `test.json: lights[0].type: unknown hitable type "disc"`,
+		},
+	}
+
+	for _, test := range testData {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := scenefile.Read(strings.NewReader(test.input), "test.json")
+			if err == nil {
+				t.Fatalf("Read() = nil, want %q", test.want)
+			}
+			if !strings.HasPrefix(err.Error(), test.want) {
+				t.Errorf("Read() = %q, want %q", err, test.want)
+			}
+		})
+	}
+}
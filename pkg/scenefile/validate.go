@@ -0,0 +1,359 @@
+package scenefile
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// The integrators and samplers that render settings can select.
+var (
+	integrators = map[string]bool{"path": true, "mis": true, "direct": true, "ao": true, "normals": true}
+	samplers    = map[string]bool{"random": true, "stratified": true, "halton": true, "sobol": true}
+)
+
+// FieldError describes an invalid value. Path points at the value in the document using
+// the JSON field names, for example world[3].hitable.radius.
+type FieldError struct {
+	Path string
+	Err  error
+}
+
+func (fe *FieldError) Error() string {
+	return fmt.Sprintf("%v: %v", fe.Path, fe.Err)
+}
+
+func (fe *FieldError) Unwrap() error {
+	return fe.Err
+}
+
+func fieldErrorf(path string, format string, args ...interface{}) error {
+	return &FieldError{Path: path, Err: fmt.Errorf(format, args...)}
+}
+
+// Validate checks that the document describes a valid scene. File references are not followed.
+func (d *Document) Validate() error {
+	if err := d.Camera.validate("camera"); err != nil {
+		return err
+	}
+	if d.Render != nil {
+		if err := d.Render.validate("render"); err != nil {
+			return err
+		}
+	}
+
+	for name, t := range d.Textures {
+		if err := d.validateTexture(t, "textures."+name, map[string]bool{name: true}); err != nil {
+			return err
+		}
+	}
+	for name, m := range d.Materials {
+		if err := d.validateMaterial(m, "materials."+name); err != nil {
+			return err
+		}
+	}
+
+	if len(d.World) == 0 {
+		return fieldErrorf("world", "the world is empty")
+	}
+	for i, h := range d.World {
+		if err := d.validateHitable(h, fmt.Sprintf("world[%v]", i), true); err != nil {
+			return err
+		}
+	}
+	for i, h := range d.Lights {
+		if err := d.validateHitable(h, fmt.Sprintf("lights[%v]", i), false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Camera) validate(path string) error {
+	if c.LookFrom == c.LookAt {
+		return fieldErrorf(path+".lookAt", "must be different from lookFrom")
+	}
+	if c.VUp == (Vec3{}) {
+		return fieldErrorf(path+".vup", "must not be zero")
+	}
+	if c.VFov <= 0 || c.VFov >= 180 {
+		return fieldErrorf(path+".vfov", "must be between 0 and 180 degrees, got %v", c.VFov)
+	}
+	if c.Aperture < 0 {
+		return fieldErrorf(path+".aperture", "must not be negative, got %v", c.Aperture)
+	}
+	if c.FocusDist <= 0 {
+		return fieldErrorf(path+".focusDist", "must be positive, got %v", c.FocusDist)
+	}
+
+	return validateInterval(c.Time, path+".time")
+}
+
+func validateInterval(i *Interval, path string) error {
+	if i != nil && i[1] < i[0] {
+		return fieldErrorf(path, "the end is before the start")
+	}
+
+	return nil
+}
+
+func (r *Render) validate(path string) error {
+	for _, v := range []struct {
+		name  string
+		value int
+	}{{"width", r.Width}, {"height", r.Height}, {"samples", r.Samples}, {"maxDepth", r.MaxDepth}} {
+		if v.value < 0 {
+			return fieldErrorf(path+"."+v.name, "must not be negative, got %v", v.value)
+		}
+	}
+	if r.Integrator != "" && !integrators[r.Integrator] {
+		return fieldErrorf(path+".integrator", "unknown integrator %q", r.Integrator)
+	}
+	if r.Sampler != "" && !samplers[r.Sampler] {
+		return fieldErrorf(path+".sampler", "unknown sampler %q", r.Sampler)
+	}
+
+	return nil
+}
+
+// validateTexture checks a texture. seen holds the names of the textures that reference it
+// so that cycles are detected.
+func (d *Document) validateTexture(t *Texture, path string, seen map[string]bool) error {
+	if t == nil {
+		return fieldErrorf(path, "missing texture")
+	}
+
+	switch t.Type {
+	case "constant":
+		if t.Color == nil {
+			return fieldErrorf(path+".color", "missing color")
+		}
+	case "checker":
+		for _, ref := range []struct {
+			field string
+			name  string
+		}{{"odd", t.Odd}, {"even", t.Even}} {
+			child, ok := d.Textures[ref.name]
+			if !ok {
+				return fieldErrorf(path+"."+ref.field, "unknown texture %q", ref.name)
+			}
+			if seen[ref.name] {
+				return fieldErrorf(path+"."+ref.field, "texture %q references itself", ref.name)
+			}
+			seen[ref.name] = true
+			err := d.validateTexture(child, "textures."+ref.name, seen)
+			delete(seen, ref.name)
+			if err != nil {
+				return err
+			}
+		}
+	case "noise":
+	case "image":
+		if t.File == "" {
+			return fieldErrorf(path+".file", "missing file name")
+		}
+	default:
+		return fieldErrorf(path+".type", "unknown texture type %q", t.Type)
+	}
+
+	return nil
+}
+
+func (d *Document) validateMaterial(m *Material, path string) error {
+	if m == nil {
+		return fieldErrorf(path, "missing material")
+	}
+
+	switch m.Type {
+	case "lambertian", "diffuse_light", "isotropic":
+		return d.validateAlbedo(m.Color, m.Texture, path)
+	case "metal":
+		if m.Color == nil {
+			return fieldErrorf(path+".color", "missing color")
+		}
+		if m.Fuzz < 0 {
+			return fieldErrorf(path+".fuzz", "must not be negative, got %v", m.Fuzz)
+		}
+	case "dielectric":
+		if m.RefIdx <= 0 {
+			return fieldErrorf(path+".refIdx", "must be positive, got %v", m.RefIdx)
+		}
+	default:
+		return fieldErrorf(path+".type", "unknown material type %q", m.Type)
+	}
+
+	return nil
+}
+
+// validateAlbedo checks that exactly one of a color or a texture name is set.
+func (d *Document) validateAlbedo(color *Vec3, texture string, path string) error {
+	switch {
+	case color != nil && texture != "":
+		return fieldErrorf(path, "color and texture are mutually exclusive")
+	case color == nil && texture == "":
+		return fieldErrorf(path, "missing color or texture")
+	case texture != "":
+		if _, ok := d.Textures[texture]; !ok {
+			return fieldErrorf(path+".texture", "unknown texture %q", texture)
+		}
+	}
+
+	return nil
+}
+
+// validateHitable checks a hitable. Primitives must have a material when needsMaterial is set.
+func (d *Document) validateHitable(h *Hitable, path string, needsMaterial bool) error {
+	if h == nil {
+		return fieldErrorf(path, "missing hitable")
+	}
+
+	if h.Material != "" {
+		if _, ok := d.Materials[h.Material]; !ok {
+			return fieldErrorf(path+".material", "unknown material %q", h.Material)
+		}
+	}
+	checkMaterial := func() error {
+		if needsMaterial && h.Material == "" {
+			return fieldErrorf(path+".material", "missing material")
+		}
+		return nil
+	}
+	ordered := func(field0 string, v0 float64, field1 string, v1 float64) error {
+		if v0 >= v1 {
+			return fieldErrorf(path+"."+field1, "must be greater than %v, got %v", field0, v1)
+		}
+		return nil
+	}
+
+	switch h.Type {
+	case "sphere":
+		if h.Center == nil {
+			return fieldErrorf(path+".center", "missing center")
+		}
+		if h.Radius <= 0 {
+			return fieldErrorf(path+".radius", "must be positive, got %v", h.Radius)
+		}
+		if err := validateInterval(h.Time, path+".time"); err != nil {
+			return err
+		}
+		return checkMaterial()
+
+	case "xy_rect", "xz_rect", "yz_rect":
+		ranges := map[byte]struct {
+			v0 float64
+			v1 float64
+		}{'x': {h.X0, h.X1}, 'y': {h.Y0, h.Y1}, 'z': {h.Z0, h.Z1}}
+		for _, axis := range []byte{h.Type[0], h.Type[1]} {
+			r := ranges[axis]
+			if err := ordered(string(axis)+"0", r.v0, string(axis)+"1", r.v1); err != nil {
+				return err
+			}
+		}
+		return checkMaterial()
+
+	case "box":
+		if h.Min == nil || h.Max == nil {
+			return fieldErrorf(path, "missing min or max")
+		}
+		for i, axis := range []string{"x", "y", "z"} {
+			if h.Min[i] > h.Max[i] {
+				return fieldErrorf(path+".max", "%v must not be less than min", axis)
+			}
+		}
+		return checkMaterial()
+
+	case "triangle":
+		if len(h.Vertices) != 3 {
+			return fieldErrorf(path+".vertices", "triangles have 3 vertices, got %v", len(h.Vertices))
+		}
+		return checkMaterial()
+
+	case "mesh":
+		if len(h.Vertices) == 0 {
+			return fieldErrorf(path+".vertices", "missing vertices")
+		}
+		if len(h.Indices) == 0 || len(h.Indices)%3 != 0 {
+			return fieldErrorf(path+".indices", "the number of indices must be a positive multiple of 3, got %v", len(h.Indices))
+		}
+		for i, index := range h.Indices {
+			if index < 0 || index >= len(h.Vertices) {
+				return fieldErrorf(fmt.Sprintf("%v.indices[%v]", path, i), "index %v is out of range", index)
+			}
+		}
+		if len(h.Normals) != 0 && len(h.Normals) != len(h.Vertices) {
+			return fieldErrorf(path+".normals", "got %v normals for %v vertices", len(h.Normals), len(h.Vertices))
+		}
+		if len(h.UVs) != 0 && len(h.UVs) != len(h.Vertices) {
+			return fieldErrorf(path+".uvs", "got %v texture coordinates for %v vertices", len(h.UVs), len(h.Vertices))
+		}
+		return checkMaterial()
+
+	case "model":
+		if h.File == "" {
+			return fieldErrorf(path+".file", "missing file name")
+		}
+		if _, err := modelFormat(h.File); err != nil {
+			return &FieldError{Path: path + ".file", Err: err}
+		}
+
+	case "flip_normals", "rotate_y":
+		return d.validateHitable(h.Hitable, path+".hitable", needsMaterial)
+
+	case "translate":
+		if h.Offset == nil {
+			return fieldErrorf(path+".offset", "missing offset")
+		}
+		return d.validateHitable(h.Hitable, path+".hitable", needsMaterial)
+
+	case "constant_medium":
+		if h.Density <= 0 {
+			return fieldErrorf(path+".density", "must be positive, got %v", h.Density)
+		}
+		if err := d.validateAlbedo(h.Color, h.Texture, path); err != nil {
+			return err
+		}
+		// Only the shape of the boundary is used.
+		return d.validateHitable(h.Hitable, path+".hitable", false)
+
+	case "bvh":
+		if len(h.Hitables) == 0 {
+			return fieldErrorf(path+".hitables", "BVHs need at least one hitable")
+		}
+		if err := validateInterval(h.Time, path+".time"); err != nil {
+			return err
+		}
+		for i, child := range h.Hitables {
+			if err := d.validateHitable(child, fmt.Sprintf("%v.hitables[%v]", path, i), needsMaterial); err != nil {
+				return err
+			}
+		}
+
+	default:
+		return fieldErrorf(path+".type", "unknown hitable type %q", h.Type)
+	}
+
+	return nil
+}
+
+// Model file formats.
+const (
+	formatOBJ = iota
+	formatPLY
+	formatGLTF
+)
+
+// modelFormat returns the format of a model file based on its extension.
+func modelFormat(fileName string) (int, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".obj":
+		return formatOBJ, nil
+	case ".ply":
+		return formatPLY, nil
+	case ".gltf", ".glb":
+		return formatGLTF, nil
+	default:
+		return 0, errors.New("unsupported model format, expected .obj, .ply, .gltf or .glb")
+	}
+}
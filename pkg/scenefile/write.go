@@ -0,0 +1,29 @@
+package scenefile
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// Save writes the scene description to fileName.
+func Save(fileName string, d *Document) error {
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+
+	if err := Write(f, d); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Write encodes the scene description as indented JSON.
+func Write(w io.Writer, d *Document) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
@@ -0,0 +1,61 @@
+package scenes
+
+import (
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scene"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scenefile"
+)
+
+// Entry represents a registered scene.
+type Entry struct {
+	// Name identifies the scene on the command line.
+	Name string
+	// Description is a one line summary of the scene.
+	Description string
+	// Document returns the description of the scene including its default camera.
+	// Some scenes use the global random number generator.
+	Document func() *scenefile.Document
+}
+
+// Scene builds the scene using the given aspect ratio.
+func (e *Entry) Scene(aspect float64) (*scene.Scene, error) {
+	return e.Document().Scene(aspect)
+}
+
+// DefaultScene is the name of the scene rendered when none is selected.
+const DefaultScene = "cornell-box"
+
+var registry = []*Entry{
+	{Name: "random", Description: "the random spheres from the cover of the first book", Document: RandomSceneDocument},
+	{Name: "two-spheres", Description: "two checkered spheres", Document: TwoSpheresDocument},
+	{Name: "two-perlin-spheres", Description: "two spheres with Perlin noise", Document: TwoPerlinSpheresDocument},
+	{Name: "earth", Description: "a texture mapped representation of Earth", Document: TextureMappedSphereDocument},
+	{Name: "simple-light", Description: "two spheres with Perlin noise lit by a sphere and a rectangle", Document: SimpleLightDocument},
+	{Name: DefaultScene, Description: "the Cornell box with a glass sphere", Document: CornellBoxDocument},
+	{Name: "final", Description: "the scene from the last chapter of the second book", Document: FinalDocument},
+}
+
+// Entries returns the registered scenes.
+func Entries() []*Entry {
+	return registry
+}
+
+// Names returns the names of the registered scenes.
+func Names() []string {
+	names := make([]string, len(registry))
+	for i, e := range registry {
+		names[i] = e.Name
+	}
+
+	return names
+}
+
+// Lookup returns the scene registered with the given name.
+func Lookup(name string) (*Entry, bool) {
+	for _, e := range registry {
+		if e.Name == name {
+			return e, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,32 @@
+package scenes
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	rand.Seed(0)
+	seen := make(map[string]bool)
+	for _, name := range Names() {
+		if seen[name] {
+			t.Errorf("scene %q is registered twice", name)
+		}
+		seen[name] = true
+
+		e, ok := Lookup(name)
+		if !ok {
+			t.Fatalf("Lookup(%q) failed", name)
+		}
+		if err := e.Document().Validate(); err != nil {
+			t.Errorf("scene %q is invalid; %v", name, err)
+		}
+	}
+
+	if _, ok := Lookup(DefaultScene); !ok {
+		t.Errorf("the default scene %q is not registered", DefaultScene)
+	}
+	if _, ok := Lookup("teapot"); ok {
+		t.Errorf("Lookup(%q) succeeded", "teapot")
+	}
+}
@@ -2,152 +2,245 @@
 package scenes
 
 import (
+	"fmt"
 	"log"
+	"math"
 	"math/rand"
-	"os"
 
-	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/camera"
-	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitable"
-	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/material"
-	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/texture"
-	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scene"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/scenefile"
 )
 
-// RandomScene returns a random scene.
-func RandomScene() *hitable.HitableSlice {
-	checker := texture.NewChecker(texture.NewConstant(&vec3.Vec3Impl{X: 0.2, Y: 0.3, Z: 0.1}),
-		texture.NewConstant(&vec3.Vec3Impl{X: 0.9, Y: 0.9, Z: 0.9}))
-	spheres := []hitable.Hitable{hitable.NewSphere(&vec3.Vec3Impl{X: 0, Y: -1000, Z: 0}, &vec3.Vec3Impl{X: 0, Y: -1000, Z: 0}, 0, 1, 1000, material.NewLambertian(checker))}
+// earthFile is the texture used by the scenes that contain a representation of Earth.
+const earthFile = "../images/earth.png"
+
+// build returns the scene described by the document.
+func build(d *scenefile.Document, aspect float64) *scene.Scene {
+	s, err := d.Scene(aspect)
+	if err != nil {
+		log.Fatalf("failed to build scene; %v", err)
+	}
+
+	return s
+}
+
+// bookCamera returns the camera used by most of the scenes in the book.
+func bookCamera(lookFrom scenefile.Vec3, lookAt scenefile.Vec3, vfov float64) scenefile.Camera {
+	return scenefile.Camera{
+		LookFrom:  lookFrom,
+		LookAt:    lookAt,
+		VUp:       scenefile.Vec3{0, 1, 0},
+		VFov:      vfov,
+		FocusDist: 10,
+	}
+}
+
+func sphere(center scenefile.Vec3, radius float64, mat string) *scenefile.Hitable {
+	return &scenefile.Hitable{Type: "sphere", Center: &center, Radius: radius, Material: mat}
+}
+
+func flip(h *scenefile.Hitable) *scenefile.Hitable {
+	return &scenefile.Hitable{Type: "flip_normals", Hitable: h}
+}
+
+// checkerTextures returns the textures of the checkered spheres.
+func checkerTextures() map[string]*scenefile.Texture {
+	return map[string]*scenefile.Texture{
+		"checker": {Type: "checker", Odd: "dark", Even: "light"},
+		"dark":    {Type: "constant", Color: &scenefile.Vec3{0.2, 0.3, 0.1}},
+		"light":   {Type: "constant", Color: &scenefile.Vec3{0.9, 0.9, 0.9}},
+	}
+}
+
+// RandomSceneDocument returns the description of a random scene.
+func RandomSceneDocument() *scenefile.Document {
+	d := &scenefile.Document{
+		Camera:   bookCamera(scenefile.Vec3{13, 2, 3}, scenefile.Vec3{}, 20),
+		Textures: checkerTextures(),
+		Materials: map[string]*scenefile.Material{
+			"ground": {Type: "lambertian", Texture: "checker"},
+			"glass":  {Type: "dielectric", RefIdx: 1.5},
+		},
+		World: []*scenefile.Hitable{sphere(scenefile.Vec3{0, -1000, 0}, 1000, "ground")},
+	}
+
 	for a := -11; a < 11; a++ {
 		for b := -11; b < 11; b++ {
 			chooseMat := rand.Float64()
-			center := &vec3.Vec3Impl{X: float64(a) + 0.9*rand.Float64(), Y: 0.2, Z: float64(b) + 0.9*rand.Float64()}
-			if vec3.Sub(center, &vec3.Vec3Impl{X: 4, Y: 0.2, Z: 0}).Length() > 0.9 {
-				if chooseMat < 0.8 {
-					// diffuse
-					spheres = append(spheres, hitable.NewSphere(center,
-						vec3.Add(center, &vec3.Vec3Impl{Y: 0.5 * rand.Float64()}), 0.0, 1.0, 0.2,
-						material.NewLambertian(texture.NewConstant(&vec3.Vec3Impl{
-							X: rand.Float64() * rand.Float64(),
-							Y: rand.Float64() * rand.Float64(),
-							Z: rand.Float64() * rand.Float64(),
-						}))))
-				} else if chooseMat < 0.95 {
-					// metal
-					spheres = append(spheres, hitable.NewSphere(center, center, 0.0, 1.0, 0.2,
-						material.NewMetal(&vec3.Vec3Impl{
-							X: 0.5 * (1.0 - rand.Float64()),
-							Y: 0.5 * (1.0 - rand.Float64()),
-							Z: 0.5 * (1.0 - rand.Float64()),
-						}, 0.2*rand.Float64())))
-				} else {
-					// glass
-					spheres = append(spheres, hitable.NewSphere(center, center, 0.0, 1.0, 0.2, material.NewDielectric(1.5)))
-				}
+			center := scenefile.Vec3{float64(a) + 0.9*rand.Float64(), 0.2, float64(b) + 0.9*rand.Float64()}
+			dx, dz := center[0]-4, center[2]
+			if math.Sqrt(dx*dx+dz*dz) <= 0.9 {
+				continue
 			}
+			name := fmt.Sprintf("sphere%v", len(d.World))
+			s := sphere(center, 0.2, name)
+			if chooseMat < 0.8 {
+				// diffuse
+				s.Center1 = &scenefile.Vec3{center[0], center[1] + 0.5*rand.Float64(), center[2]}
+				d.Materials[name] = &scenefile.Material{Type: "lambertian", Color: &scenefile.Vec3{
+					rand.Float64() * rand.Float64(),
+					rand.Float64() * rand.Float64(),
+					rand.Float64() * rand.Float64(),
+				}}
+			} else if chooseMat < 0.95 {
+				// metal
+				color := scenefile.Vec3{0.5 * (1.0 - rand.Float64()), 0.5 * (1.0 - rand.Float64()), 0.5 * (1.0 - rand.Float64())}
+				d.Materials[name] = &scenefile.Material{Type: "metal", Color: &color, Fuzz: 0.2 * rand.Float64()}
+			} else {
+				// glass
+				s.Material = "glass"
+			}
+			d.World = append(d.World, s)
 		}
 	}
 
-	spheres = append(spheres, hitable.NewSphere(&vec3.Vec3Impl{Y: 1.0}, &vec3.Vec3Impl{Y: 1.0}, 0.0, 1.0, 1.0, material.NewDielectric(1.5)))
-	spheres = append(spheres, hitable.NewSphere(&vec3.Vec3Impl{X: -4.0, Y: 1.0}, &vec3.Vec3Impl{X: -4.0, Y: 1.0}, 0.0, 1.0, 1.0, material.NewLambertian(texture.NewConstant(&vec3.Vec3Impl{X: 0.4, Y: 0.2, Z: 0.1}))))
-	spheres = append(spheres, hitable.NewSphere(&vec3.Vec3Impl{X: 4.0, Y: 1.0}, &vec3.Vec3Impl{X: 4.0, Y: 1.0}, 0.0, 1.0, 1.0, material.NewMetal(&vec3.Vec3Impl{X: 0.7, Y: 0.6, Z: 0.5}, 0.0)))
+	d.Materials["brown"] = &scenefile.Material{Type: "lambertian", Color: &scenefile.Vec3{0.4, 0.2, 0.1}}
+	d.Materials["mirror"] = &scenefile.Material{Type: "metal", Color: &scenefile.Vec3{0.7, 0.6, 0.5}}
+	d.World = append(d.World,
+		sphere(scenefile.Vec3{0, 1, 0}, 1, "glass"),
+		sphere(scenefile.Vec3{-4, 1, 0}, 1, "brown"),
+		sphere(scenefile.Vec3{4, 1, 0}, 1, "mirror"))
 
-	return hitable.NewSlice(spheres)
+	return d
 }
 
-// TwoSpheres returns a scene containing two spheres.
-func TwoSpheres() *hitable.HitableSlice {
-	checker := texture.NewChecker(texture.NewConstant(&vec3.Vec3Impl{X: 0.2, Y: 0.3, Z: 0.1}),
-		texture.NewConstant(&vec3.Vec3Impl{X: 0.9, Y: 0.9, Z: 0.9}))
-	spheres := []hitable.Hitable{
-		hitable.NewSphere(&vec3.Vec3Impl{X: 0, Y: -10, Z: 0}, &vec3.Vec3Impl{X: 0, Y: -10, Z: 0}, 0, 1, 10, material.NewLambertian(checker)),
-		hitable.NewSphere(&vec3.Vec3Impl{X: 0, Y: 10, Z: 0}, &vec3.Vec3Impl{X: 0, Y: 10, Z: 0}, 0, 1, 10, material.NewLambertian(checker)),
+// RandomScene returns a random scene.
+func RandomScene(aspect float64) *scene.Scene {
+	return build(RandomSceneDocument(), aspect)
+}
+
+// TwoSpheresDocument returns the description of a scene containing two spheres.
+func TwoSpheresDocument() *scenefile.Document {
+	return &scenefile.Document{
+		Camera:   bookCamera(scenefile.Vec3{13, 2, 3}, scenefile.Vec3{}, 20),
+		Textures: checkerTextures(),
+		Materials: map[string]*scenefile.Material{
+			"checker": {Type: "lambertian", Texture: "checker"},
+		},
+		World: []*scenefile.Hitable{
+			sphere(scenefile.Vec3{0, -10, 0}, 10, "checker"),
+			sphere(scenefile.Vec3{0, 10, 0}, 10, "checker"),
+		},
 	}
+}
 
-	return hitable.NewSlice(spheres)
+// TwoSpheres returns a scene containing two spheres.
+func TwoSpheres(aspect float64) *scene.Scene {
+	return build(TwoSpheresDocument(), aspect)
 }
 
-// TwoPerlinSpheres returns a scene containing two spheres with Perlin noise.
-func TwoPerlinSpheres() *hitable.HitableSlice {
-	perText := texture.NewNoise(4.0)
-	spheres := []hitable.Hitable{
-		hitable.NewSphere(&vec3.Vec3Impl{X: 0, Y: -1000, Z: 0}, &vec3.Vec3Impl{X: 0, Y: -1000, Z: 0}, 0, 1, 1000, material.NewLambertian(perText)),
-		hitable.NewSphere(&vec3.Vec3Impl{X: 0, Y: 2, Z: 0}, &vec3.Vec3Impl{X: 0, Y: 2, Z: 0}, 0, 1, 2, material.NewLambertian(perText)),
+// TwoPerlinSpheresDocument returns the description of a scene containing two spheres with Perlin noise.
+func TwoPerlinSpheresDocument() *scenefile.Document {
+	return &scenefile.Document{
+		Camera: bookCamera(scenefile.Vec3{13, 2, 3}, scenefile.Vec3{}, 20),
+		Textures: map[string]*scenefile.Texture{
+			"noise": {Type: "noise", Scale: 4},
+		},
+		Materials: map[string]*scenefile.Material{
+			"noise": {Type: "lambertian", Texture: "noise"},
+		},
+		World: []*scenefile.Hitable{
+			sphere(scenefile.Vec3{0, -1000, 0}, 1000, "noise"),
+			sphere(scenefile.Vec3{0, 2, 0}, 2, "noise"),
+		},
 	}
+}
 
-	return hitable.NewSlice(spheres)
+// TwoPerlinSpheres returns a scene containing two spheres with Perlin noise.
+func TwoPerlinSpheres(aspect float64) *scene.Scene {
+	return build(TwoPerlinSpheresDocument(), aspect)
 }
 
-// TextureMappedSphere returns a scene containing a representation of Earth.
-func TextureMappedSphere() *hitable.HitableSlice {
-	file, err := os.Open("../images/earth.png")
-	if err != nil {
-		log.Fatalf("could not read texture file; %v", err)
-	}
-	imgText, err := texture.NewFromPNG(file)
-	if err != nil {
-		log.Fatalf("failed to decode image; %v", err)
-	}
-	spheres := []hitable.Hitable{
-		hitable.NewSphere(&vec3.Vec3Impl{X: 0, Y: 0, Z: 0}, &vec3.Vec3Impl{X: 0, Y: 0, Z: 0}, 0, 1, 1, material.NewLambertian(imgText)),
+// TextureMappedSphereDocument returns the description of a scene containing a representation of Earth.
+func TextureMappedSphereDocument() *scenefile.Document {
+	return &scenefile.Document{
+		Camera: bookCamera(scenefile.Vec3{13, 2, 3}, scenefile.Vec3{}, 20),
+		Textures: map[string]*scenefile.Texture{
+			"earth": {Type: "image", File: earthFile},
+		},
+		Materials: map[string]*scenefile.Material{
+			"earth": {Type: "lambertian", Texture: "earth"},
+		},
+		World: []*scenefile.Hitable{
+			sphere(scenefile.Vec3{0, 0, 0}, 1, "earth"),
+		},
 	}
+}
 
-	return hitable.NewSlice(spheres)
+// TextureMappedSphere returns a scene containing a representation of Earth.
+func TextureMappedSphere(aspect float64) *scene.Scene {
+	return build(TextureMappedSphereDocument(), aspect)
 }
 
-// SimpleLight returns a scene containing three spheres and a rectangle.
-func SimpleLight() *hitable.HitableSlice {
-	perText := texture.NewNoise(4.0)
-	hitables := []hitable.Hitable{
-		hitable.NewSphere(&vec3.Vec3Impl{Y: -1000}, &vec3.Vec3Impl{Y: -1000}, 0, 1, 1000, material.NewLambertian(perText)),
-		hitable.NewSphere(&vec3.Vec3Impl{Y: 2}, &vec3.Vec3Impl{Y: 2}, 0, 1, 2, material.NewLambertian(perText)),
-		hitable.NewSphere(&vec3.Vec3Impl{Y: 7}, &vec3.Vec3Impl{Y: 7}, 0, 1, 2, material.NewDiffuseLight(texture.NewConstant(&vec3.Vec3Impl{X: 4, Y: 4, Z: 4}))),
-		hitable.NewXYRect(3, 5, 1, 3, -2, material.NewDiffuseLight(texture.NewConstant(&vec3.Vec3Impl{X: 4, Y: 4, Z: 4}))),
+// SimpleLightDocument returns the description of a scene containing three spheres and a rectangle.
+func SimpleLightDocument() *scenefile.Document {
+	lightSphere := sphere(scenefile.Vec3{0, 7, 0}, 2, "light")
+	lightRect := &scenefile.Hitable{Type: "xy_rect", X0: 3, X1: 5, Y0: 1, Y1: 3, K: -2, Material: "light"}
+
+	return &scenefile.Document{
+		Camera: bookCamera(scenefile.Vec3{26, 3, 6}, scenefile.Vec3{0, 2, 0}, 20),
+		Textures: map[string]*scenefile.Texture{
+			"noise": {Type: "noise", Scale: 4},
+		},
+		Materials: map[string]*scenefile.Material{
+			"noise": {Type: "lambertian", Texture: "noise"},
+			"light": {Type: "diffuse_light", Color: &scenefile.Vec3{4, 4, 4}},
+		},
+		World: []*scenefile.Hitable{
+			sphere(scenefile.Vec3{0, -1000, 0}, 1000, "noise"),
+			sphere(scenefile.Vec3{0, 2, 0}, 2, "noise"),
+			lightSphere,
+			lightRect,
+		},
+		Lights: []*scenefile.Hitable{lightSphere, lightRect},
 	}
+}
 
-	return hitable.NewSlice(hitables)
+// SimpleLight returns a scene containing three spheres and a rectangle.
+func SimpleLight(aspect float64) *scene.Scene {
+	return build(SimpleLightDocument(), aspect)
 }
 
-// CornellBox returns a scene recreating the Cornell box.
-func CornellBox(aspect float64) (*hitable.HitableSlice, *camera.Camera) {
-	red := material.NewLambertian(texture.NewConstant(&vec3.Vec3Impl{X: 0.65, Y: 0.05, Z: 0.05}))
-	white := material.NewLambertian(texture.NewConstant(&vec3.Vec3Impl{X: 0.73, Y: 0.73, Z: 0.73}))
-	green := material.NewLambertian(texture.NewConstant(&vec3.Vec3Impl{X: 0.12, Y: 0.45, Z: 0.15}))
-	light := material.NewDiffuseLight(texture.NewConstant(&vec3.Vec3Impl{X: 15, Y: 15, Z: 15}))
-	glass := material.NewDielectric(1.5)
-	hitables := []hitable.Hitable{
-		hitable.NewFlipNormals(hitable.NewYZRect(0, 555, 0, 555, 555, green)),
-		hitable.NewYZRect(0, 555, 0, 555, 0, red),
-		hitable.NewFlipNormals(hitable.NewXZRect(213, 343, 227, 332, 554, light)),
-		hitable.NewFlipNormals(hitable.NewXZRect(0, 555, 0, 555, 555, white)),
-		hitable.NewXZRect(0, 555, 0, 555, 0, white),
-		hitable.NewFlipNormals(hitable.NewXYRect(0, 555, 0, 555, 555, white)),
-		hitable.NewSphere(&vec3.Vec3Impl{X: 190, Y: 90, Z: 190}, &vec3.Vec3Impl{X: 190, Y: 90, Z: 190}, 0, 1, 90, glass),
-		hitable.NewTranslate(hitable.NewRotateY(hitable.NewBox(&vec3.Vec3Impl{X: 0, Y: 0, Z: 0}, &vec3.Vec3Impl{X: 165, Y: 330, Z: 165}, white), 15), &vec3.Vec3Impl{X: 265, Y: 0, Z: 295}),
+// CornellBoxDocument returns the description of the Cornell box scene.
+func CornellBoxDocument() *scenefile.Document {
+	return &scenefile.Document{
+		Camera: bookCamera(scenefile.Vec3{278, 278, -800}, scenefile.Vec3{278, 278, 0}, 40),
+		Materials: map[string]*scenefile.Material{
+			"red":   {Type: "lambertian", Color: &scenefile.Vec3{0.65, 0.05, 0.05}},
+			"white": {Type: "lambertian", Color: &scenefile.Vec3{0.73, 0.73, 0.73}},
+			"green": {Type: "lambertian", Color: &scenefile.Vec3{0.12, 0.45, 0.15}},
+			"light": {Type: "diffuse_light", Color: &scenefile.Vec3{15, 15, 15}},
+			"glass": {Type: "dielectric", RefIdx: 1.5},
+		},
+		World: []*scenefile.Hitable{
+			flip(&scenefile.Hitable{Type: "yz_rect", Y0: 0, Y1: 555, Z0: 0, Z1: 555, K: 555, Material: "green"}),
+			{Type: "yz_rect", Y0: 0, Y1: 555, Z0: 0, Z1: 555, K: 0, Material: "red"},
+			flip(&scenefile.Hitable{Type: "xz_rect", X0: 213, X1: 343, Z0: 227, Z1: 332, K: 554, Material: "light"}),
+			flip(&scenefile.Hitable{Type: "xz_rect", X0: 0, X1: 555, Z0: 0, Z1: 555, K: 555, Material: "white"}),
+			{Type: "xz_rect", X0: 0, X1: 555, Z0: 0, Z1: 555, K: 0, Material: "white"},
+			flip(&scenefile.Hitable{Type: "xy_rect", X0: 0, X1: 555, Y0: 0, Y1: 555, K: 555, Material: "white"}),
+			sphere(scenefile.Vec3{190, 90, 190}, 90, "glass"),
+			{Type: "translate", Offset: &scenefile.Vec3{265, 0, 295}, Hitable: &scenefile.Hitable{
+				Type: "rotate_y", Angle: 15, Hitable: &scenefile.Hitable{
+					Type: "box", Min: &scenefile.Vec3{0, 0, 0}, Max: &scenefile.Vec3{165, 330, 165}, Material: "white"}}},
+		},
+		Lights: []*scenefile.Hitable{
+			{Type: "xz_rect", X0: 213, X1: 343, Z0: 227, Z1: 332, K: 554},
+			sphere(scenefile.Vec3{190, 90, 190}, 90, ""),
+		},
 	}
+}
 
-	lookFrom := &vec3.Vec3Impl{X: 278.0, Y: 278.0, Z: -800.0}
-	lookAt := &vec3.Vec3Impl{X: 278, Y: 278, Z: 0}
-	vup := &vec3.Vec3Impl{Y: 1}
-	distToFocus := 10.0
-	aperture := 0.0
-	vfov := float64(40.0)
-	time0 := 0.0
-	time1 := 1.0
-	cam := camera.New(lookFrom, lookAt, vup, vfov, aspect, aperture, distToFocus, time0, time1)
-
-	return hitable.NewSlice(hitables), cam
+// CornellBox returns a scene recreating the Cornell box.
+func CornellBox(aspect float64) *scene.Scene {
+	return build(CornellBoxDocument(), aspect)
 }
 
-// Final returns the scene from the last chapter in the book.
-func Final(aspect float64) (*hitable.HitableSlice, *camera.Camera) {
+// FinalDocument returns the description of the scene from the last chapter in the book.
+func FinalDocument() *scenefile.Document {
 	nb := 20
-	list := []hitable.Hitable{}
-	boxList := []hitable.Hitable{}
-	boxList2 := []hitable.Hitable{}
-
-	white := material.NewLambertian(texture.NewConstant(&vec3.Vec3Impl{X: 0.73, Y: 0.73, Z: 0.73}))
-	ground := material.NewLambertian(texture.NewConstant(&vec3.Vec3Impl{X: 0.48, Y: 0.83, Z: 0.53}))
+	boxList := []*scenefile.Hitable{}
+	boxList2 := []*scenefile.Hitable{}
 
 	for i := 0; i < nb; i++ {
 		for j := 0; j < nb; j++ {
@@ -158,57 +251,57 @@ func Final(aspect float64) (*hitable.HitableSlice, *camera.Camera) {
 			x1 := x0 + w
 			y1 := 100.0 * (rand.Float64() + 0.01)
 			z1 := z0 + w
-			boxList = append(boxList, hitable.NewBox(&vec3.Vec3Impl{X: x0, Y: y0, Z: z0}, &vec3.Vec3Impl{X: x1, Y: y1, Z: z1}, ground))
+			boxList = append(boxList, &scenefile.Hitable{Type: "box", Min: &scenefile.Vec3{x0, y0, z0}, Max: &scenefile.Vec3{x1, y1, z1}, Material: "ground"})
 		}
 	}
 
-	list = append(list, hitable.NewBVH(boxList, 0, 1))
-
-	light := material.NewDiffuseLight(texture.NewConstant(&vec3.Vec3Impl{X: 7, Y: 7, Z: 7}))
-	list = append(list, hitable.NewXZRect(123, 423, 147, 412, 554, light))
-
-	center := &vec3.Vec3Impl{X: 400, Y: 400, Z: 200}
-	list = append(list, hitable.NewSphere(center, vec3.Add(center, &vec3.Vec3Impl{X: 30}), 0, 1, 50, material.NewLambertian(texture.NewConstant(&vec3.Vec3Impl{X: 0.7, Y: 0.3, Z: 0.1}))))
-	list = append(list, hitable.NewSphere(&vec3.Vec3Impl{X: 260, Y: 150, Z: 45}, &vec3.Vec3Impl{X: 260, Y: 150, Z: 45}, 0, 1, 50, material.NewDielectric(1.5)))
-	list = append(list, hitable.NewSphere(&vec3.Vec3Impl{X: 0, Y: 150, Z: 145}, &vec3.Vec3Impl{X: 0, Y: 150, Z: 145}, 0, 1, 50, material.NewMetal(&vec3.Vec3Impl{X: 0.8, Y: 0.8, Z: 0.9}, 10.0)))
-
-	boundary := hitable.NewSphere(&vec3.Vec3Impl{X: 360, Y: 150, Z: 145}, &vec3.Vec3Impl{X: 360, Y: 150, Z: 145}, 0, 1, 70, material.NewDielectric(1.5))
-	list = append(list, boundary)
-	list = append(list, hitable.NewConstantMedium(boundary, 0.2, texture.NewConstant(&vec3.Vec3Impl{X: 0.2, Y: 0.4, Z: 0.9})))
-	boundary = hitable.NewSphere(&vec3.Vec3Impl{}, &vec3.Vec3Impl{}, 0, 1, 5000, material.NewDielectric(1.5))
-	list = append(list, hitable.NewConstantMedium(boundary, 0.0001, texture.NewConstant(&vec3.Vec3Impl{X: 1.0, Y: 1.0, Z: 1.0})))
-
-	file, err := os.Open("../images/earth.png")
-	if err != nil {
-		log.Fatalf("could not read texture file; %v", err)
-	}
-	imgText, err := texture.NewFromPNG(file)
-	if err != nil {
-		log.Fatalf("failed to decode image; %v", err)
-	}
-	emat := material.NewLambertian(imgText)
-	list = append(list, hitable.NewSphere(&vec3.Vec3Impl{X: 400, Y: 200, Z: 400}, &vec3.Vec3Impl{X: 400, Y: 200, Z: 400}, 0, 1, 100, emat))
-
-	perText := texture.NewNoise(0.1)
-	list = append(list, hitable.NewSphere(&vec3.Vec3Impl{X: 220, Y: 280, Z: 300}, &vec3.Vec3Impl{X: 220, Y: 280, Z: 300}, 0, 1, 80, material.NewLambertian(perText)))
-
 	ns := 1000
 	for j := 0; j < ns; j++ {
-		center := &vec3.Vec3Impl{X: 165 * rand.Float64(), Y: 165 * rand.Float64(), Z: 165 * rand.Float64()}
-		boxList2 = append(boxList2, hitable.NewSphere(center, center, 0, 1, 10, white))
+		center := scenefile.Vec3{165 * rand.Float64(), 165 * rand.Float64(), 165 * rand.Float64()}
+		boxList2 = append(boxList2, sphere(center, 10, "white"))
 	}
 
-	list = append(list, hitable.NewTranslate(hitable.NewRotateY(hitable.NewBVH(boxList2, 0, 1), 15), &vec3.Vec3Impl{X: -100, Y: 270, Z: 395}))
+	lightShape := &scenefile.Hitable{Type: "xz_rect", X0: 123, X1: 423, Z0: 147, Z1: 412, K: 554, Material: "light"}
+	movingSphere := sphere(scenefile.Vec3{400, 400, 200}, 50, "orange")
+	movingSphere.Center1 = &scenefile.Vec3{430, 400, 200}
 
-	lookFrom := &vec3.Vec3Impl{X: 478.0, Y: 278.0, Z: -600.0}
-	lookAt := &vec3.Vec3Impl{X: 278, Y: 278, Z: 0}
-	vup := &vec3.Vec3Impl{Y: 1}
-	distToFocus := 10.0
-	aperture := 0.0
-	vfov := float64(40.0)
-	time0 := 0.0
-	time1 := 1.0
-	cam := camera.New(lookFrom, lookAt, vup, vfov, aspect, aperture, distToFocus, time0, time1)
+	return &scenefile.Document{
+		Camera: bookCamera(scenefile.Vec3{478, 278, -600}, scenefile.Vec3{278, 278, 0}, 40),
+		Textures: map[string]*scenefile.Texture{
+			"earth": {Type: "image", File: earthFile},
+			"noise": {Type: "noise", Scale: 0.1},
+		},
+		Materials: map[string]*scenefile.Material{
+			"white":  {Type: "lambertian", Color: &scenefile.Vec3{0.73, 0.73, 0.73}},
+			"ground": {Type: "lambertian", Color: &scenefile.Vec3{0.48, 0.83, 0.53}},
+			"light":  {Type: "diffuse_light", Color: &scenefile.Vec3{7, 7, 7}},
+			"orange": {Type: "lambertian", Color: &scenefile.Vec3{0.7, 0.3, 0.1}},
+			"glass":  {Type: "dielectric", RefIdx: 1.5},
+			"metal":  {Type: "metal", Color: &scenefile.Vec3{0.8, 0.8, 0.9}, Fuzz: 10},
+			"earth":  {Type: "lambertian", Texture: "earth"},
+			"noise":  {Type: "lambertian", Texture: "noise"},
+		},
+		World: []*scenefile.Hitable{
+			{Type: "bvh", Hitables: boxList},
+			lightShape,
+			movingSphere,
+			sphere(scenefile.Vec3{260, 150, 45}, 50, "glass"),
+			sphere(scenefile.Vec3{0, 150, 145}, 50, "metal"),
+			sphere(scenefile.Vec3{360, 150, 145}, 70, "glass"),
+			{Type: "constant_medium", Density: 0.2, Color: &scenefile.Vec3{0.2, 0.4, 0.9},
+				Hitable: sphere(scenefile.Vec3{360, 150, 145}, 70, "glass")},
+			{Type: "constant_medium", Density: 0.0001, Color: &scenefile.Vec3{1, 1, 1},
+				Hitable: sphere(scenefile.Vec3{0, 0, 0}, 5000, "glass")},
+			sphere(scenefile.Vec3{400, 200, 400}, 100, "earth"),
+			sphere(scenefile.Vec3{220, 280, 300}, 80, "noise"),
+			{Type: "translate", Offset: &scenefile.Vec3{-100, 270, 395}, Hitable: &scenefile.Hitable{
+				Type: "rotate_y", Angle: 15, Hitable: &scenefile.Hitable{Type: "bvh", Hitables: boxList2}}},
+		},
+		Lights: []*scenefile.Hitable{lightShape},
+	}
+}
 
-	return hitable.NewSlice(list), cam
+// Final returns the scene from the last chapter in the book.
+func Final(aspect float64) *scene.Scene {
+	return build(FinalDocument(), aspect)
 }
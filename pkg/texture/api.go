@@ -1,10 +1,30 @@
 // Package texture implements different types of textures.
 package texture
 
-import "github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+import (
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitrecord"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
 
 // Texture represents a texture.
 type Texture interface {
 	// Value returns the color values at a given point.
 	Value(u float64, v float64, p *vec3.Vec3Impl) *vec3.Vec3Impl
 }
+
+// HitTexture is implemented by textures that need more of the intersection than
+// the texture coordinates and the point, such as the interpolated vertex colors.
+type HitTexture interface {
+	Texture
+	// HitValue returns the color values at the intersection.
+	HitValue(hr *hitrecord.HitRecord) *vec3.Vec3Impl
+}
+
+// ValueAt returns the color values of the texture at the intersection.
+func ValueAt(t Texture, hr *hitrecord.HitRecord) *vec3.Vec3Impl {
+	if ht, ok := t.(HitTexture); ok {
+		return ht.HitValue(hr)
+	}
+
+	return t.Value(hr.U(), hr.V(), hr.P())
+}
@@ -3,11 +3,12 @@ package texture
 import (
 	"math"
 
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitrecord"
 	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
 )
 
 // Ensure interface compliance.
-var _ Texture = (*Checker)(nil)
+var _ HitTexture = (*Checker)(nil)
 
 // Checker represents a checker board pattern texture.
 type Checker struct {
@@ -24,10 +25,22 @@ func NewChecker(odd Texture, even Texture) *Checker {
 }
 
 func (c *Checker) Value(u float64, v float64, p *vec3.Vec3Impl) *vec3.Vec3Impl {
-	sines := math.Sin(10.0*p.X) * math.Sin(10.0*p.Y) * math.Sin(10.0*p.Z)
-	if sines < 0 {
+	if c.isOdd(p) {
 		return c.odd.Value(u, v, p)
 	}
 
 	return c.even.Value(u, v, p)
 }
+
+func (c *Checker) HitValue(hr *hitrecord.HitRecord) *vec3.Vec3Impl {
+	if c.isOdd(hr.P()) {
+		return ValueAt(c.odd, hr)
+	}
+
+	return ValueAt(c.even, hr)
+}
+
+// isOdd returns whether the point is in one of the odd squares.
+func (c *Checker) isOdd(p *vec3.Vec3Impl) bool {
+	return math.Sin(10.0*p.X)*math.Sin(10.0*p.Y)*math.Sin(10.0*p.Z) < 0
+}
@@ -27,12 +27,17 @@ func NewFromPNG(r io.Reader) (*ImageTxt, error) {
 		return nil, err
 	}
 
+	return NewFromImage(img), nil
+}
+
+// NewFromImage returns a new ImageTxt instance that samples the supplied image.
+func NewFromImage(img image.Image) *ImageTxt {
 	return &ImageTxt{
 		sizeX:      img.Bounds().Max.X,
 		sizeY:      img.Bounds().Max.Y,
 		colorModel: img.Bounds().ColorModel(),
 		data:       img,
-	}, nil
+	}
 }
 
 func (it *ImageTxt) Value(u float64, v float64, p *vec3.Vec3Impl) *vec3.Vec3Impl {
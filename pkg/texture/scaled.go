@@ -0,0 +1,31 @@
+package texture
+
+import (
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitrecord"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// Ensure interface compliance.
+var _ HitTexture = (*Scaled)(nil)
+
+// Scaled represents a texture whose colors are multiplied by a constant factor.
+type Scaled struct {
+	texture Texture
+	scale   *vec3.Vec3Impl
+}
+
+// NewScaled returns an instance of the scaled texture.
+func NewScaled(texture Texture, scale *vec3.Vec3Impl) *Scaled {
+	return &Scaled{
+		texture: texture,
+		scale:   scale,
+	}
+}
+
+func (s *Scaled) Value(u float64, v float64, p *vec3.Vec3Impl) *vec3.Vec3Impl {
+	return vec3.Mul(s.texture.Value(u, v, p), s.scale)
+}
+
+func (s *Scaled) HitValue(hr *hitrecord.HitRecord) *vec3.Vec3Impl {
+	return vec3.Mul(ValueAt(s.texture, hr), s.scale)
+}
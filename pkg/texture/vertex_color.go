@@ -0,0 +1,34 @@
+package texture
+
+import (
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/hitrecord"
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/vec3"
+)
+
+// Ensure interface compliance.
+var _ HitTexture = (*VertexColor)(nil)
+
+// VertexColor represents the vertex colors of a mesh. It returns the color that the mesh
+// interpolated at the intersection, or the fallback color for objects without vertex colors.
+type VertexColor struct {
+	fallback *vec3.Vec3Impl
+}
+
+// NewVertexColor returns an instance of the vertex color texture.
+func NewVertexColor(fallback *vec3.Vec3Impl) *VertexColor {
+	return &VertexColor{
+		fallback: fallback,
+	}
+}
+
+func (vc *VertexColor) Value(_ float64, _ float64, _ *vec3.Vec3Impl) *vec3.Vec3Impl {
+	return vc.fallback
+}
+
+func (vc *VertexColor) HitValue(hr *hitrecord.HitRecord) *vec3.Vec3Impl {
+	if c := hr.Color(); c != nil {
+		return c
+	}
+
+	return vc.fallback
+}
@@ -3,7 +3,8 @@ package vec3
 
 import (
 	"math"
-	"math/rand"
+
+	"github.com/flynn-nrg/ray-tracing-the-rest-of-your-life/pkg/sampler"
 )
 
 // Vec3Impl defines a vector with its position and colour.
@@ -124,20 +125,18 @@ func UnitVector(v *Vec3Impl) *Vec3Impl {
 }
 
 // RandomCosineDirection returns a vector with a random cosine direction.
-func RandomCosineDirection() *Vec3Impl {
-	r1 := rand.Float64()
-	r2 := rand.Float64()
+func RandomCosineDirection(sampler sampler.Sampler) *Vec3Impl {
+	r1, r2 := sampler.Get2D()
 	z := math.Sqrt(1 - r2)
 	phi := 2 * math.Pi * r1
-	x := math.Cos(phi) * 2 * math.Sqrt(r2)
-	y := math.Sin(phi) * 2 * math.Sqrt(r2)
+	x := math.Cos(phi) * math.Sqrt(r2)
+	y := math.Sin(phi) * math.Sqrt(r2)
 	return &Vec3Impl{X: x, Y: y, Z: z}
 }
 
 // RandomToSphere returns a new random sphere of the given radius at the given distance.
-func RandomToSphere(radius float64, distanceSquared float64) *Vec3Impl {
-	r1 := rand.Float64()
-	r2 := rand.Float64()
+func RandomToSphere(radius float64, distanceSquared float64, sampler sampler.Sampler) *Vec3Impl {
+	r1, r2 := sampler.Get2D()
 	z := 1 + r2*(math.Sqrt(1-radius*radius/distanceSquared)-1)
 	phi := 2 * math.Pi * r1
 	x := math.Cos(phi) * math.Sqrt(1-z*z)